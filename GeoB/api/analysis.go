@@ -0,0 +1,216 @@
+// analysis.go
+//
+// Cross-type spatial analysis endpoints for GeoGO
+// Compliance Level: High
+//
+// - Route: /analysis/join?left=&right=&predicate=within|dwithin&distance=
+// - mode=records (default) returns left rows that match, each with its
+//   matching right rows under "matches"
+// - mode=count returns every right row with the number of matching left rows
+//   under "count", busiest first (e.g. meteorites per vegetation zone)
+// - The left side takes every dataset filter (bbox, location/radius,
+//   value_min/value_max, POSTed polygon); limit/offset page the results
+//   (default limit 50)
+// - A records page matching more than db.MaxJoinPairs pairs is rejected with
+//   413; lower limit or distance
+// - Supports format=geojson like the list endpoints
+//
+// Parameters:
+//   - left, right: Dataset types (required)
+//   - predicate: within (left inside a right polygon, default) | dwithin
+//   - distance: Metres, required for dwithin (at most maxJoinDistance)
+//   - mode: records | count
+//
+// NOTE: Left rows without a match are left out of mode=records
+
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxJoinDistance caps the dwithin distance (metres) to keep joins bounded.
+const maxJoinDistance = 100000.0
+
+// joinMatch is one right row matched by a left row.
+type joinMatch struct {
+	ID        int      `json:"id"`
+	Name      string   `json:"name"`
+	Value     *float64 `json:"value,omitempty"`
+	Unit      string   `json:"unit,omitempty"`
+	DistanceM *float64 `json:"distance_m,omitempty"`
+}
+
+// joinRecord is a left row with its matches.
+type joinRecord struct {
+	dataset models.Dataset
+	matches []joinMatch
+}
+
+// MarshalJSON renders the dataset fields plus matches.
+func (r joinRecord) MarshalJSON() ([]byte, error) {
+	fields := r.dataset.Fields()
+	fields["matches"] = r.matches
+	return json.Marshal(fields)
+}
+
+// Feature renders the left row as a GeoJSON feature with a matches property.
+func (r joinRecord) Feature() models.Feature {
+	f := r.dataset.Feature()
+	f.Properties["matches"] = r.matches
+	return f
+}
+
+// joinCount is a right row with the number of left rows matching it.
+type joinCount struct {
+	dataset models.Dataset
+	count   int
+}
+
+// MarshalJSON renders the dataset fields plus count.
+func (r joinCount) MarshalJSON() ([]byte, error) {
+	fields := r.dataset.Fields()
+	fields["count"] = r.count
+	return json.Marshal(fields)
+}
+
+// Feature renders the right row as a GeoJSON feature with a count property.
+func (r joinCount) Feature() models.Feature {
+	f := r.dataset.Feature()
+	f.Properties["count"] = r.count
+	return f
+}
+
+// parseJoin reads the join parameters and the left-side dataset filter.
+func parseJoin(c *gin.Context) (db.JoinRequest, error) {
+	var j db.JoinRequest
+	left, right := strings.TrimSpace(c.Query("left")), strings.TrimSpace(c.Query("right"))
+	if left == "" || right == "" {
+		return j, badRequest("left and right are required")
+	}
+	j.Right = right
+	j.Predicate = db.JoinPredicate(c.DefaultQuery("predicate", string(db.JoinWithin)))
+	switch j.Predicate {
+	case db.JoinWithin:
+	case db.JoinDWithin:
+		if c.Query("distance") == "" {
+			return j, badRequest("distance is required for predicate=dwithin")
+		}
+		distance, err := queryFloat(c, "distance", 0)
+		if err != nil {
+			return j, err
+		}
+		// Written so NaN fails too
+		if !(distance > 0 && distance <= maxJoinDistance) {
+			return j, badRequest("distance must be greater than 0 and at most %.0f metres", maxJoinDistance)
+		}
+		j.Distance = distance
+	default:
+		return j, badRequest("Invalid predicate %q: expected within or dwithin", j.Predicate)
+	}
+	j.Mode = db.JoinMode(c.DefaultQuery("mode", string(db.JoinRecords)))
+	if j.Mode != db.JoinRecords && j.Mode != db.JoinCount {
+		return j, badRequest("Invalid mode %q: expected records or count", j.Mode)
+	}
+
+	f, err := parseDatasetFilter(c)
+	if err != nil {
+		return j, err
+	}
+	f.Type = left
+	j.Left = f
+	return j, nil
+}
+
+// JoinDatasets joins two dataset types spatially.
+func JoinDatasets(c *gin.Context) {
+	format, err := parseFormat(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	j, err := parseJoin(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+
+	result, err := db.Datasets.Join(c.Request.Context(), j)
+	if errors.Is(err, db.ErrJoinTooLarge) {
+		respondFilterError(c, &filterError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: fmt.Sprintf("The requested page matches more than %d pairs: lower limit or distance", db.MaxJoinPairs),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Spatial join %s %s %s failed: %v", j.Left.Type, j.Predicate, j.Right, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Spatial join failed"})
+		return
+	}
+	log.Printf("✅ Joined %s %s %s: %d pairs", j.Left.Type, j.Predicate, j.Right, result.PairCount)
+
+	if j.Mode == db.JoinCount {
+		rows := make([]joinCount, len(result.Right))
+		for i, d := range result.Right {
+			rows[i] = joinCount{dataset: d, count: result.Count[d.ID]}
+		}
+		respondJoin(c, format, j, result, rows)
+		return
+	}
+
+	right := make(map[int]models.Dataset, len(result.Right))
+	for _, d := range result.Right {
+		right[d.ID] = d
+	}
+	matches := make(map[int][]joinMatch)
+	for _, p := range result.Pairs {
+		d := right[p.RightID]
+		m := joinMatch{ID: d.ID, Name: d.Name}
+		if d.Value.Valid {
+			m.Value = &d.Value.Float64
+		}
+		if d.Unit.Valid {
+			m.Unit = d.Unit.String
+		}
+		if j.Predicate == db.JoinDWithin {
+			m.DistanceM = &p.Distance
+		}
+		matches[p.LeftID] = append(matches[p.LeftID], m)
+	}
+	rows := make([]joinRecord, len(result.Left))
+	for i, d := range result.Left {
+		rows[i] = joinRecord{dataset: d, matches: matches[d.ID]}
+	}
+	respondJoin(c, format, j, result, rows)
+}
+
+// respondJoin writes the join envelope, or a FeatureCollection of rows for
+// GeoJSON.
+func respondJoin[T models.Featurer](c *gin.Context, format responseFormat, j db.JoinRequest, result *db.JoinResult, rows []T) {
+	if format == formatGeoJSON {
+		respondRows(c, format, rows)
+		return
+	}
+	resp := gin.H{
+		"left":      j.Left.Type,
+		"right":     j.Right,
+		"predicate": j.Predicate,
+		"pairs":     result.PairCount,
+		"total":     result.Total,
+		"results":   rows,
+	}
+	if j.Predicate == db.JoinDWithin {
+		resp["distance_m"] = j.Distance
+	}
+	c.JSON(http.StatusOK, resp)
+}
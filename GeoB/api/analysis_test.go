@@ -0,0 +1,56 @@
+package api
+
+import (
+	"GeoGO/db"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeJoin returns a fixed join result or error.
+type fakeJoin struct {
+	fakeDatasets
+	result *db.JoinResult
+	err    error
+	req    db.JoinRequest
+}
+
+func (f *fakeJoin) Join(_ context.Context, j db.JoinRequest) (*db.JoinResult, error) {
+	f.req = j
+	return f.result, f.err
+}
+
+func TestJoinDatasets(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		err    error
+		status int
+	}{
+		{"records", "/analysis/join?left=meteorite&right=vegetation", nil, http.StatusOK},
+		{"too many pairs", "/analysis/join?left=meteorite&right=meteorite&predicate=dwithin&distance=100000&limit=0", db.ErrJoinTooLarge, http.StatusRequestEntityTooLarge},
+		{"missing distance", "/analysis/join?left=meteorite&right=meteorite&predicate=dwithin", nil, http.StatusBadRequest},
+		{"NaN distance", "/analysis/join?left=meteorite&right=climate&predicate=dwithin&distance=NaN", nil, http.StatusBadRequest},
+		{"distance too far", "/analysis/join?left=meteorite&right=climate&predicate=dwithin&distance=100001", nil, http.StatusBadRequest},
+		{"bad mode", "/analysis/join?left=meteorite&right=vegetation&mode=all", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &fakeJoin{err: tt.err, result: &db.JoinResult{}}
+			withFakes(t, &fakeMeteorites{}, &fakeDatasets{})
+			db.Datasets = d
+
+			r := testRouter()
+			r.GET("/analysis/join", JoinDatasets)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.status, w.Body)
+			}
+			if tt.status == http.StatusOK && (d.req.Mode != db.JoinRecords || d.req.Left.Limit != defaultLimit) {
+				t.Errorf("request mode/limit = %q/%d", d.req.Mode, d.req.Left.Limit)
+			}
+		})
+	}
+}
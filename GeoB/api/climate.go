@@ -0,0 +1,472 @@
+// climate.go
+//
+// Climate projection endpoints for GeoGO
+// Compliance Level: High
+//
+// - Routes: /climate/stations/:stn_id/projections, /climate/interpolate
+// - Returns every variable (tas, tasmax, tasmin, hurs, pan-evap) of a station
+//   across RCP scenarios, models and climatology periods, grouped by variable
+// - Diff mode (from= and to=) compares two scenarios of one dimension - two
+//   RCPs, two models or two climatology periods - pairing rows that agree on
+//   the other dimensions and returning to minus from for every period; a
+//   period missing on either side (null) has a null change
+// - Interpolation estimates one variable, period and scenario at any point
+//   from the k nearest stations, by IDW or ordinary kriging (geo/interpolate.go);
+//   without model the stations' ensemble mean is used
+//
+// Projection Parameters:
+//   - variable, rcp, model, climatology: Comma-separated filters (default all)
+//   - from, to: Scenarios to compare, e.g. from=rcp45&to=rcp85 or
+//     from=2020-2039&to=2080-2099
+//
+// Interpolation Parameters:
+//   - lat, lon, variable: Target point and variable (required)
+//   - period / month: As for climate listings (default Annual)
+//   - rcp, climatology, model: Scenario (default rcp85, 2080-2099, ensemble mean)
+//   - method: idw (default) | kriging; k: neighbours (default 8); power: IDW power (default 2)
+//
+// NOTE: Station ids keep their leading zeros (e.g. 086071 for Melbourne)
+
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/geo"
+	"GeoGO/models"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// climatePeriods are the per-period values of a projection; a period the
+// source leaves blank is null rather than 0, a real temperature.
+type climatePeriods struct {
+	Annual *float64 `json:"annual"`
+	DJF    *float64 `json:"djf"`
+	MAM    *float64 `json:"mam"`
+	JJA    *float64 `json:"jja"`
+	SON    *float64 `json:"son"`
+}
+
+func periodsOf(d models.ClimateData) climatePeriods {
+	return climatePeriods{Annual: d.Annual, DJF: d.DJF, MAM: d.MAM, JJA: d.JJA, SON: d.SON}
+}
+
+// minus returns p - o, rounded to the two decimals of the source data.
+// A period missing on either side has no change.
+func (p climatePeriods) minus(o climatePeriods) climatePeriods {
+	diff := func(a, b *float64) *float64 {
+		if a == nil || b == nil {
+			return nil
+		}
+		d := roundTo(*a-*b, 2)
+		return &d
+	}
+	return climatePeriods{
+		Annual: diff(p.Annual, o.Annual),
+		DJF:    diff(p.DJF, o.DJF),
+		MAM:    diff(p.MAM, o.MAM),
+		JJA:    diff(p.JJA, o.JJA),
+		SON:    diff(p.SON, o.SON),
+	}
+}
+
+// climateProjection is one scenario of a variable. In diff mode the compared
+// dimension is left empty and the periods hold the change.
+type climateProjection struct {
+	RCP         string `json:"rcp,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Climatology string `json:"climatology,omitempty"`
+	climatePeriods
+}
+
+// climateVariable groups the projections of one variable.
+type climateVariable struct {
+	ClimateType string              `json:"climate_type"`
+	Unit        string              `json:"unit"`
+	Projections []climateProjection `json:"projections,omitempty"`
+	Changes     []climateProjection `json:"changes,omitempty"`
+}
+
+// Scenario dimensions, named as in the response.
+const (
+	dimensionRCP         = "rcp"
+	dimensionModel       = "model"
+	dimensionClimatology = "climatology"
+)
+
+// climateDimension returns the value of one scenario dimension.
+func climateDimension(d models.ClimateData, dimension string) string {
+	switch dimension {
+	case dimensionRCP:
+		return d.RCP
+	case dimensionModel:
+		return d.Model
+	}
+	return d.Climatology
+}
+
+// climateTypes maps variable codes back to the stored climate_type.
+func climateTypes() map[string]string {
+	types := make(map[string]string, len(models.ClimateVariables))
+	for climateType, code := range models.ClimateVariables {
+		types[code] = climateType
+	}
+	return types
+}
+
+// queryList reads a comma-separated parameter; nil means no filter.
+func queryList(c *gin.Context, name string) []string {
+	v := strings.TrimSpace(c.Query(name))
+	if v == "" {
+		return nil
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// GetClimateProjections returns a station's projections, or the change
+// between two scenarios.
+func GetClimateProjections(c *gin.Context) {
+	stationID := strings.TrimSpace(c.Param("stn_id"))
+	variables := queryList(c, "variable")
+	types := climateTypes()
+	for _, v := range variables {
+		if _, ok := types[v]; !ok {
+			respondFilterError(c, badRequest("Invalid variable %q: expected tas, tasmax, tasmin, hurs or pan-evap", v))
+			return
+		}
+	}
+	from, to := strings.TrimSpace(c.Query("from")), strings.TrimSpace(c.Query("to"))
+	if (from == "") != (to == "") {
+		respondFilterError(c, badRequest("from and to must be given together"))
+		return
+	}
+
+	rows, err := db.Climate.Projections(c.Request.Context(), stationID)
+	if err != nil {
+		log.Printf("❌ Failed to fetch projections for station %s: %v", stationID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch climate projections"})
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown climate station " + stationID})
+		return
+	}
+	station := rows[0]
+
+	filters := map[string][]string{
+		dimensionRCP:         queryList(c, "rcp"),
+		dimensionModel:       queryList(c, "model"),
+		dimensionClimatology: queryList(c, "climatology"),
+	}
+	rows = slices.DeleteFunc(rows, func(d models.ClimateData) bool {
+		if variables != nil && !slices.Contains(variables, d.Variable) {
+			return true
+		}
+		for dimension, values := range filters {
+			if values != nil && !slices.Contains(values, climateDimension(d, dimension)) {
+				return true
+			}
+		}
+		return false
+	})
+
+	resp := gin.H{
+		"station_id":   station.StationID,
+		"station_name": station.StationName,
+		"lat":          station.Lat,
+		"lon":          station.Lon,
+	}
+	var groups map[string]*climateVariable
+	if from == "" {
+		groups = groupProjections(rows)
+		log.Printf("✅ Returning %d projections for station %s", len(rows), stationID)
+	} else {
+		dimension := compareDimension(rows, from, to)
+		if dimension == "" {
+			respondFilterError(c, badRequest("from %q and to %q must both be RCPs, models or climatology periods of station %s", from, to, stationID))
+			return
+		}
+		groups = diffProjections(rows, dimension, from, to)
+		resp["compare"], resp["from"], resp["to"] = dimension, from, to
+		log.Printf("✅ Compared %s %s -> %s for station %s", dimension, from, to, stationID)
+	}
+	resp["variables"] = groups
+	c.JSON(http.StatusOK, resp)
+}
+
+// groupProjections groups rows (already sorted) by variable.
+func groupProjections(rows []models.ClimateData) map[string]*climateVariable {
+	groups := make(map[string]*climateVariable)
+	for _, d := range rows {
+		g := variableGroup(groups, d)
+		g.Projections = append(g.Projections, climateProjection{
+			RCP: d.RCP, Model: d.Model, Climatology: d.Climatology, climatePeriods: periodsOf(d),
+		})
+	}
+	return groups
+}
+
+func variableGroup(groups map[string]*climateVariable, d models.ClimateData) *climateVariable {
+	g, ok := groups[d.Variable]
+	if !ok {
+		g = &climateVariable{ClimateType: climateTypes()[d.Variable], Unit: d.Unit}
+		groups[d.Variable] = g
+	}
+	return g
+}
+
+// compareDimension returns the dimension holding both from and to, or "".
+func compareDimension(rows []models.ClimateData, from, to string) string {
+	for _, dimension := range []string{dimensionRCP, dimensionModel, dimensionClimatology} {
+		var hasFrom, hasTo bool
+		for _, d := range rows {
+			v := climateDimension(d, dimension)
+			hasFrom, hasTo = hasFrom || v == from, hasTo || v == to
+		}
+		if hasFrom && hasTo {
+			return dimension
+		}
+	}
+	return ""
+}
+
+// diffProjections pairs the from and to rows that agree on every other
+// dimension and returns their change per variable.
+func diffProjections(rows []models.ClimateData, dimension, from, to string) map[string]*climateVariable {
+	// key blanks the compared dimension so paired rows share it
+	key := func(d models.ClimateData) climateProjection {
+		p := climateProjection{RCP: d.RCP, Model: d.Model, Climatology: d.Climatology}
+		switch dimension {
+		case dimensionRCP:
+			p.RCP = ""
+		case dimensionModel:
+			p.Model = ""
+		default:
+			p.Climatology = ""
+		}
+		return p
+	}
+	type pairKey struct {
+		variable string
+		scenario climateProjection
+	}
+	base := make(map[pairKey]models.ClimateData)
+	for _, d := range rows {
+		if climateDimension(d, dimension) == from {
+			base[pairKey{d.Variable, key(d)}] = d
+		}
+	}
+
+	groups := make(map[string]*climateVariable)
+	for _, d := range rows {
+		if climateDimension(d, dimension) != to {
+			continue
+		}
+		b, ok := base[pairKey{d.Variable, key(d)}]
+		if !ok {
+			continue
+		}
+		change := key(d)
+		change.climatePeriods = periodsOf(d).minus(periodsOf(b))
+		g := variableGroup(groups, d)
+		g.Changes = append(g.Changes, change)
+	}
+	return groups
+}
+
+// Interpolation defaults and bounds.
+const (
+	defaultInterpolationRCP         = "rcp85"
+	defaultInterpolationClimatology = "2080-2099"
+	defaultInterpolationK           = 8
+	maxInterpolationK               = 50
+	defaultIDWPower                 = 2.0
+	maxIDWPower                     = 10.0
+)
+
+// Interpolation methods.
+const (
+	methodIDW     = "idw"
+	methodKriging = "kriging"
+)
+
+// interpolationRequest is a parsed /climate/interpolate request.
+type interpolationRequest struct {
+	lat, lon  float64
+	variable  string
+	selection db.ClimateSelection
+	method    string
+	k         int
+	power     float64
+}
+
+// interpolationStation is one station contributing to an estimate.
+type interpolationStation struct {
+	StationID   string  `json:"station_id"`
+	StationName string  `json:"station_name"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Value       float64 `json:"value"`
+	Models      int     `json:"models"`
+	DistanceM   float64 `json:"distance_m"`
+	Weight      float64 `json:"weight"`
+}
+
+// parseInterpolation reads the target point, the value to interpolate and
+// the method parameters.
+func parseInterpolation(c *gin.Context) (interpolationRequest, error) {
+	var q interpolationRequest
+	var err error
+	if c.Query("lat") == "" || c.Query("lon") == "" {
+		return q, badRequest("lat and lon are required")
+	}
+	if q.lat, q.lon, err = parseCoordinates(c.Query("lat"), c.Query("lon")); err != nil {
+		return q, err
+	}
+	if q.selection.ClimateType, err = parseClimateVariable(c); err != nil {
+		return q, err
+	}
+	if q.selection.ClimateType == "" {
+		return q, badRequest("variable is required (tas, tasmax, tasmin, hurs or pan-evap)")
+	}
+	q.variable = models.ClimateVariables[q.selection.ClimateType]
+	if q.selection.Period, err = parseClimatePeriod(c); err != nil {
+		return q, err
+	}
+	if q.selection.Period == "" {
+		q.selection.Period = "annual"
+	}
+	q.selection.RCP = c.DefaultQuery("rcp", defaultInterpolationRCP)
+	q.selection.Climatology = c.DefaultQuery("climatology", defaultInterpolationClimatology)
+	q.selection.Model = strings.TrimSpace(c.Query("model"))
+
+	q.method = c.DefaultQuery("method", methodIDW)
+	if q.method != methodIDW && q.method != methodKriging {
+		return q, badRequest("Invalid method %q: expected idw or kriging", q.method)
+	}
+	if q.k, err = queryInt(c, "k", defaultInterpolationK); err != nil {
+		return q, err
+	}
+	if q.k < 1 || q.k > maxInterpolationK {
+		return q, badRequest("k must be between 1 and %d", maxInterpolationK)
+	}
+	if q.power, err = queryFloat(c, "power", defaultIDWPower); err != nil {
+		return q, err
+	}
+	// Written so NaN fails too
+	if !(q.power > 0 && q.power <= maxIDWPower) {
+		return q, badRequest("power must be greater than 0 and at most %.0f", maxIDWPower)
+	}
+	return q, nil
+}
+
+// InterpolateClimate estimates a climate value at any point from the
+// surrounding stations by inverse-distance weighting or ordinary kriging.
+func InterpolateClimate(c *gin.Context) {
+	q, err := parseInterpolation(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	sel := q.selection
+
+	stations, err := db.Climate.StationValues(c.Request.Context(), sel)
+	if err != nil {
+		log.Printf("❌ Failed to fetch %s station values: %v", q.variable, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch climate stations"})
+		return
+	}
+	if len(stations) == 0 {
+		scenario := strings.TrimSpace(strings.Join([]string{sel.RCP, sel.Climatology, sel.Model}, " "))
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("No climate stations have %s for %s", q.variable, scenario)})
+		return
+	}
+	samples := make([]geo.Sample, len(stations))
+	for i, s := range stations {
+		samples[i] = geo.Sample{Lat: s.Lat, Lon: s.Lon, Value: s.Value}
+	}
+
+	resp := gin.H{
+		"lat":           q.lat,
+		"lon":           q.lon,
+		"variable":      q.variable,
+		"period":        sel.Period,
+		"rcp":           sel.RCP,
+		"climatology":   sel.Climatology,
+		"ensemble_mean": sel.Model == "",
+		"method":        q.method,
+		"k":             q.k,
+		"unit":          stations[0].Unit,
+	}
+	if sel.Model != "" {
+		resp["model"] = sel.Model
+	}
+	var estimate float64
+	var weights []geo.Weight
+	switch q.method {
+	case methodIDW:
+		estimate, weights = geo.IDW(samples, q.lat, q.lon, q.k, q.power)
+		resp["power"] = q.power
+	case methodKriging:
+		v, err := geo.FitVariogram(samples)
+		if err == nil {
+			var kriged geo.Kriged
+			if kriged, err = geo.Krige(samples, v, q.lat, q.lon, q.k); err == nil {
+				estimate, weights = kriged.Value, kriged.Weights
+				resp["variance"] = roundTo(kriged.Variance, 4)
+				resp["std_error"] = roundTo(math.Sqrt(kriged.Variance), 2)
+				resp["variogram"] = gin.H{
+					"model":   "exponential",
+					"nugget":  roundTo(v.Nugget, 4),
+					"sill":    roundTo(v.Sill, 4),
+					"range_m": math.Round(v.Range),
+				}
+			}
+		}
+		if err != nil {
+			respondFilterError(c, &filterError{
+				status:  http.StatusUnprocessableEntity,
+				message: fmt.Sprintf("Kriging failed for %d station(s): %v; use method=idw", len(stations), err),
+			})
+			return
+		}
+	}
+
+	contributors := make([]interpolationStation, len(weights))
+	for i, w := range weights {
+		s := stations[w.Index]
+		contributors[i] = interpolationStation{
+			StationID:   s.StationID,
+			StationName: s.StationName,
+			Lat:         s.Lat,
+			Lon:         s.Lon,
+			Value:       roundTo(s.Value, 2),
+			Models:      s.Models,
+			DistanceM:   math.Round(w.Distance),
+			Weight:      roundTo(w.Weight, 4),
+		}
+	}
+	resp["estimate"] = roundTo(estimate, 2)
+	resp["stations"] = contributors
+	log.Printf("✅ Interpolated %s %s at %.5f,%.5f by %s from %d station(s)",
+		q.variable, sel.Period, q.lat, q.lon, q.method, len(contributors))
+	c.JSON(http.StatusOK, resp)
+}
+
+// roundTo rounds v to the given number of decimals.
+func roundTo(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
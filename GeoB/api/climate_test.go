@@ -0,0 +1,82 @@
+package api
+
+import (
+	"GeoGO/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func f64(v float64) *float64 { return &v }
+
+func TestDiffProjectionsMissingPeriods(t *testing.T) {
+	row := func(rcp string, annual, djf, jja *float64) models.ClimateData {
+		return models.ClimateData{Variable: "tasmax", Model: "ACCESS1-0", Climatology: "2080-2099", RCP: rcp, Annual: annual, DJF: djf, JJA: jja, MAM: f64(20), SON: f64(21)}
+	}
+	rows := []models.ClimateData{
+		// DJF is blank in the base scenario; JJA is a real 0 degrees
+		row("rcp45", f64(22.5), nil, f64(0)),
+		row("rcp85", f64(24.25), f64(31), f64(1.5)),
+	}
+	changes := diffProjections(rows, dimensionRCP, "rcp45", "rcp85")["tasmax"].Changes
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v", changes)
+	}
+	out, err := json.Marshal(changes[0].climatePeriods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"annual":1.75,"djf":null,"mam":0,"jja":1.5,"son":0}`; string(out) != want {
+		t.Errorf("change = %s, want %s", out, want)
+	}
+
+	out, err = json.Marshal(periodsOf(rows[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"annual":22.5,"djf":null,"mam":20,"jja":0,"son":21}`; string(out) != want {
+		t.Errorf("periods = %s, want %s", out, want)
+	}
+}
+
+func TestParseInterpolation(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		ok    bool
+	}{
+		{"defaults", "lat=-37.8&lon=145&variable=tas", true},
+		{"power", "lat=-37.8&lon=145&variable=tas&power=3.5", true},
+		{"NaN power", "lat=-37.8&lon=145&variable=tas&power=NaN", false},
+		{"infinite power", "lat=-37.8&lon=145&variable=tas&power=Inf", false},
+		{"zero power", "lat=-37.8&lon=145&variable=tas&power=0", false},
+		{"power too high", "lat=-37.8&lon=145&variable=tas&power=11", false},
+		{"NaN latitude", "lat=NaN&lon=145&variable=tas", false},
+		{"bad k", "lat=-37.8&lon=145&variable=tas&k=0", false},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/climate/interpolate?"+tt.query, nil)
+			q, err := parseInterpolation(c)
+			if !tt.ok {
+				var fe *filterError
+				if !errors.As(err, &fe) || fe.status != http.StatusBadRequest {
+					t.Errorf("error = %v, want a 400", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if q.k != defaultInterpolationK || q.method != methodIDW || q.selection.Period != "annual" {
+				t.Errorf("request = %+v", q)
+			}
+		})
+	}
+}
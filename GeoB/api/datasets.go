@@ -1,81 +1,53 @@
 package api
 
 import (
-	"GeoGO/api/geocoding"
 	"GeoGO/db"
+	"GeoGO/geo"
 	"GeoGO/models"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetDatasets provides a unified endpoint for all dataset types
+// Bounds for the k parameter of /datasets/nearest.
+const (
+	defaultNearestK = 10
+	maxNearestK     = 1000
+)
+
+// Defaults and bounds for /datasets/stats/:type.
+const (
+	defaultPercentiles   = "25,50,75"
+	maxPercentiles       = 20
+	defaultHistogramBins = 10
+	maxHistogramBins     = 100
+)
+
+// GetDatasets provides a unified endpoint for all dataset types.
+// Results are plain JSON or a GeoJSON FeatureCollection (see format.go).
 func GetDatasets(c *gin.Context) {
-	datasetType := c.Query("type")
-	limit := 50
-	offset := 0
-	valueMin, valueMax := 0.0, 10000000.0
-	var lat, lon, radius float64
-	location := c.Query("location")
-
-	// Parse query parameters
-	if v := c.Query("limit"); v != "" {
-		limit, _ = strconv.Atoi(v)
-	}
-	if v := c.Query("offset"); v != "" {
-		offset, _ = strconv.Atoi(v)
-	}
-	if v := c.Query("value_min"); v != "" {
-		valueMin, _ = strconv.ParseFloat(v, 64)
-	}
-	if v := c.Query("value_max"); v != "" {
-		valueMax, _ = strconv.ParseFloat(v, 64)
-	}
-	if v := c.Query("radius"); v != "" {
-		radius, _ = strconv.ParseFloat(v, 64)
-	}
-
-	// Build query
-	query := `
-		SELECT id, dataset_type, name, lat, lon, value, unit, metadata, 
-		       recclass, mass, year, nametype, fall
-		FROM datasets
-		WHERE value BETWEEN $1 AND $2
-	`
-	args := []interface{}{valueMin, valueMax}
-	paramCount := 2
-
-	// Add dataset type filter
-	if datasetType != "" {
-		paramCount++
-		query += fmt.Sprintf(" AND dataset_type = $%d", paramCount)
-		args = append(args, datasetType)
-	}
-
-	// Add location filter
-	if location != "" {
-		coords, err := geocoding.ForwardGeocode(location)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get coordinates for location"})
-			return
-		}
-		lat, lon = coords.Lat, coords.Lon
-		paramCount++
-		query += fmt.Sprintf(" AND ST_DWithin(geom::geography, ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography, $%d)",
-			paramCount+1, paramCount+2, paramCount+3)
-		args = append(args, lon, lat, radius)
-		paramCount += 3
+	format, err := parseFormat(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
 	}
+	filter, err := parseDatasetFilter(c)
+	if err == nil {
+		err = parseDatasetListing(c, &filter)
+	}
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	filter.ValueMin = withDefault(filter.ValueMin, defaultValueMin)
+	filter.ValueMax = withDefault(filter.ValueMax, defaultValueMax)
 
-	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", paramCount+1, paramCount+2)
-	args = append(args, limit, offset)
-
-	// Execute query
-	datasets := make([]models.Dataset, 0)
-	err := db.DB.Select(&datasets, query, args...)
+	datasets, err := db.Datasets.List(c.Request.Context(), filter)
 	if err != nil {
 		log.Printf("❌ Failed to fetch datasets: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
@@ -83,33 +55,12 @@ func GetDatasets(c *gin.Context) {
 	}
 
 	log.Printf("✅ Returning %d datasets", len(datasets))
-	c.JSON(http.StatusOK, datasets)
+	respondPage(c, format, datasets, filter.Limit, db.DatasetCursor)
 }
 
 // GetDatasetTypes returns information about available dataset types
 func GetDatasetTypes(c *gin.Context) {
-	query := `
-		SELECT 
-			dataset_type,
-			COUNT(*) as count,
-			MIN(value) as min_value,
-			MAX(value) as max_value,
-			MIN(timestamp) as min_date,
-			MAX(timestamp) as max_date
-		FROM datasets 
-		GROUP BY dataset_type
-	`
-
-	var results []struct {
-		Type     string   `db:"dataset_type"`
-		Count    int      `db:"count"`
-		MinValue *float64 `db:"min_value"`
-		MaxValue *float64 `db:"max_value"`
-		MinDate  *string  `db:"min_date"`
-		MaxDate  *string  `db:"max_date"`
-	}
-
-	err := db.DB.Select(&results, query)
+	results, err := db.Datasets.Types(c.Request.Context())
 	if err != nil {
 		log.Printf("❌ Failed to fetch dataset types: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dataset types"})
@@ -143,6 +94,9 @@ func GetDatasetTypes(c *gin.Context) {
 		case models.DatasetTypeFire:
 			info.Name = "Fire Projections"
 			info.Description = "Fire risk and projection data"
+		case models.DatasetTypeCatchment:
+			info.Name = "Community Catchments"
+			info.Description = "Community profiles: population, land use, services and health access"
 		default:
 			info.Name = string(info.Type)
 			info.Description = "Geospatial dataset"
@@ -165,43 +119,65 @@ func GetDatasetTypes(c *gin.Context) {
 	c.JSON(http.StatusOK, datasetInfos)
 }
 
-// GetDatasetStats returns statistics for a specific dataset type
+// GetDatasetStats returns value statistics, a histogram, a per-unit breakdown
+// and the extent of the rows of a dataset type matching the /datasets filters.
 func GetDatasetStats(c *gin.Context) {
-	datasetType := c.Param("type")
-	if datasetType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Dataset type is required"})
+	filter, err := parseDatasetFilter(c)
+	if err == nil {
+		err = parseDatasetListing(c, &filter)
+	}
+	if err != nil {
+		respondFilterError(c, err)
 		return
 	}
+	filter.After, filter.Limit, filter.Offset, filter.Sort = nil, 0, 0, ""
 
-	query := `
-		SELECT 
-			COUNT(*) as total_count,
-			AVG(value) as avg_value,
-			MIN(value) as min_value,
-			MAX(value) as max_value,
-			ST_Extent(geom) as spatial_extent
-		FROM datasets 
-		WHERE dataset_type = $1
-	`
-
-	var stats struct {
-		TotalCount    int     `db:"total_count" json:"total_count"`
-		AvgValue      float64 `db:"avg_value" json:"avg_value"`
-		MinValue      float64 `db:"min_value" json:"min_value"`
-		MaxValue      float64 `db:"max_value" json:"max_value"`
-		SpatialExtent string  `db:"spatial_extent" json:"spatial_extent"`
+	percentiles, err := parsePercentiles(c.DefaultQuery("percentiles", defaultPercentiles))
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	bins, err := queryInt(c, "bins", defaultHistogramBins)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	if bins < 0 || bins > maxHistogramBins {
+		respondFilterError(c, badRequest("bins must be between 0 and %d", maxHistogramBins))
+		return
 	}
 
-	err := db.DB.Get(&stats, query, datasetType)
+	stats, err := db.Datasets.Stats(c.Request.Context(), db.StatsRequest{Filter: filter, Percentiles: percentiles, Bins: bins})
 	if err != nil {
 		log.Printf("❌ Failed to fetch dataset stats: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dataset stats"})
 		return
 	}
 
+	log.Printf("✅ Stats for %d %s rows", stats.TotalCount, filter.Type)
 	c.JSON(http.StatusOK, stats)
 }
 
+// parsePercentiles reads a comma-separated list of percentiles in [0, 100];
+// an empty list requests none.
+func parsePercentiles(v string) ([]float64, error) {
+	percentiles := []float64{}
+	if strings.TrimSpace(v) == "" {
+		return percentiles, nil
+	}
+	for _, part := range strings.Split(v, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || !finite(p) || p < 0 || p > 100 {
+			return nil, badRequest("Invalid percentiles %q: expected numbers between 0 and 100", v)
+		}
+		percentiles = append(percentiles, p)
+	}
+	if len(percentiles) > maxPercentiles {
+		return nil, badRequest("At most %d percentiles can be requested", maxPercentiles)
+	}
+	return percentiles, nil
+}
+
 // GetDatasetsByType returns datasets of a specific type with filtering
 func GetDatasetsByType(c *gin.Context) {
 	datasetType := c.Param("type")
@@ -210,7 +186,109 @@ func GetDatasetsByType(c *gin.Context) {
 		return
 	}
 
-	// Add type filter to the main query
-	c.Set("dataset_type", datasetType)
+	// parseDatasetFilter reads the type from the path parameter
 	GetDatasets(c)
 }
+
+// neighbor is a dataset row annotated with its distance and bearing from the query point.
+type neighbor struct {
+	dataset  models.Dataset
+	distance float64
+	bearing  float64
+}
+
+// MarshalJSON renders the dataset fields plus distance_m and bearing.
+func (n neighbor) MarshalJSON() ([]byte, error) {
+	fields := n.dataset.Fields()
+	fields["distance_m"] = n.distance
+	fields["bearing"] = n.bearing
+	return json.Marshal(fields)
+}
+
+// Feature renders the row as a GeoJSON feature with distance_m and bearing properties.
+func (n neighbor) Feature() models.Feature {
+	f := n.dataset.Feature()
+	f.Properties["distance_m"] = n.distance
+	f.Properties["bearing"] = n.bearing
+	return f
+}
+
+// closestPoint returns the point of a row nearest to (lat, lon), where
+// distance_m is measured to: the row point, or the closest point on its shape
+// (the query point itself when it lies inside a polygon, giving bearing 0).
+func closestPoint(d models.Dataset, lat, lon float64) (float64, float64, error) {
+	if !d.Geometry.Valid {
+		return d.Lat, d.Lon, nil
+	}
+	g, err := geo.ParseGeometry([]byte(d.Geometry.String))
+	if err != nil {
+		return 0, 0, fmt.Errorf("dataset %d geometry: %w", d.ID, err)
+	}
+	pt, _ := g.Closest(lat, lon)
+	return pt.Lat, pt.Lon, nil
+}
+
+// GetNearestDatasets returns the k dataset rows closest to a point, nearest first.
+// The point is given as lat/lon or, failing that, as location (place name or "lat,lon").
+// All dataset filters (type, value range, bbox) apply; k defaults to 10.
+func GetNearestDatasets(c *gin.Context) {
+	format, err := parseFormat(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	filter, err := parseDatasetFilter(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	k, err := queryInt(c, "k", defaultNearestK)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	if k < 1 || k > maxNearestK {
+		respondFilterError(c, badRequest("k must be between 1 and %d", maxNearestK))
+		return
+	}
+
+	var lat, lon float64
+	switch {
+	case c.Query("lat") != "" || c.Query("lon") != "":
+		if lat, lon, err = parseCoordinates(c.Query("lat"), c.Query("lon")); err != nil {
+			respondFilterError(c, err)
+			return
+		}
+	case filter.Near != nil:
+		lat, lon = filter.Near.Lat, filter.Near.Lon
+	default:
+		respondFilterError(c, badRequest("lat and lon (or location) are required"))
+		return
+	}
+	filter.Near = nil
+
+	rows, err := db.Datasets.Nearest(c.Request.Context(), filter, lat, lon, k)
+	if err != nil {
+		log.Printf("❌ Failed to fetch nearest datasets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+		return
+	}
+
+	results := make([]neighbor, len(rows))
+	for i, r := range rows {
+		toLat, toLon, err := closestPoint(r.Dataset, lat, lon)
+		if err != nil {
+			log.Printf("❌ Failed to read dataset geometry: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+			return
+		}
+		results[i] = neighbor{
+			dataset:  r.Dataset,
+			distance: r.Distance,
+			bearing:  geo.Bearing(lat, lon, toLat, toLon),
+		}
+	}
+
+	log.Printf("✅ Returning %d nearest datasets to [%.6f, %.6f]", len(results), lat, lon)
+	respondRows(c, format, results)
+}
@@ -0,0 +1,84 @@
+// format.go
+//
+// Response format negotiation for GeoGO list endpoints
+// Compliance Level: High
+//
+// - format=geojson or format=json in the query string wins over the Accept header
+// - Accept: application/geo+json selects GeoJSON; anything else gets plain JSON
+// - GeoJSON responses are FeatureCollections with a bbox over all returned rows
+// - Requests with a cursor parameter get a page envelope carrying next_cursor
+//   ({"data": [...], "next_cursor": ...}, or next_cursor on the FeatureCollection)
+//
+// NOTE: Plain JSON stays the default so existing clients are unaffected
+
+package api
+
+import (
+	"GeoGO/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// mimeGeoJSON is the registered media type for GeoJSON (RFC 7946).
+const mimeGeoJSON = "application/geo+json"
+
+// responseFormat selects the representation of a list response.
+type responseFormat int
+
+const (
+	formatJSON responseFormat = iota
+	formatGeoJSON
+)
+
+// parseFormat resolves the response format from the format parameter or Accept header.
+func parseFormat(c *gin.Context) (responseFormat, error) {
+	switch c.Query("format") {
+	case "geojson":
+		return formatGeoJSON, nil
+	case "json":
+		return formatJSON, nil
+	case "":
+	default:
+		return formatJSON, badRequest("Invalid format %q: expected json or geojson", c.Query("format"))
+	}
+	if c.NegotiateFormat(binding.MIMEJSON, mimeGeoJSON) == mimeGeoJSON {
+		return formatGeoJSON, nil
+	}
+	return formatJSON, nil
+}
+
+// respondRows writes rows as a JSON array or a GeoJSON FeatureCollection.
+func respondRows[T models.Featurer](c *gin.Context, format responseFormat, rows []T) {
+	if format != formatGeoJSON {
+		c.JSON(http.StatusOK, rows)
+		return
+	}
+	// gin keeps a Content-Type that is already set
+	c.Header("Content-Type", mimeGeoJSON)
+	c.JSON(http.StatusOK, models.NewFeatureCollection(rows))
+}
+
+// respondPage writes one page of a listing. Without a cursor parameter it
+// behaves like respondRows; with one, it adds next_cursor, which is null once
+// a page comes back short (the listing is exhausted).
+func respondPage[T models.Featurer](c *gin.Context, format responseFormat, rows []T, limit int, cursor func(T) string) {
+	if _, ok := c.GetQuery("cursor"); !ok {
+		respondRows(c, format, rows)
+		return
+	}
+	var next *string
+	if limit > 0 && len(rows) == limit {
+		token := cursor(rows[len(rows)-1])
+		next = &token
+	}
+	if format != formatGeoJSON {
+		c.JSON(http.StatusOK, gin.H{"data": rows, "next_cursor": next})
+		return
+	}
+	fc := models.NewFeatureCollection(rows)
+	fc.NextCursor = next
+	c.Header("Content-Type", mimeGeoJSON)
+	c.JSON(http.StatusOK, fc)
+}
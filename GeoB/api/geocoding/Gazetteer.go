@@ -0,0 +1,371 @@
+// Gazetteer.go
+//
+// Offline gazetteer geocoding provider for GeoGO
+// Resolves locations from a GeoNames-style TSV file loaded into memory.
+// Compliance Level: High
+//
+// - No network access; intended for air-gapped analysis hosts
+// - Forward lookup: exact, then prefix, then fuzzy (edit distance) name match
+// - Reverse lookup: nearest place using a 1-degree grid index
+// - Ties are broken by population so "Melbourne" resolves to the city in Victoria
+//
+// File Format (GeoNames dump, tab separated, one place per line):
+//   0 geonameid, 1 name, 2 asciiname, 3 alternatenames (comma separated),
+//   4 latitude, 5 longitude, 6 feature class, 7 feature code, 8 country code,
+//   9 cc2, 10 admin1 code, 11 admin2 code, 12 admin3 code, 13 admin4 code,
+//   14 population, ...
+// Only columns 0-5 are required; blank lines and lines starting with '#' are skipped.
+//
+// TODO: Resolve admin1 codes to names using admin1CodesASCII.txt
+// TODO: Consider persisting the name index to speed up startup on large dumps
+//
+// NOTE: allCountries.txt (~12M places) needs several GB of RAM; prefer
+// cities500/cities15000 extracts for typical deployments
+
+package geocoding
+
+import (
+	"GeoGO/geo"
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prefixScanLimit caps the number of index entries inspected for a prefix match.
+const prefixScanLimit = 5000
+
+// Place is a single gazetteer entry.
+type Place struct {
+	Name        string
+	Lat         float64
+	Lon         float64
+	CountryCode string
+	Admin1      string
+	Population  int64
+}
+
+// DisplayName returns a human-readable label such as "Melbourne, AU".
+func (p Place) DisplayName() string {
+	if p.CountryCode == "" {
+		return p.Name
+	}
+	return p.Name + ", " + p.CountryCode
+}
+
+// nameEntry maps a normalised name (primary, ascii or alternate) to a place index.
+type nameEntry struct {
+	key   string
+	place int
+}
+
+// cellKey identifies a 1x1 degree grid cell.
+type cellKey struct {
+	lat int
+	lon int
+}
+
+// GazetteerGeocoder resolves locations from an in-memory gazetteer.
+type GazetteerGeocoder struct {
+	places []Place
+	names  []nameEntry // sorted by key
+	cells  map[cellKey][]int
+}
+
+// LoadGazetteer reads a GeoNames-style TSV file and builds the name and grid indexes.
+//
+// Parameters:
+//   - path: Path to the gazetteer file
+//
+// Returns:
+//   - *GazetteerGeocoder: Ready-to-use provider
+//   - error: File access errors or a file with no usable rows
+func LoadGazetteer(path string) (*GazetteerGeocoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open gazetteer: %w", err)
+	}
+	defer f.Close()
+
+	g := &GazetteerGeocoder{cells: make(map[cellKey][]int)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	skipped := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) < 6 {
+			skipped++
+			continue
+		}
+		lat, errLat := strconv.ParseFloat(cols[4], 64)
+		lon, errLon := strconv.ParseFloat(cols[5], 64)
+		if errLat != nil || errLon != nil || cols[1] == "" {
+			skipped++
+			continue
+		}
+		place := Place{Name: cols[1], Lat: lat, Lon: lon}
+		if len(cols) > 8 {
+			place.CountryCode = cols[8]
+		}
+		if len(cols) > 10 {
+			place.Admin1 = cols[10]
+		}
+		if len(cols) > 14 {
+			place.Population, _ = strconv.ParseInt(cols[14], 10, 64)
+		}
+		g.add(place, cols[2], cols[3])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read gazetteer: %w", err)
+	}
+	if len(g.places) == 0 {
+		return nil, fmt.Errorf("gazetteer %s contains no usable places", path)
+	}
+
+	sort.Slice(g.names, func(i, j int) bool {
+		if g.names[i].key != g.names[j].key {
+			return g.names[i].key < g.names[j].key
+		}
+		return g.places[g.names[i].place].Population > g.places[g.names[j].place].Population
+	})
+	log.Printf("✅ Loaded gazetteer %s: %d places, %d names (%d rows skipped)", path, len(g.places), len(g.names), skipped)
+	return g, nil
+}
+
+// add registers a place under its primary, ascii and alternate names.
+func (g *GazetteerGeocoder) add(p Place, asciiName, alternates string) {
+	idx := len(g.places)
+	g.places = append(g.places, p)
+
+	seen := map[string]bool{}
+	addName := func(name string) {
+		key := normalizeName(name)
+		if key == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		g.names = append(g.names, nameEntry{key: key, place: idx})
+	}
+	addName(p.Name)
+	addName(asciiName)
+	if alternates != "" {
+		for _, alt := range strings.Split(alternates, ",") {
+			addName(alt)
+		}
+	}
+
+	cell := cellFor(p.Lat, p.Lon)
+	g.cells[cell] = append(g.cells[cell], idx)
+}
+
+// Forward resolves a location name to coordinates.
+// A trailing ", CC" qualifier restricts matches to that country code
+// (e.g. "Perth, AU" versus "Perth, GB").
+func (g *GazetteerGeocoder) Forward(location string) (*ForwardGeocodeResponse, error) {
+	name, country := splitQualifier(location)
+	key := normalizeName(name)
+	if key == "" {
+		return nil, ErrNotFound
+	}
+
+	match := func(p Place) bool {
+		return country == "" || strings.EqualFold(p.CountryCode, country)
+	}
+
+	if idx, ok := g.lookupExact(key, match); ok {
+		return g.response(idx, location, "exact"), nil
+	}
+	if idx, ok := g.lookupPrefix(key, match); ok {
+		return g.response(idx, location, "prefix"), nil
+	}
+	if idx, ok := g.lookupFuzzy(key, match); ok {
+		return g.response(idx, location, "fuzzy"), nil
+	}
+	return nil, ErrNotFound
+}
+
+func (g *GazetteerGeocoder) response(idx int, query, kind string) *ForwardGeocodeResponse {
+	p := g.places[idx]
+	log.Printf("🗺️ Gazetteer %s match: '%s' -> %s [%.4f, %.4f]", kind, query, p.DisplayName(), p.Lat, p.Lon)
+	return &ForwardGeocodeResponse{Lat: p.Lat, Lon: p.Lon}
+}
+
+// lookupExact returns the most populous place whose name equals key.
+func (g *GazetteerGeocoder) lookupExact(key string, match func(Place) bool) (int, bool) {
+	i := sort.Search(len(g.names), func(i int) bool { return g.names[i].key >= key })
+	for ; i < len(g.names) && g.names[i].key == key; i++ {
+		// Entries sharing a key are sorted by population, so the first match wins.
+		if p := g.names[i].place; match(g.places[p]) {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// lookupPrefix returns the most populous place whose name starts with key.
+func (g *GazetteerGeocoder) lookupPrefix(key string, match func(Place) bool) (int, bool) {
+	best, found := 0, false
+	i := sort.Search(len(g.names), func(i int) bool { return g.names[i].key >= key })
+	for n := 0; i < len(g.names) && n < prefixScanLimit && strings.HasPrefix(g.names[i].key, key); i, n = i+1, n+1 {
+		p := g.names[i].place
+		if !match(g.places[p]) {
+			continue
+		}
+		if !found || g.places[p].Population > g.places[best].Population {
+			best, found = p, true
+		}
+	}
+	return best, found
+}
+
+// lookupFuzzy returns the closest place by edit distance, tolerating roughly
+// one typo per four characters (at most three).
+func (g *GazetteerGeocoder) lookupFuzzy(key string, match func(Place) bool) (int, bool) {
+	maxDist := len(key) / 4
+	if maxDist < 1 {
+		maxDist = 1
+	}
+	if maxDist > 3 {
+		maxDist = 3
+	}
+
+	best, bestDist, found := 0, maxDist+1, false
+	for _, entry := range g.names {
+		if abs(len(entry.key)-len(key)) > maxDist {
+			continue
+		}
+		d := levenshtein(key, entry.key, bestDist)
+		if d > maxDist || !match(g.places[entry.place]) {
+			continue
+		}
+		if d < bestDist || (d == bestDist && g.places[entry.place].Population > g.places[best].Population) {
+			best, bestDist, found = entry.place, d, true
+		}
+	}
+	return best, found
+}
+
+// Reverse returns the display name of the nearest gazetteer place.
+// The grid is searched in growing rings of cells; once a candidate is found
+// one more ring is checked because a neighbouring cell can hold a closer place.
+func (g *GazetteerGeocoder) Reverse(lat, lon float64) (string, error) {
+	origin := cellFor(lat, lon)
+	best, bestDist := -1, math.Inf(1)
+	for ring, stopAt := 0, 180; ring <= stopAt; ring++ {
+		for dLat := -ring; dLat <= ring; dLat++ {
+			for dLon := -ring; dLon <= ring; dLon++ {
+				if abs(dLat) != ring && abs(dLon) != ring {
+					continue // interior cells were visited in earlier rings
+				}
+				cell := cellKey{lat: origin.lat + dLat, lon: wrapLon(origin.lon + dLon)}
+				for _, idx := range g.cells[cell] {
+					p := g.places[idx]
+					if d := geo.Haversine(lat, lon, p.Lat, p.Lon); d < bestDist {
+						best, bestDist = idx, d
+					}
+				}
+			}
+		}
+		if best >= 0 && stopAt == 180 {
+			stopAt = ring + 1
+		}
+	}
+	if best < 0 {
+		return coordinateLabel(lat, lon), nil
+	}
+	return g.places[best].DisplayName(), nil
+}
+
+// normalizeName lower-cases a name and collapses punctuation and whitespace
+// so that "St. Kilda" and "st kilda" share a key.
+func normalizeName(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case r == '.' || r == '\'':
+			continue
+		case r == ' ' || r == '-' || r == '_' || r == '\t':
+			space = true
+		default:
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// splitQualifier splits "Perth, AU" into ("Perth", "AU"). Qualifiers that are
+// not two-letter country codes are kept as part of the name.
+func splitQualifier(location string) (string, string) {
+	i := strings.LastIndex(location, ",")
+	if i < 0 {
+		return location, ""
+	}
+	qualifier := strings.TrimSpace(location[i+1:])
+	if len(qualifier) != 2 {
+		return location, ""
+	}
+	return strings.TrimSpace(location[:i]), qualifier
+}
+
+func cellFor(lat, lon float64) cellKey {
+	return cellKey{lat: int(math.Floor(lat)), lon: int(math.Floor(lon))}
+}
+
+// wrapLon keeps grid longitudes within [-180, 180) across the antimeridian.
+func wrapLon(lon int) int {
+	for lon < -180 {
+		lon += 360
+	}
+	for lon >= 180 {
+		lon -= 360
+	}
+	return lon
+}
+
+// levenshtein computes the edit distance between a and b, giving up early
+// (returning limit+1) once every path exceeds limit.
+func levenshtein(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -1,68 +1,56 @@
 // Geocoding.go
 //
 // Geocoding service implementation for GeoGO
-// Provides forward and reverse geocoding capabilities using Nominatim.
+// Provides forward and reverse geocoding capabilities through a pluggable provider.
 // Compliance Level: High
 //
-// - Implements Redis caching for geocoding results
-// - Handles external API rate limiting
-// - Manages request timeouts
+// - Defines the Geocoder interface implemented by every backend
+// - Selects the active provider at startup (Nominatim or offline gazetteer)
+// - Exposes HTTP handlers for forward and reverse lookups
 // - Provides fallback mechanisms
 //
-// TODO: Implement rotating User-Agent headers for Nominatim API
-// TODO: Add exponential backoff for rate-limited requests
-// TODO: Consider implementing alternative geocoding providers
+// Providers:
+// - nominatim: nominatim.openstreetmap.org with Redis caching (see Nominatim.go)
+// - gazetteer: in-memory GeoNames-style TSV for air-gapped hosts (see Gazetteer.go)
+//
 // TODO: Add geocoding result validation
 // TODO: Implement bulk geocoding capabilities
-//
-// NOTE: Nominatim API Usage:
-// - Free tier has strict rate limits (1 request per second)
-// - Requires proper attribution in production
-// - May return different results based on zoom level
-// - Consider implementing request queuing for high-volume scenarios
-//
-// Redis Cache Policy:
-// TTL: 24 hours
-// Key Structure:
-// - Reverse geocoding: geo:{lat},{lon}
-// - Forward geocoding: geo:{location}
-// Reasoning:
-// - 24-hour TTL balances API load with data freshness
-// - Location names change less frequently than coordinates
-// - Consider implementing cache warming for common locations
+// TODO: Consider chaining providers (gazetteer first, Nominatim fallback)
 
 package geocoding
 
 import (
-	"context"
-	"encoding/json"
+	"GeoGO/config"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
-	"net/url"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
-// Redis client configuration for caching geocoding results.
-// Uses a local Redis instance with default settings for development.
-//
-// TODO: Move Redis configuration to environment variables
-// TODO: Add connection retry logic
-// TODO: Implement connection pooling
-// TODO: Add Redis health checks
-//
-// NOTE: Current configuration is for development only
-// NOTE: Consider using Redis Sentinel for high availability
-var redisClient = redis.NewClient(&redis.Options{
-	Addr:     "localhost:6379",
-	Password: "",
-	DB:       0,
-})
+// Provider names accepted by Init.
+const (
+	ProviderNominatim = "nominatim"
+	ProviderGazetteer = "gazetteer"
+)
+
+// ErrNotFound is returned when a provider cannot resolve a location name.
+var ErrNotFound = errors.New("location not found")
+
+// Geocoder is implemented by every geocoding backend.
+// Forward resolves a human-readable location to coordinates, Reverse resolves
+// coordinates to a human-readable name.
+type Geocoder interface {
+	Forward(location string) (*ForwardGeocodeResponse, error)
+	Reverse(lat, lon float64) (string, error)
+}
+
+// provider is the active geocoding backend. Nominatim is the default so that
+// existing deployments behave as before when no provider is configured.
+var provider Geocoder = NewNominatimGeocoder()
 
 // ReverseGeocodeResponse represents the structure of the response from the Nominatim reverse geocoding API.
 // It contains the human-readable location name for given coordinates.
@@ -77,6 +65,53 @@ type ForwardGeocodeResponse struct {
 	Lon float64 `json:"lon,string"`
 }
 
+// Init configures the Redis cache and selects the geocoding provider used by
+// ForwardGeocode and ReverseGeocode. It is called once at startup.
+//
+// Parameters:
+//   - cfg: Provider name ("nominatim" or "gazetteer") and gazetteer file path
+//   - cache: Redis connection used by the Nominatim provider
+//
+// Returns:
+//   - error: Unknown provider name or gazetteer load failure
+func Init(cfg config.GeocoderConfig, cache config.RedisConfig) error {
+	redisClient = redis.NewClient(&redis.Options{
+		Addr:     cache.Addr,
+		Password: cache.Password,
+		DB:       cache.DB,
+	})
+
+	switch cfg.Provider {
+	case "", ProviderNominatim:
+		SetProvider(NewNominatimGeocoder())
+	case ProviderGazetteer:
+		if cfg.GazetteerPath == "" {
+			return fmt.Errorf("gazetteer provider requires a gazetteer file path")
+		}
+		g, err := LoadGazetteer(cfg.GazetteerPath)
+		if err != nil {
+			return err
+		}
+		SetProvider(g)
+	default:
+		return fmt.Errorf("unknown geocoding provider %q", cfg.Provider)
+	}
+	log.Printf("🗺️ Geocoding provider: %s", providerName(cfg.Provider))
+	return nil
+}
+
+// SetProvider replaces the active geocoding backend.
+func SetProvider(g Geocoder) {
+	provider = g
+}
+
+func providerName(name string) string {
+	if name == "" {
+		return ProviderNominatim
+	}
+	return name
+}
+
 // GetMeteoriteLocation handles HTTP requests for reverse geocoding.
 // It validates input coordinates and returns a human-readable location name.
 //
@@ -125,8 +160,7 @@ func GetMeteoriteLocation(c *gin.Context) {
 	})
 }
 
-// ReverseGeocode converts coordinates to a human-readable location name using the Nominatim API.
-// It implements caching using Redis to reduce API calls and improve response times.
+// ReverseGeocode converts coordinates to a human-readable location name using the active provider.
 //
 // Parameters:
 //   - lat: Latitude coordinate
@@ -134,64 +168,13 @@ func GetMeteoriteLocation(c *gin.Context) {
 //
 // Returns:
 //   - string: Human-readable location name
-//   - error: Any geocoding or caching errors
-//
-// Implementation Details:
-//   - Uses Redis for caching with a 24-hour TTL
-//   - Implements a 10-second timeout for API requests
-//   - Provides fallback to coordinate string on API failure
-//
-// TODO: Implement rotating User-Agent headers
-// TODO: Add exponential backoff for rate-limited requests
-// TODO: Consider implementing alternative geocoding providers
-// TODO: Add geocoding result validation
-//
-// NOTE: Nominatim API Usage:
-// - Free tier has strict rate limits (1 request per second)
-// - Requires proper attribution in production
-// - May return different results based on zoom level
-// - Consider implementing request queuing for high-volume scenarios
+//   - error: Any geocoding errors
 func ReverseGeocode(lat, lon float64) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	redisKey := fmt.Sprintf("geo:%f,%f", lat, lon)
-	cached, err := redisClient.Get(ctx, redisKey).Result()
-	if err == nil && cached != "" {
-		log.Printf("🗺️ Cache Hit: %s -> %s", redisKey, cached)
-		return cached, nil
-	} else if err != redis.Nil {
-		log.Printf("⚠️ Redis error: %v", err)
-	}
-	url := fmt.Sprintf("https://nominatim.openstreetmap.org/reverse?format=json&lat=%f&lon=%f", lat, lon)
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		log.Println("❌ Reverse geocoding API request failed:", err)
-		return fmt.Sprintf("Coordinates: %.4f, %.4f", lat, lon), nil
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("❌ Nominatim API returned status %d", resp.StatusCode)
-		return fmt.Sprintf("Coordinates: %.4f, %.4f", lat, lon), nil
-	}
-	var result ReverseGeocodeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		log.Println("❌ Error decoding API response:", err)
-		return fmt.Sprintf("Coordinates: %.4f, %.4f", lat, lon), nil
-	}
-	if result.DisplayName == "" {
-		result.DisplayName = fmt.Sprintf("Coordinates: %.4f, %.4f", lat, lon)
-	}
-	if err := redisClient.Set(ctx, redisKey, result.DisplayName, 24*time.Hour).Err(); err != nil {
-		log.Printf("⚠️ Redis cache save failed: %v", err)
-	} else {
-		log.Printf("🌍 Fetched from API & cached: %s -> %s", redisKey, result.DisplayName)
-	}
-	return result.DisplayName, nil
+	return provider.Reverse(lat, lon)
 }
 
 // GetCoordinatesFromLocation handles HTTP requests for forward geocoding.
-// It converts a location name to coordinates using the Nominatim API.
+// It converts a location name to coordinates using the active provider.
 //
 // Query Parameters:
 //   - location: Human-readable location name
@@ -199,14 +182,12 @@ func ReverseGeocode(lat, lon float64) (string, error) {
 // Response:
 //   - 200 OK: Coordinates in JSON format
 //   - 400 Bad Request: Missing location parameter
+//   - 404 Not Found: No match for the location name
 //   - 500 Internal Server Error: Geocoding service failure
 //
-// TODO: Add support for fuzzy location matching
-// TODO: Implement location name normalization
 // TODO: Add support for batch geocoding
 // TODO: Consider adding response compression
 //
-// NOTE: Current implementation uses exact matching
 // NOTE: Consider implementing location name disambiguation
 func GetCoordinatesFromLocation(c *gin.Context) {
 	location := c.Query("location")
@@ -215,6 +196,10 @@ func GetCoordinatesFromLocation(c *gin.Context) {
 		return
 	}
 	coords, err := ForwardGeocode(location)
+	if errors.Is(err, ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Forward geocoding failed"})
 		return
@@ -226,66 +211,14 @@ func GetCoordinatesFromLocation(c *gin.Context) {
 	})
 }
 
-// ForwardGeocode converts a location name to coordinates using the Nominatim API.
-// It implements caching using Redis to reduce API calls and improve response times.
+// ForwardGeocode converts a location name to coordinates using the active provider.
 //
 // Parameters:
 //   - location: Human-readable location name
 //
 // Returns:
 //   - *ForwardGeocodeResponse: Coordinates (latitude and longitude)
-//   - error: Any geocoding or caching errors
-//
-// Implementation Details:
-//   - Uses Redis for caching with a 24-hour TTL
-//   - Implements a 10-second timeout for API requests
-//   - Handles multiple results by returning the first match
-//
-// TODO: Add support for different location formats
-// TODO: Implement location name normalization
-// TODO: Add support for fuzzy matching
-// TODO: Consider implementing result ranking
-//
-// NOTE: Current implementation returns first match
-// NOTE: Consider implementing result scoring and ranking
+//   - error: ErrNotFound when nothing matches, or any provider error
 func ForwardGeocode(location string) (*ForwardGeocodeResponse, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	redisKey := fmt.Sprintf("geo:%s", strings.ToLower(location))
-	cached, err := redisClient.Get(ctx, redisKey).Result()
-	if err == nil && cached != "" {
-		log.Printf("🗺️ Cache Hit: %s -> %s", redisKey, cached)
-		parts := strings.Split(cached, ",")
-		if len(parts) == 2 {
-			lat, _ := strconv.ParseFloat(parts[0], 64)
-			lon, _ := strconv.ParseFloat(parts[1], 64)
-			return &ForwardGeocodeResponse{Lat: lat, Lon: lon}, nil
-		}
-	} else if err != redis.Nil {
-		log.Printf("⚠️ Redis error: %v", err)
-	}
-	url := fmt.Sprintf("https://nominatim.openstreetmap.org/search?format=json&q=%s", url.QueryEscape(location))
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		log.Println("❌ Forward geocoding API request failed:", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("❌ Nominatim API returned status %d", resp.StatusCode)
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-	var results []ForwardGeocodeResponse
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil || len(results) == 0 {
-		log.Println("❌ Error decoding API response:", err)
-		return nil, fmt.Errorf("Failed to parse API response")
-	}
-	redisValue := fmt.Sprintf("%f,%f", results[0].Lat, results[0].Lon)
-	if err := redisClient.Set(ctx, redisKey, redisValue, 24*time.Hour).Err(); err != nil {
-		log.Printf("⚠️ Redis cache save failed: %v", err)
-	} else {
-		log.Printf("🌍 Fetched from API & cached: %s -> %s", redisKey, redisValue)
-	}
-	return &results[0], nil
+	return provider.Forward(location)
 }
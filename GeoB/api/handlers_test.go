@@ -0,0 +1,248 @@
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeMeteorites records the filter of the last List call.
+type fakeMeteorites struct {
+	db.MeteoriteRepository // unimplemented methods panic
+	rows                   []models.Meteorite
+	err                    error
+	filter                 db.MeteoriteFilter
+}
+
+func (f *fakeMeteorites) List(_ context.Context, filter db.MeteoriteFilter) ([]models.Meteorite, error) {
+	f.filter = filter
+	return f.rows, f.err
+}
+
+// fakeDatasets records the filter of the last List call and the types of
+// the last Containing call.
+type fakeDatasets struct {
+	db.DatasetRepository // unimplemented methods panic
+	rows                 []models.Dataset
+	err                  error
+	filter               db.DatasetFilter
+	types                []string
+}
+
+func (f *fakeDatasets) List(_ context.Context, filter db.DatasetFilter) ([]models.Dataset, error) {
+	f.filter = filter
+	return f.rows, f.err
+}
+
+func (f *fakeDatasets) Containing(_ context.Context, types []string, _, _ float64) ([]models.Dataset, error) {
+	f.types = types
+	return f.rows, f.err
+}
+
+// withFakes swaps the package repositories for the duration of a test.
+func withFakes(t *testing.T, m *fakeMeteorites, d *fakeDatasets) {
+	t.Helper()
+	oldM, oldD := db.Meteorites, db.Datasets
+	db.Meteorites, db.Datasets = m, d
+	t.Cleanup(func() { db.Meteorites, db.Datasets = oldM, oldD })
+}
+
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/meteorites", GetAllMeteorites)
+	r.GET("/meteorites/nearby", GetNearbyMeteorites)
+	r.GET("/datasets", GetDatasets)
+	r.GET("/datasets/:type", GetDatasetsByType)
+	r.GET("/lookup", LookupPoint)
+	return r
+}
+
+func serve(t *testing.T, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	testRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestGetDatasetsDefaults(t *testing.T) {
+	d := &fakeDatasets{rows: []models.Dataset{
+		{ID: 2, DatasetType: "climate", Name: "b", Lat: -37.8, Lon: 144.9, Value: sql.NullFloat64{Float64: 21.5, Valid: true}},
+		{ID: 1, DatasetType: "climate", Name: "a", Lat: -38, Lon: 145},
+	}}
+	withFakes(t, &fakeMeteorites{}, d)
+
+	w := serve(t, "/datasets/climate")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	f := d.filter
+	if f.Type != "climate" || f.Limit != defaultLimit || f.Offset != 0 {
+		t.Errorf("filter type/limit/offset = %q/%d/%d", f.Type, f.Limit, f.Offset)
+	}
+	// The listing keeps the original value range, which leaves out NULL values
+	if f.ValueMin == nil || *f.ValueMin != defaultValueMin || f.ValueMax == nil || *f.ValueMax != defaultValueMax {
+		t.Errorf("value range = %v..%v, want %v..%v", f.ValueMin, f.ValueMax, defaultValueMin, defaultValueMax)
+	}
+
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0]["id"] != 2.0 {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestGetDatasetsFilters(t *testing.T) {
+	d := &fakeDatasets{}
+	withFakes(t, &fakeMeteorites{}, d)
+
+	w := serve(t, "/datasets?type=wind&value_min=-5&limit=10&offset=20&bbox=140,-40,150,-30")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	f := d.filter
+	if f.Type != "wind" || f.Limit != 10 || f.Offset != 20 {
+		t.Errorf("filter type/limit/offset = %q/%d/%d", f.Type, f.Limit, f.Offset)
+	}
+	if f.ValueMin == nil || *f.ValueMin != -5 || f.ValueMax == nil || *f.ValueMax != defaultValueMax {
+		t.Errorf("value range = %v..%v", f.ValueMin, f.ValueMax)
+	}
+	if f.BBox == nil || f.BBox.MinLon != 140 || f.BBox.MaxLat != -30 {
+		t.Errorf("bbox = %+v", f.BBox)
+	}
+}
+
+func TestGetDatasetsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		err    error
+		status int
+	}{
+		{"bad value", "/datasets?value_min=abc", nil, http.StatusBadRequest},
+		{"NaN value", "/datasets?value_min=NaN", nil, http.StatusBadRequest},
+		{"infinite value", "/datasets?value_max=%2BInf", nil, http.StatusBadRequest},
+		{"bad limit", "/datasets?limit=-1", nil, http.StatusBadRequest},
+		{"bad bbox", "/datasets?bbox=1,2,3", nil, http.StatusBadRequest},
+		{"NaN bbox", "/datasets?bbox=NaN,NaN,NaN,NaN", nil, http.StatusBadRequest},
+		{"bad format", "/datasets?format=xml", nil, http.StatusBadRequest},
+		{"repository failure", "/datasets", errors.New("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFakes(t, &fakeMeteorites{}, &fakeDatasets{err: tt.err})
+			if w := serve(t, tt.target); w.Code != tt.status {
+				t.Errorf("status = %d, want %d (body %s)", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestGetAllMeteoritesDefaults(t *testing.T) {
+	m := &fakeMeteorites{rows: []models.Meteorite{{ID: 1, Name: "Allende"}}}
+	withFakes(t, m, &fakeDatasets{})
+
+	w := serve(t, "/meteorites?year_start=1950&recclass=CV3")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	f := m.filter
+	if f.YearStart == nil || *f.YearStart != 1950 || f.YearEnd == nil || *f.YearEnd != defaultYearEnd {
+		t.Errorf("year range = %v..%v", f.YearStart, f.YearEnd)
+	}
+	if f.MassMin == nil || *f.MassMin != defaultMassMin || f.MassMax == nil || *f.MassMax != defaultMassMax {
+		t.Errorf("mass range = %v..%v", f.MassMin, f.MassMax)
+	}
+	if f.Recclass != "CV3" || f.Limit != defaultLimit {
+		t.Errorf("recclass/limit = %q/%d", f.Recclass, f.Limit)
+	}
+}
+
+func TestGetNearbyMeteorites(t *testing.T) {
+	m := &fakeMeteorites{}
+	withFakes(t, m, &fakeDatasets{})
+
+	w := serve(t, "/meteorites/nearby?lat=-37.8&lon=144.9&radius=1000")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if n := m.filter.Near; n == nil || n.Lat != -37.8 || n.Lon != 144.9 || n.Radius != 1000 {
+		t.Errorf("near = %+v", n)
+	}
+	if m.filter.Limit != 0 {
+		t.Errorf("limit = %d, want unpaginated", m.filter.Limit)
+	}
+
+	for _, target := range []string{
+		"/meteorites/nearby?lat=91&lon=0&radius=1000",
+		"/meteorites/nearby?lat=0&lon=0&radius=-1",
+		"/meteorites/nearby?lat=0&lon=0",
+		"/meteorites/nearby?lat=NaN&lon=NaN&radius=1000",
+		"/meteorites/nearby?lat=0&lon=Inf&radius=1000",
+		"/meteorites/nearby?lat=0&lon=0&radius=NaN",
+	} {
+		if w := serve(t, target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestLookupPointLayers(t *testing.T) {
+	d := &fakeDatasets{}
+	withFakes(t, &fakeMeteorites{}, d)
+
+	w := serve(t, "/lookup?lat=-38&lon=145.5&layers=vegetation,catchment,vegetation")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if want := []string{"vegetation", "catchment"}; !reflect.DeepEqual(d.types, want) {
+		t.Errorf("layers = %v, want %v", d.types, want)
+	}
+
+	for _, target := range []string{
+		"/lookup?lat=-38&lon=145.5&layers=bogus",
+		"/lookup?lat=-38&lon=145.5&layers=vegetation,Catchment",
+		"/lookup?lat=-38&lon=145.5&layers=vegetation,",
+		"/lookup?lat=NaN&lon=NaN",
+	} {
+		if w := serve(t, target); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestParsePercentiles(t *testing.T) {
+	tests := []struct {
+		v    string
+		want []float64
+		ok   bool
+	}{
+		{"", []float64{}, true},
+		{"50", []float64{50}, true},
+		{"0, 25,99.9,100", []float64{0, 25, 99.9, 100}, true},
+		{"NaN", nil, false},
+		{"50,nan", nil, false},
+		{"Inf", nil, false},
+		{"-Inf", nil, false},
+		{"-1", nil, false},
+		{"100.5", nil, false},
+		{"50,", nil, false},
+	}
+	for _, tt := range tests {
+		got, err := parsePercentiles(tt.v)
+		if (err == nil) != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePercentiles(%q) = %v, %v; want %v (ok %v)", tt.v, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// heatmap.go
+//
+// Heatmap raster tile endpoint for GeoGO
+// Renders the kernel density of a dataset type as PNG tiles for map overlays.
+// Compliance Level: High
+//
+// - Route: /heatmap/:type/:z/:x/:y.png (XYZ scheme, y grows southwards)
+// - Accepts the dataset filters (value_min/value_max, from/to, location/radius,
+//   climate period/month and variable); the tile itself replaces bbox
+// - Rows within radius pixels outside the tile are included so kernels are
+//   not cut at tile edges
+// - Empty tiles are transparent PNGs rather than 204, which image layers
+//   would show as broken tiles
+//
+// Parameters:
+//   - radius: Kernel radius in pixels (default 20, 1-128)
+//   - weight: count (default) | value | mass; rows without a positive weight
+//     are left out
+//   - ramp: heat (default), viridis, magma, blues or comma-separated hex
+//     colours, low to high (e.g. ffffb2,fd8d3c,bd0026)
+//   - max: Density of the last ramp colour; by default each tile is scaled to
+//     its own maximum, so pass a fixed max for a seamless mosaic
+//   - opacity: Alpha of the densest pixels, 0-1 (default 0.8)
+//
+// TODO: Add Cache-Control headers once datasets carry an update timestamp
+
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/geo"
+	"GeoGO/heatmap"
+	"bytes"
+	"image/png"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mimePNG is the media type of heatmap tiles.
+const mimePNG = "image/png"
+
+// Heatmap defaults and bounds.
+const (
+	defaultHeatmapRadius  = 20.0
+	maxHeatmapRadius      = 128.0
+	defaultHeatmapOpacity = 0.8
+)
+
+// parseHeatmapOptions reads radius, ramp, max and opacity.
+func parseHeatmapOptions(c *gin.Context) (heatmap.Options, error) {
+	o := heatmap.Options{Size: geo.TileSize}
+	var err error
+	if o.Radius, err = queryFloat(c, "radius", defaultHeatmapRadius); err != nil {
+		return o, err
+	}
+	if o.Radius < 1 || o.Radius > maxHeatmapRadius {
+		return o, badRequest("radius must be between 1 and %g pixels", maxHeatmapRadius)
+	}
+	if o.Ramp, err = heatmap.ParseRamp(c.DefaultQuery("ramp", "heat")); err != nil {
+		return o, badRequest("Invalid ramp: %v (expected heat, viridis, magma, blues or hex colours)", err)
+	}
+	if o.Max, err = queryFloat(c, "max", 0); err != nil {
+		return o, err
+	}
+	if o.Max < 0 {
+		return o, badRequest("max must be positive")
+	}
+	if o.Opacity, err = queryFloat(c, "opacity", defaultHeatmapOpacity); err != nil {
+		return o, err
+	}
+	if o.Opacity < 0 || o.Opacity > 1 {
+		return o, badRequest("opacity must be between 0 and 1")
+	}
+	return o, nil
+}
+
+// GetHeatmapTile renders one heatmap tile of a dataset type.
+func GetHeatmapTile(c *gin.Context) {
+	z, x, y, err := parseTileAddress(c, ".png")
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	filter, err := parseDatasetFilter(c)
+	if err == nil {
+		err = parseDatasetListing(c, &filter)
+	}
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	if filter.BBox != nil {
+		respondFilterError(c, badRequest("bbox cannot be combined with a heatmap tile"))
+		return
+	}
+	opts, err := parseHeatmapOptions(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	weight := c.DefaultQuery("weight", "count")
+	if weight != "count" && weight != "value" && weight != "mass" {
+		respondFilterError(c, badRequest("Invalid weight %q: expected count, value or mass", weight))
+		return
+	}
+
+	box := geo.TileBounds(z, x, y, opts.Radius/geo.TileSize)
+	filter.BBox = &box
+	filter.After, filter.Limit, filter.Offset, filter.Sort = nil, 0, 0, ""
+	datasets, err := db.Datasets.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("❌ Failed to fetch datasets for heatmap %s/%d/%d/%d: %v", filter.Type, z, x, y, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch data"})
+		return
+	}
+
+	points := make([]heatmap.Point, 0, len(datasets))
+	for _, d := range datasets {
+		p := heatmap.Point{Weight: 1}
+		switch {
+		case weight == "value" && d.Value.Valid:
+			p.Weight = d.Value.Float64
+		case weight == "mass" && d.Mass.Valid:
+			p.Weight = d.Mass.Float64
+		case weight != "count":
+			continue
+		}
+		p.X, p.Y = geo.TileCoords(z, x, y, geo.TileSize, d.Lat, d.Lon)
+		points = append(points, p)
+	}
+
+	img, _ := heatmap.Render(points, opts)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		log.Printf("❌ Failed to encode heatmap %s/%d/%d/%d: %v", filter.Type, z, x, y, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render tile"})
+		return
+	}
+	c.Data(http.StatusOK, mimePNG, buf.Bytes())
+}
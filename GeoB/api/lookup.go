@@ -0,0 +1,132 @@
+// lookup.go
+//
+// Point-in-polygon lookup endpoint for GeoGO
+// Classifies a site against the area layers in one call.
+// Compliance Level: High
+//
+// - Route: /lookup?lat=&lon=&layers=vegetation,catchment
+// - Returns every polygon row of the requested layers that contains the point,
+//   with its attributes (zone, type and link for vegetation zones)
+// - Supports format=geojson, returning the matching polygons as features
+//
+// Parameters:
+//   - lat, lon: Site coordinates (required)
+//   - layers: Comma-separated dataset types (default all); unknown types are
+//     rejected so a typo is not mistaken for "no zone here"
+//
+// NOTE: Only rows stored as polygons take part. Zone boundaries come from the
+// GeoJSON exports (geogo import VegetationZones_*.geojson, Catchments_*.geojson);
+// rows placed at a point never match
+
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/models"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// lookupMatch is one area feature containing the site.
+type lookupMatch struct {
+	Layer      string                 `json:"layer"`
+	ID         int                    `json:"id"`
+	Name       string                 `json:"name"`
+	Value      *float64               `json:"value,omitempty"`
+	Unit       string                 `json:"unit,omitempty"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// parseLayers reads the layers parameter; an empty result means every layer.
+func parseLayers(c *gin.Context) ([]string, error) {
+	v, ok := c.GetQuery("layers")
+	if !ok {
+		return nil, nil
+	}
+	var layers []string
+	for _, layer := range strings.Split(v, ",") {
+		layer = strings.TrimSpace(layer)
+		if layer == "" {
+			return nil, badRequest("Invalid layers %q: expected comma-separated dataset types", v)
+		}
+		if !models.DatasetType(layer).Valid() {
+			return nil, badRequest("Unknown layer %q: expected one of %v", layer, models.DatasetTypes)
+		}
+		if !slices.Contains(layers, layer) {
+			layers = append(layers, layer)
+		}
+	}
+	return layers, nil
+}
+
+// LookupPoint returns the area features of each layer containing a site.
+func LookupPoint(c *gin.Context) {
+	format, err := parseFormat(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	if c.Query("lat") == "" || c.Query("lon") == "" {
+		respondFilterError(c, badRequest("lat and lon are required"))
+		return
+	}
+	lat, lon, err := parseCoordinates(c.Query("lat"), c.Query("lon"))
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	layers, err := parseLayers(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+
+	datasets, err := db.Datasets.Containing(c.Request.Context(), layers, lat, lon)
+	if err != nil {
+		log.Printf("❌ Failed to look up %.5f,%.5f: %v", lat, lon, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to look up location"})
+		return
+	}
+	log.Printf("✅ Lookup %.5f,%.5f: %d area(s)", lat, lon, len(datasets))
+
+	if format == formatGeoJSON {
+		respondRows(c, format, datasets)
+		return
+	}
+	matches := make([]lookupMatch, 0, len(datasets))
+	for _, d := range datasets {
+		m := lookupMatch{
+			Layer:      string(d.DatasetType),
+			ID:         d.ID,
+			Name:       d.Name,
+			Attributes: map[string]interface{}{},
+		}
+		if d.Value.Valid {
+			m.Value = &d.Value.Float64
+		}
+		if d.Unit.Valid {
+			m.Unit = d.Unit.String
+		}
+		if d.Metadata.Valid {
+			if err := json.Unmarshal([]byte(d.Metadata.String), &m.Attributes); err != nil {
+				log.Printf("❌ Dataset %d has invalid metadata: %v", d.ID, err)
+			}
+		}
+		matches = append(matches, m)
+	}
+	if layers == nil {
+		layers = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"lat":     lat,
+		"lon":     lon,
+		"layers":  layers,
+		"count":   len(matches),
+		"matches": matches,
+	})
+}
@@ -0,0 +1,236 @@
+// pipes.go
+//
+// Drainage pipe network endpoints for GeoGO
+// Compliance Level: High
+//
+// - Route: /infrastructure/pipes/trace?pit=&direction=downstream|upstream
+// - Returns every reachable pipe, the pit count, total pipe length and the
+//   outfall(s) (downstream) or source pits (upstream)
+// - Route: /infrastructure/pipes/:id/hydraulics - full-bore capacity and QA
+//   issues of one pipe (see the hydraulics package)
+// - Route: /infrastructure/pipes/qa?issue=&limit=&offset= - QA report of every
+//   flagged pipe with per-issue counts
+// - Closed loops in the register are reported under "loops"; their pits are
+//   listed as outfalls/sources because the trace cannot leave them
+// - The network is loaded from the pipes table and cached in memory; it is
+//   reloaded after pipeNetworkTTL so a new import is picked up without a restart
+//
+// Parameters:
+//   - pit: Pit identifier, e.g. WcwWint0034MH (required)
+//   - direction: downstream (default) | upstream
+//   - issue: Comma-separated QA issue codes to list (default all)
+//
+// NOTE: Pipes carry no geometry yet (the register has no coordinates), so
+// there is no GeoJSON output
+
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/hydraulics"
+	"GeoGO/models"
+	"GeoGO/network"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pipeNetworkTTL is how long a loaded pipe network is reused.
+const pipeNetworkTTL = 5 * time.Minute
+
+var pipeNetwork struct {
+	sync.Mutex
+	graph  *network.Graph
+	loaded time.Time
+}
+
+// loadPipeNetwork returns the cached network, reloading it when stale.
+func loadPipeNetwork(ctx context.Context) (*network.Graph, error) {
+	pipeNetwork.Lock()
+	defer pipeNetwork.Unlock()
+	if pipeNetwork.graph != nil && time.Since(pipeNetwork.loaded) < pipeNetworkTTL {
+		return pipeNetwork.graph, nil
+	}
+	pipes, err := db.Pipes.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pipeNetwork.graph = network.New(pipes)
+	pipeNetwork.loaded = time.Now()
+	log.Printf("🔄 Loaded pipe network: %d pipes", pipeNetwork.graph.Len())
+	return pipeNetwork.graph, nil
+}
+
+// pipeNetworkOrError loads the network and writes the error response on failure.
+func pipeNetworkOrError(c *gin.Context) (*network.Graph, bool) {
+	graph, err := loadPipeNetwork(c.Request.Context())
+	if err != nil {
+		log.Printf("❌ Failed to load pipe network: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load pipe network"})
+		return nil, false
+	}
+	if graph.Len() == 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Pipe network has not been imported (geogo import -type pipe_network)"})
+		return nil, false
+	}
+	return graph, true
+}
+
+// pipeTrace is the trace response.
+type pipeTrace struct {
+	Pit          string        `json:"pit"`
+	Direction    string        `json:"direction"`
+	PipeCount    int           `json:"pipe_count"`
+	PitCount     int           `json:"pit_count"`
+	TotalLengthM float64       `json:"total_length_m"`
+	Outfall      *string       `json:"outfall,omitempty"`
+	Outfalls     []string      `json:"outfalls,omitempty"`
+	Sources      []string      `json:"sources,omitempty"`
+	Loops        [][]string    `json:"loops,omitempty"`
+	Pipes        []models.Pipe `json:"pipes"`
+}
+
+// TracePipes returns every pipe reachable from a pit, downstream or upstream.
+func TracePipes(c *gin.Context) {
+	pit := strings.TrimSpace(c.Query("pit"))
+	if pit == "" {
+		respondFilterError(c, badRequest("pit is required"))
+		return
+	}
+	dir := network.Direction(c.DefaultQuery("direction", string(network.Downstream)))
+	if dir != network.Downstream && dir != network.Upstream {
+		respondFilterError(c, badRequest("Invalid direction %q: expected downstream or upstream", dir))
+		return
+	}
+
+	graph, ok := pipeNetworkOrError(c)
+	if !ok {
+		return
+	}
+	trace, err := graph.Trace(pit, dir)
+	if errors.Is(err, network.ErrUnknownPit) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown pit " + pit})
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Pipe trace failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Pipe trace failed"})
+		return
+	}
+
+	resp := pipeTrace{
+		Pit:          trace.Pit,
+		Direction:    string(trace.Direction),
+		PipeCount:    len(trace.Pipes),
+		PitCount:     trace.Pits,
+		Loops:        trace.Loops,
+		TotalLengthM: trace.TotalLength,
+		Pipes:        trace.Pipes,
+	}
+	if dir == network.Downstream {
+		resp.Outfalls = trace.Terminals
+		if len(trace.Terminals) == 1 {
+			resp.Outfall = &trace.Terminals[0]
+		}
+	} else {
+		resp.Sources = trace.Terminals
+	}
+	if resp.Pipes == nil {
+		resp.Pipes = []models.Pipe{}
+	}
+
+	log.Printf("✅ Traced %d pipes %s of %s (%.1f m)", resp.PipeCount, dir, pit, resp.TotalLengthM)
+	c.JSON(http.StatusOK, resp)
+}
+
+// defaultQALimit is the QA report page size used when no limit is given.
+const defaultQALimit = 100
+
+// qaIssues are the issue codes accepted by the issue parameter.
+var qaIssues = []string{
+	hydraulics.IssueReversedGrade, hydraulics.IssueFlatGrade, hydraulics.IssueSteepGrade,
+	hydraulics.IssueGradeMismatch, hydraulics.IssueInvertStep,
+	hydraulics.IssueMissingSlope, hydraulics.IssueMissingSize,
+}
+
+// GetPipeHydraulics returns the full-bore capacity and QA issues of one pipe.
+func GetPipeHydraulics(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondFilterError(c, badRequest("Invalid pipe id %q", c.Param("id")))
+		return
+	}
+	graph, ok := pipeNetworkOrError(c)
+	if !ok {
+		return
+	}
+	pipe, ok := graph.Pipe(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pipe not found"})
+		return
+	}
+
+	result := hydraulics.Analyse(graph, pipe)
+	log.Printf("✅ Analysed pipe %d: %d issue(s)", id, len(result.Issues))
+	c.JSON(http.StatusOK, gin.H{"pipe": pipe, "hydraulics": result})
+}
+
+// GetPipeQA returns the hydraulic QA report for the whole network.
+func GetPipeQA(c *gin.Context) {
+	codes := make(map[string]bool)
+	if v := c.Query("issue"); v != "" {
+		for _, code := range strings.Split(v, ",") {
+			code = strings.TrimSpace(code)
+			if !slices.Contains(qaIssues, code) {
+				respondFilterError(c, badRequest("Invalid issue %q: expected one of %s", code, strings.Join(qaIssues, ", ")))
+				return
+			}
+			codes[code] = true
+		}
+	}
+	limit, offset, err := parsePage(c, defaultQALimit)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	graph, ok := pipeNetworkOrError(c)
+	if !ok {
+		return
+	}
+
+	report := hydraulics.QA(graph)
+	results := report.Results
+	if len(codes) > 0 {
+		results = slices.DeleteFunc(results, func(r hydraulics.Result) bool {
+			for code := range codes {
+				if r.HasIssue(code) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	matched := len(results)
+	results = results[min(offset, matched):]
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	report.Results = results
+
+	log.Printf("✅ Pipe QA: %d of %d pipes flagged, returning %d", report.Flagged, report.Pipes, len(results))
+	c.JSON(http.StatusOK, gin.H{
+		"pipes":   report.Pipes,
+		"flagged": report.Flagged,
+		"issues":  report.Issues,
+		"matched": matched,
+		"results": report.Results,
+	})
+}
@@ -1,216 +1,460 @@
 // search.go
 //
-// Search functionality implementation for GeoGO
-// Provides query building and parallel execution capabilities.
+// Search filter parsing for GeoGO
+// Translates query parameters into repository filter structs.
 // Compliance Level: High
 //
-// - Implements parameterized query construction
-// - Handles concurrent query execution
-// - Manages resource synchronization
-// - Implements proper error handling
+// - Validates every numeric parameter and reports the offending name
+// - Resolves the location parameter (literal "lat,lon" or geocoded place name)
+// - Keeps handlers thin: no SQL is built at the HTTP layer
 //
-// TODO: Add query plan analysis for performance optimization
-// TODO: Implement query result caching for frequently accessed data
-// TODO: Add support for more complex spatial queries
-// TODO: Consider implementing query timeout mechanism
-// TODO: Add support for query result streaming
+// Shared Parameters:
+//   - limit / offset: Pagination (limit defaults to 50)
+//   - cursor: Keyset pagination; pass it empty for the first page, then the
+//     returned next_cursor (cannot be combined with offset)
+//   - from / to: Row time as RFC 3339 or YYYY-MM-DD (UTC); to is exclusive. The
+//     row time is the timestamp, else January 1 of the year (meteorites)
+//   - location: "lat,lon" or a place name resolved by the geocoding provider
+//   - radius: Search radius in metres around location (default 50 km)
+//   - bbox: Viewport "minLon,minLat,maxLon,maxLat" (minLon > maxLon crosses the antimeridian)
+//   - POST body: GeoJSON (Polygon, MultiPolygon, Feature, FeatureCollection) or
+//     WKT (POLYGON, MULTIPOLYGON) restricting results to the drawn area
 //
-// NOTE: Parallel execution may need tuning based on database connection pool size
-// NOTE: Consider implementing query result batching for large datasets
+// Listing Parameters (/datasets, /datasets/:type):
+//   - period / month: Climate period (JJA, NDJFMA, July, ...) or month number
+//     whose value replaces value for value_min/value_max, sort and the response
+//   - variable: Climate variable (tas, tasmax, tasmin, hurs, pan-evap)
+//   - sort: value | -value (default newest first; cannot be combined with cursor)
 //
-// Cache Policy:
-// - No direct caching at search level
-// - Consider implementing query result caching for common searches
-// - Cache invalidation strategy needed if implemented
+// TODO: Add support for more complex filter combinations
+// TODO: Consider implementing filter expression parsing
+//
+// NOTE: The meteorite listings default to year 0-9999 and mass 0-10,000,000 g
+// and /datasets to value 0-10,000,000, so rows without a value are left out
+// unless a bound is given; the analysis endpoints leave unset ranges unbounded
 
 package api
 
 import (
+	"GeoGO/api/geocoding"
 	"GeoGO/db"
+	"GeoGO/geo"
 	"GeoGO/models"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"net/http"
+	"slices"
 	"strconv"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// buildQueryFilters constructs a SQL WHERE clause and corresponding arguments based on query parameters.
-// It handles multiple filter types including year range, meteorite class, mass range, and location-based filtering.
-// The function implements parameterized queries to prevent SQL injection and validates all input parameters.
-//
-// Parameters:
-//   - c: Gin context containing query parameters
-//
-// Returns:
-//   - string: The constructed WHERE clause
-//   - []interface{}: Arguments for the parameterized query
-//   - error: Any validation or parsing errors encountered
-//
-// Example:
-//
-//	Input: year_start=1900, year_end=2000, mass_min=1000, location="40.7128,-74.0060"
-//	Output: "WHERE year BETWEEN $1 AND $2 AND mass BETWEEN $3 AND $4 AND ST_DWithin(...)", [1900, 2000, 1000, ...]
-//
-// TODO: Add support for more complex filter combinations
-// TODO: Implement filter validation against schema
-// TODO: Add support for custom filter operators
-// TODO: Consider implementing filter expression parsing
-//
-// NOTE: Current implementation uses simple string concatenation
-// NOTE: Consider using a query builder library for more complex queries
-func buildQueryFilters(c *gin.Context) (string, []interface{}, error) {
-	var filters []string
-	var args []interface{}
-	paramIndex := 1
-
-	yearStart, err1 := strconv.Atoi(c.DefaultQuery("year_start", "0"))
-	yearEnd, err2 := strconv.Atoi(c.DefaultQuery("year_end", "9999"))
-	recclass := c.DefaultQuery("recclass", "")
-	massMin, err3 := strconv.ParseFloat(c.DefaultQuery("mass_min", "0"), 64)
-	massMax, err4 := strconv.ParseFloat(c.DefaultQuery("mass_max", "10000000"), 64)
-	location := c.DefaultQuery("location", "")
-
-	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
-		return "", nil, fmt.Errorf("invalid query parameters")
-	}
-
-	filters = append(filters, fmt.Sprintf("year BETWEEN $%d AND $%d", paramIndex, paramIndex+1))
-	args = append(args, yearStart, yearEnd)
-	paramIndex += 2
-
-	if recclass != "" {
-		filters = append(filters, fmt.Sprintf("recclass = $%d", paramIndex))
-		args = append(args, recclass)
-		paramIndex++
-	}
-
-	filters = append(filters, fmt.Sprintf("mass BETWEEN $%d AND $%d", paramIndex, paramIndex+1))
-	args = append(args, massMin, massMax)
-	paramIndex += 2
-
-	if location != "" {
-		coords := strings.Split(location, ",")
-		if len(coords) == 2 {
-			lat, errLat := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
-			lon, errLon := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
-			if errLat == nil && errLon == nil {
-				filters = append(filters, fmt.Sprintf("ST_DWithin(geom::geography, ST_SetSRID(ST_MakePoint($%d, $%d), 4326)::geography, $%d)", paramIndex, paramIndex+1, paramIndex+2))
-				args = append(args, lon, lat, 50000)
-				paramIndex += 3
-			} else {
-				return "", nil, fmt.Errorf("invalid location format, expected lat,lon")
-			}
-		} else {
-			return "", nil, fmt.Errorf("location query is invalid")
+// defaultLimit is the page size used when no limit is given.
+const defaultLimit = 50
+
+// defaultRadius is the search radius in metres used when location is given without radius.
+const defaultRadius = 50000.0
+
+// Default ranges of the listing endpoints, kept from the original queries.
+const (
+	defaultYearStart = 0
+	defaultYearEnd   = 9999
+	defaultMassMin   = 0.0
+	defaultMassMax   = 10000000.0
+	defaultValueMin  = 0.0
+	defaultValueMax  = 10000000.0
+)
+
+// maxPolygonBody caps the size of a POSTed polygon filter.
+const maxPolygonBody = 1 << 20
+
+// filterError is a request problem reported to the client with its HTTP status.
+type filterError struct {
+	status  int
+	message string
+}
+
+func (e *filterError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return &filterError{status: http.StatusBadRequest, message: fmt.Sprintf(format, args...)}
+}
+
+// respondFilterError writes err as a JSON error response.
+func respondFilterError(c *gin.Context, err error) {
+	var fe *filterError
+	if errors.As(err, &fe) {
+		log.Printf("❌ Invalid request: %s", fe.message)
+		c.JSON(fe.status, gin.H{"error": fe.message})
+		return
+	}
+	log.Printf("❌ Failed to parse request: %v", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse request"})
+}
+
+// queryInt parses an optional integer parameter, returning def when absent.
+func queryInt(c *gin.Context, name string, def int) (int, error) {
+	v := c.Query(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, badRequest("Invalid %s: %q is not an integer", name, v)
+	}
+	return n, nil
+}
+
+// queryIntPtr parses an optional integer parameter, returning nil when absent.
+func queryIntPtr(c *gin.Context, name string) (*int, error) {
+	if c.Query(name) == "" {
+		return nil, nil
+	}
+	n, err := queryInt(c, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// queryFloat parses an optional float parameter, returning def when absent.
+func queryFloat(c *gin.Context, name string, def float64) (float64, error) {
+	v := c.Query(name)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || !finite(f) {
+		return 0, badRequest("Invalid %s: %q is not a finite number", name, v)
+	}
+	return f, nil
+}
+
+// finite reports whether f is neither NaN nor infinite. ParseFloat accepts
+// both, and they slip through range checks because every comparison with NaN
+// is false.
+func finite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// timeLayouts are the accepted from/to formats.
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// queryTime parses an optional timestamp parameter, returning nil when absent.
+// Times without an offset are UTC.
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	v := strings.TrimSpace(c.Query(name))
+	if v == "" {
+		return nil, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return &t, nil
 		}
 	}
+	return nil, badRequest("Invalid %s %q: expected RFC 3339 or YYYY-MM-DD", name, v)
+}
 
-	whereClause := ""
-	if len(filters) > 0 {
-		whereClause = " WHERE " + strings.Join(filters, " AND ")
+// parseTimeRange reads from and to, rejecting empty ranges.
+func parseTimeRange(c *gin.Context) (*time.Time, *time.Time, error) {
+	from, err := queryTime(c, "from")
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := queryTime(c, "to")
+	if err != nil {
+		return nil, nil, err
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, badRequest("from must be before to")
+	}
+	return from, to, nil
+}
+
+// queryFloatPtr parses an optional float parameter, returning nil when absent.
+func queryFloatPtr(c *gin.Context, name string) (*float64, error) {
+	if c.Query(name) == "" {
+		return nil, nil
+	}
+	f, err := queryFloat(c, name, 0)
+	if err != nil {
+		return nil, err
 	}
+	return &f, nil
+}
 
-	log.Printf("🔍 Generated SQL WHERE clause: %s", whereClause)
-	return whereClause, args, nil
+// withDefault returns p, or a pointer to def when p is nil.
+func withDefault[T any](p *T, def T) *T {
+	if p == nil {
+		return &def
+	}
+	return p
 }
 
-// FetchMeteorites executes a parameterized query to retrieve meteorite data based on provided filters.
-// It combines the base query with dynamically constructed filters and handles pagination.
-// The function ensures proper error handling and logging of query execution.
-//
-// Parameters:
-//   - c: Gin context for request handling
-//   - query: Base SQL query string
-//   - limit: Maximum number of results to return
-//   - offset: Number of results to skip (for pagination)
-//
-// Returns:
-//   - []models.Meteorite: Slice of meteorite data matching the query
-//   - error: Any database or query execution errors
-//
-// Note: The function uses parameterized queries to prevent SQL injection and
-// implements proper error handling for database operations.
-//
-// TODO: Add query plan analysis for performance optimization
-// TODO: Implement query timeout mechanism
-// TODO: Add support for query result streaming
-// TODO: Consider implementing query result caching
-//
-// NOTE: Current implementation may need optimization for large result sets
-// NOTE: Consider implementing query result batching
-func FetchMeteorites(c *gin.Context, query string, limit, offset int) ([]models.Meteorite, error) {
-	whereClause, args, err := buildQueryFilters(c)
+// parsePage reads limit and offset.
+func parsePage(c *gin.Context, defLimit int) (int, int, error) {
+	limit, err := queryInt(c, "limit", defLimit)
 	if err != nil {
-		return nil, fmt.Errorf("error building filters: %v", err)
+		return 0, 0, err
 	}
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	if limit < 0 || offset < 0 {
+		return 0, 0, badRequest("limit and offset must not be negative")
+	}
+	return limit, offset, nil
+}
 
-	queryWithFilters := query + whereClause + fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
-	args = append(args, limit, offset)
+// parseCursor decodes the cursor parameter. An absent or empty cursor yields
+// nil, i.e. the first page.
+func parseCursor[K any](c *gin.Context, decode func(string) (*K, error)) (*K, error) {
+	token, ok := c.GetQuery("cursor")
+	if !ok {
+		return nil, nil
+	}
+	if c.Query("offset") != "" {
+		return nil, badRequest("cursor and offset cannot be combined")
+	}
+	if token == "" {
+		return nil, nil
+	}
+	key, err := decode(token)
+	if err != nil {
+		return nil, badRequest("Invalid cursor %q", token)
+	}
+	return key, nil
+}
 
-	log.Printf("📡 Executing Query: %s", queryWithFilters)
+// parseCoordinates validates a latitude/longitude pair.
+func parseCoordinates(latStr, lonStr string) (float64, float64, error) {
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	lon, errLon := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if errLat != nil || errLon != nil || !finite(lat) || !finite(lon) {
+		return 0, 0, badRequest("Invalid coordinates %q, %q", latStr, lonStr)
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, badRequest("Coordinates out of range: lat must be within ±90 and lon within ±180")
+	}
+	return lat, lon, nil
+}
 
-	var meteorites []models.Meteorite
-	err = db.DB.Select(&meteorites, queryWithFilters, args...)
+// parseNear resolves the location and radius parameters into a proximity filter.
+// It returns nil when no location is given.
+func parseNear(c *gin.Context) (*db.Proximity, error) {
+	location := strings.TrimSpace(c.Query("location"))
+	if location == "" {
+		return nil, nil
+	}
+	radius, err := queryFloat(c, "radius", defaultRadius)
 	if err != nil {
-		return nil, fmt.Errorf("query error: %v", err)
+		return nil, err
+	}
+	if radius < 0 {
+		return nil, badRequest("radius must not be negative")
+	}
+
+	// Literal coordinates skip the geocoder
+	if parts := strings.Split(location, ","); len(parts) == 2 {
+		if lat, lon, err := parseCoordinates(parts[0], parts[1]); err == nil {
+			return &db.Proximity{Lat: lat, Lon: lon, Radius: radius}, nil
+		}
 	}
 
-	log.Printf("✅ Query successful, found %d results", len(meteorites))
-	return meteorites, nil
+	coords, err := geocoding.ForwardGeocode(location)
+	if errors.Is(err, geocoding.ErrNotFound) {
+		return nil, &filterError{status: http.StatusNotFound, message: fmt.Sprintf("Location %q not found", location)}
+	}
+	if err != nil {
+		log.Printf("❌ Geocoding failed for '%s': %v", location, err)
+		return nil, &filterError{status: http.StatusInternalServerError, message: "Failed to get coordinates for location"}
+	}
+	log.Printf("🌍 Location search: '%s' -> [lat: %.6f, lon: %.6f]", location, coords.Lat, coords.Lon)
+	return &db.Proximity{Lat: coords.Lat, Lon: coords.Lon, Radius: radius}, nil
 }
 
-// FetchParallel executes multiple queries concurrently and aggregates their results.
-// It uses a worker pool pattern with goroutines and channels for efficient parallel execution.
-// The function ensures proper synchronization and error handling across all concurrent operations.
-//
-// Parameters:
-//   - c: Gin context for request handling
-//   - queries: Slice of query strings to execute in parallel
-//
-// Returns:
-//   - [][]models.Meteorite: Slice of meteorite data slices, one for each query
-//
-// Implementation Details:
-//   - Uses sync.WaitGroup for goroutine synchronization
-//   - Implements a buffered channel for result collection
-//   - Handles error propagation from individual queries
-//   - Ensures proper cleanup of resources
-//
-// TODO: Add support for dynamic worker pool sizing
-// TODO: Implement graceful shutdown of workers
-// TODO: Add support for query prioritization
-// TODO: Consider implementing result streaming
-//
-// NOTE: Current implementation uses fixed-size worker pool
-// NOTE: Consider implementing work stealing for better load balancing
-// NOTE: May need tuning based on database connection pool size
-func FetchParallel(c *gin.Context, queries []string) [][]models.Meteorite {
-	var wg sync.WaitGroup
-	results := make([][]models.Meteorite, len(queries))
-	ch := make(chan struct {
-		index int
-		data  []models.Meteorite
-		err   error
-	}, len(queries))
-	for i, query := range queries {
-		wg.Add(1)
-		go func(i int, query string) {
-			defer wg.Done()
-			data, err := FetchMeteorites(c, query, 50, 0)
-			ch <- struct {
-				index int
-				data  []models.Meteorite
-				err   error
-			}{i, data, err}
-		}(i, query)
-	}
-	wg.Wait()
-	close(ch)
-	for res := range ch {
-		results[res.index] = res.data
-	}
-	return results
+// parseBBox reads the bbox parameter, returning nil when absent.
+func parseBBox(c *gin.Context) (*geo.BBox, error) {
+	v := c.Query("bbox")
+	if v == "" {
+		return nil, nil
+	}
+	box, err := geo.ParseBBox(v)
+	if err != nil {
+		return nil, badRequest("Invalid bbox: %v", err)
+	}
+	return &box, nil
+}
+
+// parseWithin reads the polygon filter from a POST body. GET requests carry
+// no polygon; a POST without one is rejected.
+func parseWithin(c *gin.Context) (geo.MultiPolygon, error) {
+	if c.Request.Method != http.MethodPost {
+		return nil, nil
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPolygonBody))
+	if err != nil {
+		return nil, badRequest("Failed to read polygon body: %v", err)
+	}
+	polygon, err := geo.ParsePolygon(body)
+	if err != nil {
+		return nil, badRequest("Invalid polygon: %v", err)
+	}
+	return polygon, nil
+}
+
+// parseSpatial combines location/radius, bbox and the POSTed polygon.
+func parseSpatial(c *gin.Context) (db.SpatialFilter, error) {
+	var s db.SpatialFilter
+	var err error
+	if s.Near, err = parseNear(c); err != nil {
+		return s, err
+	}
+	if s.BBox, err = parseBBox(c); err != nil {
+		return s, err
+	}
+	s.Within, err = parseWithin(c)
+	return s, err
+}
+
+// parseMeteoriteFilter builds a MeteoriteFilter from year_start, year_end,
+// mass_min, mass_max, recclass, the spatial parameters, limit, offset and cursor.
+// Omitted year and mass bounds take the listing defaults.
+func parseMeteoriteFilter(c *gin.Context, defLimit int) (db.MeteoriteFilter, error) {
+	var f db.MeteoriteFilter
+	var err error
+	if f.Limit, f.Offset, err = parsePage(c, defLimit); err != nil {
+		return f, err
+	}
+	if f.After, err = parseCursor(c, db.ParseMeteoriteCursor); err != nil {
+		return f, err
+	}
+	if f.YearStart, err = queryIntPtr(c, "year_start"); err != nil {
+		return f, err
+	}
+	if f.YearEnd, err = queryIntPtr(c, "year_end"); err != nil {
+		return f, err
+	}
+	if f.MassMin, err = queryFloatPtr(c, "mass_min"); err != nil {
+		return f, err
+	}
+	if f.MassMax, err = queryFloatPtr(c, "mass_max"); err != nil {
+		return f, err
+	}
+	f.YearStart = withDefault(f.YearStart, defaultYearStart)
+	f.YearEnd = withDefault(f.YearEnd, defaultYearEnd)
+	f.MassMin = withDefault(f.MassMin, defaultMassMin)
+	f.MassMax = withDefault(f.MassMax, defaultMassMax)
+	f.Recclass = c.Query("recclass")
+	f.SpatialFilter, err = parseSpatial(c)
+	return f, err
+}
+
+// parseDatasetFilter builds a DatasetFilter from the :type path parameter (or
+// type query parameter), value_min, value_max, from, to, the spatial
+// parameters, limit, offset and cursor.
+func parseDatasetFilter(c *gin.Context) (db.DatasetFilter, error) {
+	var f db.DatasetFilter
+	var err error
+	if f.Limit, f.Offset, err = parsePage(c, defaultLimit); err != nil {
+		return f, err
+	}
+	if f.After, err = parseCursor(c, db.ParseDatasetCursor); err != nil {
+		return f, err
+	}
+	f.Type = c.Param("type")
+	if f.Type == "" {
+		f.Type = c.Query("type")
+	}
+	if f.ValueMin, err = queryFloatPtr(c, "value_min"); err != nil {
+		return f, err
+	}
+	if f.ValueMax, err = queryFloatPtr(c, "value_max"); err != nil {
+		return f, err
+	}
+	if f.From, f.To, err = parseTimeRange(c); err != nil {
+		return f, err
+	}
+	f.SpatialFilter, err = parseSpatial(c)
+	return f, err
+}
+
+// parseClimatePeriod reads period (a season or month name) or month (1-12)
+// and returns the metadata key of the period, or "" when neither is given.
+func parseClimatePeriod(c *gin.Context) (string, error) {
+	period, month := strings.TrimSpace(c.Query("period")), strings.TrimSpace(c.Query("month"))
+	if period != "" && month != "" {
+		return "", badRequest("period and month cannot be combined")
+	}
+	if month != "" {
+		m, err := queryInt(c, "month", 0)
+		if err != nil {
+			return "", err
+		}
+		if m < 1 || m > 12 {
+			return "", badRequest("Invalid month %d: expected 1-12", m)
+		}
+		period = models.ClimateMonths[m-1]
+	}
+	if period == "" {
+		return "", nil
+	}
+	periods := slices.Concat(models.ClimateSeasons, models.ClimateMonths)
+	i := slices.IndexFunc(periods, func(p string) bool { return strings.EqualFold(p, period) })
+	if i < 0 {
+		return "", badRequest("Invalid period %q: expected Annual, DJF, MAM, JJA, SON, NDJFMA, MJJASO or a month name", period)
+	}
+	return strings.ToLower(periods[i]), nil
+}
+
+// parseClimateVariable reads variable (a CSIRO code) and returns its
+// climate_type, or "" when it is not given.
+func parseClimateVariable(c *gin.Context) (string, error) {
+	variable := strings.TrimSpace(c.Query("variable"))
+	if variable == "" {
+		return "", nil
+	}
+	climateType, ok := climateTypes()[variable]
+	if !ok {
+		return "", badRequest("Invalid variable %q: expected tas, tasmax, tasmin, hurs or pan-evap", variable)
+	}
+	return climateType, nil
+}
+
+// parseDatasetListing reads the parameters that only apply to dataset
+// listings: period or month and variable (climate rows) and sort.
+func parseDatasetListing(c *gin.Context, f *db.DatasetFilter) error {
+	var err error
+	if f.Period, err = parseClimatePeriod(c); err != nil {
+		return err
+	}
+	if f.ClimateType, err = parseClimateVariable(c); err != nil {
+		return err
+	}
+	if f.Period != "" && f.Type != "climate" {
+		return badRequest("period and month only apply to climate datasets")
+	}
+	if f.ClimateType != "" && f.Type != "climate" {
+		return badRequest("variable only applies to climate datasets")
+	}
+
+	switch sort := db.DatasetSort(c.Query("sort")); sort {
+	case "":
+	case db.SortValueAsc, db.SortValueDesc:
+		if _, ok := c.GetQuery("cursor"); ok {
+			return badRequest("cursor and sort cannot be combined")
+		}
+		f.Sort = sort
+	default:
+		return badRequest("Invalid sort %q: expected value or -value", sort)
+	}
+	return nil
 }
@@ -0,0 +1,87 @@
+// tiles.go
+//
+// Vector tile endpoint for GeoGO
+// Serves dataset layers as Mapbox Vector Tiles for map clients.
+// Compliance Level: High
+//
+// - Route: /tiles/:type/:z/:x/:y.mvt (XYZ scheme, y grows southwards)
+// - Tiles contain one layer named after the dataset type
+// - Attributes are chosen with fields=name,value,...; the default depends on zoom
+//   (see db.DefaultTileFields)
+// - Empty tiles are answered with 204 No Content
+//
+// TODO: Add ETag / Cache-Control headers once datasets carry an update timestamp
+
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/geo"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mimeMVT is the media type for Mapbox Vector Tiles.
+const mimeMVT = "application/vnd.mapbox-vector-tile"
+
+// parseTileAddress reads the :z/:x/:y path parameters, where y carries the
+// file extension ext (".mvt", ".png").
+func parseTileAddress(c *gin.Context, ext string) (z, x, y int, err error) {
+	yStr, ok := strings.CutSuffix(c.Param("y"), ext)
+	if !ok {
+		return 0, 0, 0, &filterError{status: http.StatusNotFound, message: "Tiles are only available as " + ext}
+	}
+	z, errZ := strconv.Atoi(c.Param("z"))
+	x, errX := strconv.Atoi(c.Param("x"))
+	y, errY := strconv.Atoi(yStr)
+	if errZ != nil || errX != nil || errY != nil || !geo.ValidTile(z, x, y) {
+		return 0, 0, 0, badRequest("Invalid tile %s/%s/%s", c.Param("z"), c.Param("x"), yStr)
+	}
+	return z, x, y, nil
+}
+
+// parseTileRequest reads the tile address and fields parameter.
+func parseTileRequest(c *gin.Context) (db.TileRequest, error) {
+	t := db.TileRequest{Type: c.Param("type")}
+	var err error
+	if t.Z, t.X, t.Y, err = parseTileAddress(c, ".mvt"); err != nil {
+		return t, err
+	}
+
+	if v := c.Query("fields"); v != "" {
+		t.Fields = []string{}
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if !db.IsTileField(f) {
+				return t, badRequest("Invalid fields: %q is not a tile attribute", f)
+			}
+			t.Fields = append(t.Fields, f)
+		}
+	}
+	return t, nil
+}
+
+// GetDatasetTile renders one vector tile of a dataset type.
+func GetDatasetTile(c *gin.Context) {
+	req, err := parseTileRequest(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+
+	tile, err := db.Datasets.Tile(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("❌ Failed to render tile %s/%d/%d/%d: %v", req.Type, req.Z, req.X, req.Y, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render tile"})
+		return
+	}
+	if len(tile) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Data(http.StatusOK, mimeMVT, tile)
+}
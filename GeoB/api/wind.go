@@ -0,0 +1,274 @@
+// wind.go
+//
+// Wind observation endpoints for GeoGO
+// Compliance Level: High
+//
+// - Routes: /wind/stations, /wind/stations/:location/series,
+//   /wind/stations/:location/rose
+// - series resamples a location's observations into fixed intervals: mean
+//   and max speed, max gust and the vector-averaged direction (wind package)
+// - rose bins the observations by direction sector and speed for a period
+// - Locations are the location_description of the source export
+//
+// Parameters:
+//   - from, to: Period as RFC 3339 or YYYY-MM-DD (UTC); to is exclusive
+//   - interval: series interval, a Go duration or days (1h default, 15m, 1d);
+//     at most maxWindIntervalDays days; at most maxSeriesIntervals intervals
+//     are returned
+//   - sectors: rose sectors, 4 | 8 | 16 (default 16)
+//   - speed_bins: ascending rose speed edges in m/s; speeds below the first
+//     are calm (default 0.5,2,4,6,8,10)
+//
+// NOTE: Empty series intervals are left out rather than returned as zeros
+
+package api
+
+import (
+	"GeoGO/db"
+	"GeoGO/models"
+	"GeoGO/wind"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Series and rose bounds.
+const (
+	defaultWindInterval = "1h"
+	minWindInterval     = time.Minute
+	maxWindIntervalDays = 3660 // bounds "Nd" before it is multiplied into a Duration
+	maxSeriesIntervals  = 10000
+	defaultRoseSectors  = 16
+	defaultSpeedBins    = "0.5,2,4,6,8,10"
+)
+
+// parseInterval reads a Go duration or a whole number of days ("1d").
+func parseInterval(v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v)
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		var n int
+		if n, err = strconv.Atoi(days); err == nil && (n <= 0 || n > maxWindIntervalDays) {
+			return 0, badRequest("Invalid interval %q: days must be between 1 and %d", v, maxWindIntervalDays)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	}
+	if err != nil || d < minWindInterval {
+		return 0, badRequest("Invalid interval %q: expected a duration of at least 1m (e.g. 15m, 1h, 1d)", v)
+	}
+	return d, nil
+}
+
+// parseSpeedBins reads ascending, non-negative speed bin edges.
+func parseSpeedBins(v string) ([]float64, error) {
+	var edges []float64
+	for _, part := range strings.Split(v, ",") {
+		e, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || e < 0 || (len(edges) > 0 && e <= edges[len(edges)-1]) {
+			return nil, badRequest("Invalid speed_bins %q: expected ascending speeds in m/s", v)
+		}
+		edges = append(edges, e)
+	}
+	return edges, nil
+}
+
+// windStation loads the :location station, writing 404 when it is unknown.
+func windStation(c *gin.Context) (*db.WindStation, bool) {
+	location := c.Param("location")
+	station, err := db.Wind.Station(c.Request.Context(), location)
+	if err != nil {
+		log.Printf("❌ Failed to fetch wind station %s: %v", location, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wind station"})
+		return nil, false
+	}
+	if station == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown wind station " + location})
+		return nil, false
+	}
+	return station, true
+}
+
+// windObservations loads the observations of station in [from, to).
+func windObservations(c *gin.Context, station *db.WindStation, from, to *time.Time) ([]models.WindData, bool) {
+	obs, err := db.Wind.Observations(c.Request.Context(), station.Location, from, to)
+	if err != nil {
+		log.Printf("❌ Failed to fetch wind observations for %s: %v", station.Location, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wind observations"})
+		return nil, false
+	}
+	return obs, true
+}
+
+// windResponse starts a response with the station and period.
+func windResponse(station *db.WindStation, from, to *time.Time, observations int) gin.H {
+	return gin.H{
+		"location":     station.Location,
+		"lat":          station.Lat,
+		"lon":          station.Lon,
+		"from":         from,
+		"to":           to,
+		"observations": observations,
+	}
+}
+
+// GetWindStations lists the wind locations with their observation span.
+func GetWindStations(c *gin.Context) {
+	stations, err := db.Wind.Stations(c.Request.Context())
+	if err != nil {
+		log.Printf("❌ Failed to fetch wind stations: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch wind stations"})
+		return
+	}
+	out := make([]gin.H, len(stations))
+	for i, s := range stations {
+		out[i] = gin.H{
+			"location":          s.Location,
+			"lat":               s.Lat,
+			"lon":               s.Lon,
+			"observations":      s.Count,
+			"first_observation": s.First,
+			"last_observation":  s.Last,
+		}
+	}
+	log.Printf("✅ Returning %d wind stations", len(out))
+	c.JSON(http.StatusOK, out)
+}
+
+// windInterval is one resampled interval in a series response.
+type windInterval struct {
+	Start     time.Time `json:"start"`
+	Count     int       `json:"count"`
+	MeanSpeed float64   `json:"mean_speed"`
+	MaxSpeed  float64   `json:"max_speed"`
+	MaxGust   *float64  `json:"max_gust"`
+	Direction *float64  `json:"direction"`
+	Cardinal  string    `json:"cardinal,omitempty"`
+}
+
+// GetWindSeries resamples a location's observations into fixed intervals.
+func GetWindSeries(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	intervalParam := c.DefaultQuery("interval", defaultWindInterval)
+	interval, err := parseInterval(intervalParam)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	station, ok := windStation(c)
+	if !ok {
+		return
+	}
+	obs, ok := windObservations(c, station, from, to)
+	if !ok {
+		return
+	}
+
+	intervals := wind.Resample(obs, interval)
+	if len(intervals) > maxSeriesIntervals {
+		respondFilterError(c, badRequest("interval %s gives %d intervals (at most %d); use a longer interval or a shorter from/to period",
+			intervalParam, len(intervals), maxSeriesIntervals))
+		return
+	}
+	out := make([]windInterval, len(intervals))
+	for i, in := range intervals {
+		out[i] = windInterval{
+			Start:     in.Start,
+			Count:     in.Count,
+			MeanSpeed: roundTo(in.MeanSpeed, 2),
+			MaxSpeed:  in.MaxSpeed,
+			MaxGust:   in.MaxGust,
+		}
+		if in.Direction != nil {
+			// A mean just under 360 rounds up to it; report it as 0
+			d := math.Mod(roundTo(*in.Direction, 1), 360)
+			out[i].Direction = &d
+			out[i].Cardinal = wind.Cardinal(d)
+		}
+	}
+
+	resp := windResponse(station, from, to, len(obs))
+	resp["interval"] = intervalParam
+	resp["interval_s"] = interval.Seconds()
+	resp["intervals"] = out
+	log.Printf("✅ Resampled %d wind observations for %s into %d intervals", len(obs), station.Location, len(out))
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetWindRose bins a location's observations by direction and speed.
+func GetWindRose(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	sectors, err := queryInt(c, "sectors", defaultRoseSectors)
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	if sectors != 4 && sectors != 8 && sectors != 16 {
+		respondFilterError(c, badRequest("sectors must be 4, 8 or 16"))
+		return
+	}
+	edges, err := parseSpeedBins(c.DefaultQuery("speed_bins", defaultSpeedBins))
+	if err != nil {
+		respondFilterError(c, err)
+		return
+	}
+	station, ok := windStation(c)
+	if !ok {
+		return
+	}
+	obs, ok := windObservations(c, station, from, to)
+	if !ok {
+		return
+	}
+
+	rose := wind.NewRose(obs, sectors, edges)
+	percent := func(n int) float64 {
+		if rose.Total == 0 {
+			return 0
+		}
+		return roundTo(100*float64(n)/float64(rose.Total), 2)
+	}
+	bins := make([]gin.H, len(edges))
+	for i, e := range edges {
+		bins[i] = gin.H{"min": e, "max": nil}
+		if i+1 < len(edges) {
+			bins[i]["max"] = edges[i+1]
+		}
+	}
+	out := make([]gin.H, len(rose.Sectors))
+	for i, s := range rose.Sectors {
+		frequencies := make([]float64, len(s.Bins))
+		for j, n := range s.Bins {
+			frequencies[j] = percent(n)
+		}
+		out[i] = gin.H{
+			"direction":   s.Direction,
+			"cardinal":    wind.Cardinal(s.Direction),
+			"count":       s.Count,
+			"percent":     percent(s.Count),
+			"counts":      s.Bins,
+			"frequencies": frequencies,
+		}
+	}
+
+	resp := windResponse(station, from, to, len(obs))
+	resp["total"] = rose.Total
+	resp["calm"] = rose.Calm
+	resp["calm_percent"] = percent(rose.Calm)
+	resp["missing_direction"] = rose.Missing
+	resp["speed_bins"] = bins
+	resp["sectors"] = out
+	log.Printf("✅ Wind rose for %s from %d observations", station.Location, len(obs))
+	c.JSON(http.StatusOK, resp)
+}
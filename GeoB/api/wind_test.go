@@ -0,0 +1,33 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		v    string
+		want time.Duration
+		ok   bool
+	}{
+		{"15m", 15 * time.Minute, true},
+		{"1h", time.Hour, true},
+		{"1d", 24 * time.Hour, true},
+		{"3660d", 3660 * 24 * time.Hour, true},
+		{"30s", 0, false},
+		{"0d", 0, false},
+		{"-1d", 0, false},
+		{"3661d", 0, false},
+		// Would overflow time.Duration if multiplied
+		{"99999999999d", 0, false},
+		{"1.5d", 0, false},
+		{"d", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseInterval(tt.v)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseInterval(%q) = %v, %v; want %v (ok %v)", tt.v, got, err, tt.want, tt.ok)
+		}
+	}
+}
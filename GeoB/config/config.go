@@ -0,0 +1,300 @@
+// config.go
+//
+// Runtime configuration for GeoGO
+// Loads settings from a YAML or TOML file and applies environment overrides.
+// Compliance Level: Critical
+//
+// - Handles sensitive database and Redis credentials
+// - Validates all settings at startup so misconfiguration fails fast
+// - Same binary runs in dev, staging and prod without recompiling
+//
+// Precedence (highest wins):
+//  1. Environment variables (GEOGO_*)
+//  2. Config file (-config flag or GEOGO_CONFIG)
+//  3. Built-in defaults (local development)
+//
+// Environment Variables:
+//   GEOGO_SERVER_ADDR, GEOGO_CORS_ORIGINS (comma separated),
+//   GEOGO_DB_DRIVER, GEOGO_DB_PATH, GEOGO_DB_HOST, GEOGO_DB_PORT, GEOGO_DB_USER,
+//   GEOGO_DB_PASSWORD, GEOGO_DB_NAME, GEOGO_DB_SSLMODE, GEOGO_DB_MAX_OPEN_CONNS, GEOGO_DB_MAX_IDLE_CONNS,
+//   GEOGO_REDIS_ADDR, GEOGO_REDIS_PASSWORD, GEOGO_REDIS_DB,
+//   GEOGO_GEOCODER, GEOGO_GAZETTEER_PATH
+//
+// TODO: Support secret files (e.g. GEOGO_DB_PASSWORD_FILE) for container secrets
+// TODO: Consider hot reloading for non-connection settings
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the root configuration object.
+type Config struct {
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Redis    RedisConfig    `yaml:"redis" toml:"redis"`
+	Geocoder GeocoderConfig `yaml:"geocoder" toml:"geocoder"`
+}
+
+// ServerConfig controls the HTTP listener and CORS policy.
+type ServerConfig struct {
+	Addr         string   `yaml:"addr" toml:"addr"`
+	AllowOrigins []string `yaml:"allow_origins" toml:"allow_origins"`
+}
+
+// DatabaseConfig holds the storage backend settings.
+// Driver "postgres" (PostGIS) uses the host/port/user fields; driver "sqlite"
+// uses Path and needs no server.
+type DatabaseConfig struct {
+	Driver       string `yaml:"driver" toml:"driver"`
+	Path         string `yaml:"path" toml:"path"`
+	Host         string `yaml:"host" toml:"host"`
+	Port         int    `yaml:"port" toml:"port"`
+	User         string `yaml:"user" toml:"user"`
+	Password     string `yaml:"password" toml:"password"`
+	Name         string `yaml:"name" toml:"name"`
+	SSLMode      string `yaml:"sslmode" toml:"sslmode"`
+	MaxOpenConns int    `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns int    `yaml:"max_idle_conns" toml:"max_idle_conns"`
+}
+
+// RedisConfig holds the geocoding cache connection settings.
+type RedisConfig struct {
+	Addr     string `yaml:"addr" toml:"addr"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+}
+
+// GeocoderConfig selects the geocoding provider.
+type GeocoderConfig struct {
+	Provider      string `yaml:"provider" toml:"provider"`
+	GazetteerPath string `yaml:"gazetteer_path" toml:"gazetteer_path"`
+}
+
+// Default returns the local development configuration.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:         ":8080",
+			AllowOrigins: []string{"http://localhost:3000", "http://127.0.0.1:3000"},
+		},
+		Database: DatabaseConfig{
+			Driver:       "postgres",
+			Path:         "data/geogo.db",
+			Host:         "localhost",
+			Port:         5432,
+			User:         "postgres",
+			Password:     "postgres",
+			Name:         "GeoGo",
+			SSLMode:      "disable",
+			MaxOpenConns: 25,
+			MaxIdleConns: 5,
+		},
+		Redis: RedisConfig{
+			Addr: "localhost:6379",
+		},
+		Geocoder: GeocoderConfig{
+			Provider: "nominatim",
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the optional file at path and
+// the environment, then validates the result.
+//
+// Parameters:
+//   - path: YAML (.yaml/.yml) or TOML (.toml) file; empty falls back to GEOGO_CONFIG
+//
+// Returns:
+//   - Config: Validated configuration
+//   - error: File, parse, override or validation errors
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		path = os.Getenv("GEOGO_CONFIG")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// loadFile decodes a config file over the current values, so keys missing
+// from the file keep their defaults.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("unsupported config format %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides settings from GEOGO_* environment variables.
+func (c *Config) applyEnv() error {
+	var errs []error
+	str := func(key string, dst *string) {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = v
+		}
+	}
+	num := func(key string, dst *int) {
+		if v, ok := os.LookupEnv(key); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not an integer", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+
+	str("GEOGO_SERVER_ADDR", &c.Server.Addr)
+	if v, ok := os.LookupEnv("GEOGO_CORS_ORIGINS"); ok {
+		c.Server.AllowOrigins = splitList(v)
+	}
+
+	str("GEOGO_DB_DRIVER", &c.Database.Driver)
+	str("GEOGO_DB_PATH", &c.Database.Path)
+	str("GEOGO_DB_HOST", &c.Database.Host)
+	num("GEOGO_DB_PORT", &c.Database.Port)
+	str("GEOGO_DB_USER", &c.Database.User)
+	str("GEOGO_DB_PASSWORD", &c.Database.Password)
+	str("GEOGO_DB_NAME", &c.Database.Name)
+	str("GEOGO_DB_SSLMODE", &c.Database.SSLMode)
+	num("GEOGO_DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	num("GEOGO_DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+
+	str("GEOGO_REDIS_ADDR", &c.Redis.Addr)
+	str("GEOGO_REDIS_PASSWORD", &c.Redis.Password)
+	num("GEOGO_REDIS_DB", &c.Redis.DB)
+
+	str("GEOGO_GEOCODER", &c.Geocoder.Provider)
+	str("GEOGO_GAZETTEER_PATH", &c.Geocoder.GazetteerPath)
+
+	return errors.Join(errs...)
+}
+
+// Validate checks the configuration for missing or out-of-range settings.
+// All problems are reported together rather than one per restart.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if len(c.Server.AllowOrigins) == 0 {
+		errs = append(errs, errors.New("server.allow_origins must list at least one origin (use \"*\" to allow all)"))
+	}
+
+	switch c.Database.Driver {
+	case "postgres":
+		errs = append(errs, c.Database.validatePostgres()...)
+	case "sqlite":
+		if c.Database.Path == "" {
+			errs = append(errs, errors.New("database.path is required for the sqlite driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("database.driver %q must be postgres or sqlite", c.Database.Driver))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database connection pool sizes must not be negative"))
+	}
+
+	if c.Redis.Addr == "" {
+		errs = append(errs, errors.New("redis.addr is required"))
+	}
+	if c.Redis.DB < 0 {
+		errs = append(errs, fmt.Errorf("redis.db %d must not be negative", c.Redis.DB))
+	}
+
+	switch c.Geocoder.Provider {
+	case "nominatim":
+	case "gazetteer":
+		if c.Geocoder.GazetteerPath == "" {
+			errs = append(errs, errors.New("geocoder.gazetteer_path is required for the gazetteer provider"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("geocoder.provider %q must be nominatim or gazetteer", c.Geocoder.Provider))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (d DatabaseConfig) validatePostgres() []error {
+	var errs []error
+	if d.Host == "" {
+		errs = append(errs, errors.New("database.host is required"))
+	}
+	if d.Port < 1 || d.Port > 65535 {
+		errs = append(errs, fmt.Errorf("database.port %d is out of range", d.Port))
+	}
+	if d.User == "" {
+		errs = append(errs, errors.New("database.user is required"))
+	}
+	if d.Name == "" {
+		errs = append(errs, errors.New("database.name is required"))
+	}
+	switch d.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("database.sslmode %q is not a valid libpq sslmode", d.SSLMode))
+	}
+	return errs
+}
+
+// DSN returns the lib/pq connection string.
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(d.Host), d.Port, quoteDSN(d.User), quoteDSN(d.Password), quoteDSN(d.Name), d.SSLMode)
+}
+
+// quoteDSN quotes a libpq keyword value when it contains spaces, quotes or
+// backslashes, or is empty.
+func quoteDSN(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+// climate.go
+//
+// Climate station projections stored in the datasets table
+// Compliance Level: High
+//
+// - Each climate row is one station, variable, RCP, model and climatology
+//   period; the scenario fields and seasonal values live in metadata
+//   (see ingest/sources.go mapClimate and utils/process_datasets.py)
+// - Rows are selected by metadata keys (station_id, climate_type, rcp, ...);
+//   the JSON accessor (sqlDialect) is the only difference between the backends
+// - StationValues averages the models of a scenario per station (the
+//   samples for /climate/interpolate)
+//
+// NOTE: PostGIS serves the station lookup from the idx_datasets_station
+// expression index (utils/SQL/create_unified_schema.sql)
+
+package db
+
+import (
+	"GeoGO/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type climateRepository struct {
+	db      *sqlx.DB
+	dialect sqlDialect
+}
+
+// NewClimateRepository returns the climate repository for either backend.
+func NewClimateRepository(conn *sqlx.DB) ClimateRepository {
+	return &climateRepository{db: conn, dialect: driverDialects[conn.DriverName()]}
+}
+
+// key renders the text of a metadata key.
+func (r *climateRepository) key(name string) string {
+	return fmt.Sprintf(r.dialect.text, name)
+}
+
+// climateMetadata is the metadata of one climate row.
+type climateMetadata struct {
+	StationName string   `json:"station_name"`
+	StationID   string   `json:"station_id"`
+	Model       string   `json:"model"`
+	RCP         string   `json:"rcp"`
+	Ensemble    string   `json:"ensemble"`
+	Climatology string   `json:"climatology"`
+	ClimateType string   `json:"climate_type"`
+	Unit        string   `json:"unit"`
+	Annual      *float64 `json:"annual"`
+	DJF         *float64 `json:"djf"`
+	MAM         *float64 `json:"mam"`
+	JJA         *float64 `json:"jja"`
+	SON         *float64 `json:"son"`
+}
+
+func (r *climateRepository) Projections(ctx context.Context, stationID string) ([]models.ClimateData, error) {
+	var rows []struct {
+		ID       int             `db:"id"`
+		Lat      float64         `db:"lat"`
+		Lon      float64         `db:"lon"`
+		Value    sql.NullFloat64 `db:"value"`
+		Unit     sql.NullString  `db:"unit"`
+		Metadata sql.NullString  `db:"metadata"`
+	}
+	w := &whereBuilder{}
+	// A literal type lets PostGIS match the partial station index
+	w.add("dataset_type = 'climate'")
+	w.add(r.key("station_id")+" = ?", stationID)
+	query := "SELECT id, lat, lon, value, unit, metadata FROM datasets" + w.clause() + " ORDER BY id"
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+
+	projections := make([]models.ClimateData, 0, len(rows))
+	for _, row := range rows {
+		var m climateMetadata
+		if err := json.Unmarshal([]byte(row.Metadata.String), &m); err != nil {
+			return nil, fmt.Errorf("climate row %d metadata: %w", row.ID, err)
+		}
+		p := models.ClimateData{
+			StationName: m.StationName,
+			StationID:   m.StationID,
+			Lat:         row.Lat,
+			Lon:         row.Lon,
+			Annual:      m.Annual,
+			DJF:         m.DJF,
+			MAM:         m.MAM,
+			JJA:         m.JJA,
+			SON:         m.SON,
+			Model:       m.Model,
+			RCP:         m.RCP,
+			Ensemble:    m.Ensemble,
+			Climatology: m.Climatology,
+			Variable:    models.ClimateVariables[m.ClimateType],
+			Unit:        m.Unit,
+		}
+		if p.Annual == nil && row.Value.Valid {
+			p.Annual = &row.Value.Float64
+		}
+		if p.Variable == "" {
+			p.Variable = m.ClimateType
+		}
+		if p.Unit == "" {
+			p.Unit = row.Unit.String
+		}
+		projections = append(projections, p)
+	}
+	slices.SortStableFunc(projections, func(a, b models.ClimateData) int {
+		for _, c := range [][2]string{{a.Variable, b.Variable}, {a.RCP, b.RCP}, {a.Model, b.Model}, {a.Climatology, b.Climatology}} {
+			if d := strings.Compare(c[0], c[1]); d != 0 {
+				return d
+			}
+		}
+		return 0
+	})
+	return projections, nil
+}
+
+// ClimateSelection picks one value per station: a variable and period of
+// one scenario.
+type ClimateSelection struct {
+	ClimateType string // metadata climate_type, e.g. max_temperature
+	Period      string // metadata period key, as DatasetFilter.Period
+	RCP         string
+	Climatology string
+	Model       string // empty averages every model (ensemble mean)
+}
+
+// StationValue is the selected value of one climate station.
+type StationValue struct {
+	StationID   string  `db:"station_id"`
+	StationName string  `db:"station_name"`
+	Lat         float64 `db:"lat"`
+	Lon         float64 `db:"lon"`
+	Value       float64 `db:"value"`
+	Unit        string  `db:"unit"`
+	Models      int     `db:"models"` // models averaged into Value
+}
+
+func (r *climateRepository) StationValues(ctx context.Context, s ClimateSelection) ([]StationValue, error) {
+	value := fmt.Sprintf(r.dialect.number, s.Period)
+	w := &whereBuilder{}
+	w.add("dataset_type = 'climate'")
+	w.add(r.key("climate_type")+" = ?", s.ClimateType)
+	w.add(r.key("rcp")+" = ?", s.RCP)
+	w.add(r.key("climatology")+" = ?", s.Climatology)
+	if s.Model != "" {
+		w.add(r.key("model")+" = ?", s.Model)
+	}
+	w.add(value + " IS NOT NULL")
+	station := r.key("station_id")
+	query := "SELECT " + station + " AS station_id, MIN(" + r.key("station_name") + ") AS station_name," +
+		" AVG(lat) AS lat, AVG(lon) AS lon, AVG(" + value + ") AS value, COALESCE(MIN(unit), '') AS unit," +
+		" COUNT(DISTINCT " + r.key("model") + ") AS models FROM datasets" + w.clause() +
+		" GROUP BY " + station + " ORDER BY " + station
+
+	stations := make([]StationValue, 0)
+	if err := r.db.SelectContext(ctx, &stations, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
@@ -0,0 +1,92 @@
+// cursor.go
+//
+// Keyset pagination cursors for GeoGO listings
+// Compliance Level: High
+//
+// - A cursor encodes the sort key and id of the last row of a page; the next page
+//   starts strictly after it, so concurrent inserts never skip or repeat rows
+// - Tokens are opaque base64url strings tagged with the table they belong to;
+//   clients must not build or parse them
+// - Sort orders are total (ties broken by id) so keysets are unambiguous
+//
+// Sort Keys:
+// - locations: (COALESCE(year, 0), id) descending
+// - datasets:  id descending
+
+package db
+
+import (
+	"GeoGO/models"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for tokens that were not issued by this API.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MeteoriteKey is the position of a row in the meteorite sort order.
+type MeteoriteKey struct {
+	Year int
+	ID   int
+}
+
+// DatasetKey is the position of a row in the dataset sort order.
+type DatasetKey struct {
+	ID int
+}
+
+// MeteoriteCursor returns the token for the page following m.
+func MeteoriteCursor(m models.Meteorite) string {
+	return encodeCursor("m", m.Year, m.ID)
+}
+
+// DatasetCursor returns the token for the page following d.
+func DatasetCursor(d models.Dataset) string {
+	return encodeCursor("d", d.ID)
+}
+
+// ParseMeteoriteCursor decodes a token issued by MeteoriteCursor.
+func ParseMeteoriteCursor(token string) (*MeteoriteKey, error) {
+	v, err := decodeCursor(token, "m", 2)
+	if err != nil {
+		return nil, err
+	}
+	return &MeteoriteKey{Year: v[0], ID: v[1]}, nil
+}
+
+// ParseDatasetCursor decodes a token issued by DatasetCursor.
+func ParseDatasetCursor(token string) (*DatasetKey, error) {
+	v, err := decodeCursor(token, "d", 1)
+	if err != nil {
+		return nil, err
+	}
+	return &DatasetKey{ID: v[0]}, nil
+}
+
+func encodeCursor(kind string, keys ...int) string {
+	parts := []string{kind}
+	for _, k := range keys {
+		parts = append(parts, strconv.Itoa(k))
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(parts, ":")))
+}
+
+func decodeCursor(token, kind string, n int) ([]int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != n+1 || parts[0] != kind {
+		return nil, ErrInvalidCursor
+	}
+	keys := make([]int, n)
+	for i, p := range parts[1:] {
+		if keys[i], err = strconv.Atoi(p); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return keys, nil
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"GeoGO/config"
 	"log"
 
 	"github.com/jmoiron/sqlx"
@@ -9,24 +10,43 @@ import (
 
 // Database connection configuration and initialization
 // Compliance Level: Critical
-// - Handles sensitive database credentials
+// - Handles sensitive database credentials (supplied by the config package)
+// - Selects the storage backend (PostGIS or embedded SQLite) and its repositories
 // - Manages connection pooling and timeouts
 // - Implements error handling for connection failures
 // - Uses secure connection parameters (sslmode=disable only for local development)
 var DB *sqlx.DB
 
-func InitDB() {
+func InitDB(cfg config.DatabaseConfig) {
 	var err error
-	dsn := "host=localhost port=5432 user=postgres password=postgres dbname=GeoGo sslmode=disable"
-	DB, err = sqlx.Open("postgres", dsn)
-	if err != nil {
-		log.Fatal("❌ Database connection failed:", err)
+	switch cfg.Driver {
+	case "sqlite":
+		DB, err = OpenSQLite(cfg.Path)
+		if err != nil {
+			log.Fatal("❌ SQLite database failed to open:", err)
+		}
+		Meteorites, Datasets = NewSQLiteRepositories(DB)
+	default:
+		DB, err = sqlx.Open("postgres", cfg.DSN())
+		if err != nil {
+			log.Fatal("❌ Database connection failed:", err)
+		}
+		Meteorites, Datasets = NewPostGISRepositories(DB)
 	}
+	Pipes = NewPipeRepository(DB)
+	Climate = NewClimateRepository(DB)
+	Wind = NewWindRepository(DB)
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
 
 	err = DB.Ping()
 	if err != nil {
 		log.Fatal("❌ Database unreachable:", err)
 	}
 
-	log.Println("✅ Connected to PostgreSQL + PostGIS")
+	if cfg.Driver == "sqlite" {
+		log.Printf("✅ Opened SQLite database %s", cfg.Path)
+		return
+	}
+	log.Printf("✅ Connected to PostgreSQL + PostGIS (%s:%d/%s)", cfg.Host, cfg.Port, cfg.Name)
 }
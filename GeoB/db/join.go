@@ -0,0 +1,129 @@
+// join.go
+//
+// Spatial joins between dataset types, shared by the storage backends
+// Compliance Level: Moderate
+//
+// - within: the left row lies inside a right polygon (ST_CoveredBy); right
+//   rows that are not polygons never match
+// - dwithin: the nearest parts of the left and right rows are at most
+//   Distance metres apart (ST_DWithin on geography)
+// - The left side takes every dataset filter; the right side is a whole type
+// - A row never matches itself when a type is joined with itself
+// - JoinRecords pages the matched left rows and pairs only that page; a page
+//   holding more than MaxJoinPairs pairs fails with ErrJoinTooLarge
+// - JoinCount counts the matches of every right row without keeping pairs
+//   and pages the right rows, busiest first
+//
+// NOTE: Totals still test every left row; only the returned page is kept
+
+package db
+
+import (
+	"GeoGO/models"
+	"cmp"
+	"errors"
+	"slices"
+)
+
+// MaxJoinPairs caps the pairs of one JoinRecords page.
+const MaxJoinPairs = 10000
+
+// ErrJoinTooLarge is returned when a JoinRecords page exceeds MaxJoinPairs.
+var ErrJoinTooLarge = errors.New("join page has too many pairs")
+
+// JoinPredicate is the spatial relation tested between left and right rows.
+type JoinPredicate string
+
+const (
+	JoinWithin  JoinPredicate = "within"
+	JoinDWithin JoinPredicate = "dwithin"
+)
+
+// JoinMode selects what a join returns.
+type JoinMode string
+
+const (
+	JoinRecords JoinMode = "records" // matched left rows with their pairs
+	JoinCount   JoinMode = "count"   // right rows with their match counts
+)
+
+// JoinRequest describes a spatial join between two dataset types.
+type JoinRequest struct {
+	// Left selects the left rows; After is ignored. Limit and Offset page
+	// the matched left rows, or the right rows for JoinCount.
+	Left      DatasetFilter
+	Right     string // right dataset type
+	Predicate JoinPredicate
+	Distance  float64 // metres, for JoinDWithin
+	Mode      JoinMode
+}
+
+// JoinPair links a left row to one matching right row.
+type JoinPair struct {
+	LeftID  int `db:"left_id"`
+	RightID int `db:"right_id"`
+	// Distance is the distance in metres between the rows (0 for JoinWithin)
+	Distance float64 `db:"distance_m"`
+}
+
+// JoinResult holds one page of a join.
+type JoinResult struct {
+	// JoinRecords: the page of left rows with at least one match, newest
+	// (highest id) first
+	Left []models.Dataset
+	// JoinRecords: the right rows in Pairs, by id. JoinCount: the page of
+	// right rows, busiest first (ties by id)
+	Right []models.Dataset
+	Pairs []JoinPair  // JoinRecords: by left row (as in Left), then distance, then right id
+	Count map[int]int // JoinCount: matching left rows by right id; absent means 0
+
+	Total     int // matched left rows (JoinRecords) or right rows (JoinCount) before paging
+	PairCount int // pairs in the whole join
+}
+
+// newJoinResult returns an empty result.
+func newJoinResult() *JoinResult {
+	return &JoinResult{Left: []models.Dataset{}, Right: []models.Dataset{}, Pairs: []JoinPair{}, Count: map[int]int{}}
+}
+
+// countPage orders right row ids busiest first (ties by id) and applies
+// offset/limit.
+func countPage(ids []int, count map[int]int, limit, offset int) []int {
+	slices.SortFunc(ids, func(a, b int) int {
+		if c := cmp.Compare(count[b], count[a]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	return paginate(ids, limit, offset)
+}
+
+// orderByIDs reorders rows to follow ids; rows missing from ids are dropped.
+func orderByIDs(rows []models.Dataset, ids []int) []models.Dataset {
+	byID := make(map[int]models.Dataset, len(rows))
+	for _, d := range rows {
+		byID[d.ID] = d
+	}
+	out := make([]models.Dataset, 0, len(ids))
+	for _, id := range ids {
+		if d, ok := byID[id]; ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// sortPairs orders pairs as documented on JoinResult.
+func sortPairs(pairs []JoinPair) {
+	slices.SortFunc(pairs, func(a, b JoinPair) int {
+		switch {
+		case a.LeftID != b.LeftID:
+			return b.LeftID - a.LeftID
+		case a.Distance < b.Distance:
+			return -1
+		case a.Distance > b.Distance:
+			return 1
+		}
+		return a.RightID - b.RightID
+	})
+}
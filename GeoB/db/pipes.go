@@ -0,0 +1,62 @@
+// pipes.go
+//
+// Drainage pipe network storage
+// Compliance Level: High
+//
+// - One row per pipe from the INF_DRN_PIPES asset register, loaded by `geogo import`
+// - from_pit/to_pit give the flow direction; the network package builds the graph
+// - The queries are plain SQL shared by PostGIS and SQLite
+//
+// Schema Notes:
+// - PostGIS adds a nullable geom LINESTRING column (utils/SQL/create_unified_schema.sql);
+//   the register export carries no coordinates, so it stays empty until pit
+//   locations are loaded
+// - Zero measurements in the register mean "not surveyed" and are stored as NULL
+
+package db
+
+import (
+	"GeoGO/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// pipeColumns is the column order used for reads and bulk loads (id excluded).
+var pipeColumns = []string{
+	"compkey", "pipe_unit_id", "from_pit", "to_pit", "unit_type", "catchment", "sub_catchment",
+	"material", "shape", "parallel_lines", "diameter_mm", "height_mm", "length_m",
+	"up_invert", "down_invert", "grade", "average_depth",
+}
+
+const pipeSelect = `SELECT id, compkey, COALESCE(pipe_unit_id, '') AS pipe_unit_id, from_pit, to_pit,
+	COALESCE(unit_type, '') AS unit_type, COALESCE(catchment, '') AS catchment,
+	COALESCE(sub_catchment, '') AS sub_catchment, COALESCE(material, '') AS material,
+	COALESCE(shape, '') AS shape, COALESCE(parallel_lines, 1) AS parallel_lines,
+	diameter_mm, height_mm, length_m, up_invert, down_invert, grade, average_depth
+	FROM pipes`
+
+type pipeRepository struct {
+	db *sqlx.DB
+}
+
+// NewPipeRepository returns the pipe repository for either backend.
+func NewPipeRepository(conn *sqlx.DB) PipeRepository {
+	return &pipeRepository{db: conn}
+}
+
+func (r *pipeRepository) All(ctx context.Context) ([]models.Pipe, error) {
+	var pipes []models.Pipe
+	err := r.db.SelectContext(ctx, &pipes, pipeSelect+" ORDER BY id")
+	return pipes, err
+}
+
+// pipeValues renders p in pipeColumns order, mapping empty strings to NULL.
+func pipeValues(p models.Pipe) []interface{} {
+	return []interface{}{
+		p.CompKey, nullString(p.UnitID), p.FromPit, p.ToPit, nullString(p.UnitType),
+		nullString(p.Catchment), nullString(p.SubCatchment), nullString(p.Material), nullString(p.Shape),
+		p.ParallelLines, nullFloat(p.DiameterMM), nullFloat(p.HeightMM), nullFloat(p.LengthM),
+		nullFloat(p.UpInvert), nullFloat(p.DownInvert), nullFloat(p.Grade), nullFloat(p.AverageDepth),
+	}
+}
@@ -0,0 +1,486 @@
+// postgis.go
+//
+// PostgreSQL + PostGIS storage backend for GeoGO
+// Production backend; spatial filtering happens in the database.
+// Compliance Level: High
+//
+// - geom holds any geometry type (points, lines, polygons, Multi*); non-point
+//   rows are returned with their GeoJSON in the geometry column
+// - Uses ST_DWithin on geography for metre-accurate radius queries (distance
+//   to the nearest part of a shape)
+// - Viewport and polygon filters use ST_Intersects on geometry so the planner
+//   can use the index directly
+// - Point-in-polygon lookups use ST_Covers on area rows only
+// - Spatial joins run as one self-join of datasets (ST_CoveredBy, ST_DWithin)
+// - Nearest-neighbour queries use the <-> KNN operator to bound the search
+//   radius, then rank exactly by geodesic distance within it
+// - Vector tiles are encoded in the database with ST_AsMVT (PostGIS 3.0+)
+// - Relies on the GIST index on geom (see utils/SQL/create_unified_schema.sql)
+// - Uses parameterized queries to prevent SQL injection
+//
+// TODO: Implement query retry logic for transient failures
+
+package db
+
+import (
+	"GeoGO/geo"
+	"GeoGO/models"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const postgisMeteoriteColumns = `id, name, recclass, mass, year, ST_X(geom) AS lon, ST_Y(geom) AS lat`
+
+const postgisDatasetColumns = `id, dataset_type, name, lat, lon,
+	CASE WHEN GeometryType(geom) = 'POINT' THEN NULL ELSE ST_AsGeoJSON(geom) END AS geometry,
+	value, unit, metadata, timestamp, recclass, mass, year, nametype, fall`
+
+// postgisDialect holds the PostgreSQL metadata and row time expressions.
+var postgisDialect = sqlDialect{
+	text:     "metadata->>'%s'",
+	number:   "(metadata->>'%s')::float8",
+	observed: "COALESCE(timestamp, CASE WHEN year > 0 THEN make_timestamp(year, 1, 1, 0, 0, 0) END)",
+}
+
+// postgisNear renders the ST_DWithin proximity condition.
+const postgisNear = "ST_DWithin(geom::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)"
+
+// postgisEnvelope renders an index-assisted bounding-box overlap condition,
+// used as a prefilter.
+const postgisEnvelope = "geom && ST_MakeEnvelope(?, ?, ?, ?, 4326)"
+
+// postgisInEnvelope renders the viewport condition; shapes must actually cross the box.
+const postgisInEnvelope = "ST_Intersects(geom, ST_MakeEnvelope(?, ?, ?, ?, 4326))"
+
+// postgisWithin renders the polygon condition; the argument is WKT.
+const postgisWithin = "ST_Intersects(geom, ST_GeomFromText(?, 4326))"
+
+// Join conditions between the left (l) and right (r) rows. dwithin first
+// matches geometry boxes expanded by the distance in degrees of longitude at
+// the right row's highest latitude (110 km per degree, a conservative figure)
+// so the GIST index narrows the candidates before the geodesic test.
+const (
+	postgisJoinWithin  = "GeometryType(r.geom) IN ('POLYGON', 'MULTIPOLYGON') AND ST_CoveredBy(l.geom, r.geom)"
+	postgisJoinDWithin = `l.geom && ST_Expand(r.geom, ? / (110000 * cos(radians(LEAST(
+		GREATEST(abs(ST_YMin(r.geom)), abs(ST_YMax(r.geom))) + ? / 110000.0, 89)))))
+		AND ST_DWithin(l.geom::geography, r.geom::geography, ?)`
+)
+
+// postgisJoinLocated is locatedCondition for the right (r) rows of a join.
+const postgisJoinLocated = "COALESCE(r.metadata->>'location_source', '') <> 'fallback'"
+
+// postgisCovers renders the point-in-polygon condition for area rows;
+// boundary points count as inside.
+const postgisCovers = "GeometryType(geom) IN ('POLYGON', 'MULTIPOLYGON') AND ST_Covers(geom, ST_SetSRID(ST_MakePoint(?, ?), 4326))"
+
+// addPostGISSpatial appends the conditions for every spatial member of s.
+func addPostGISSpatial(w *whereBuilder, s SpatialFilter) {
+	if s.Near != nil {
+		w.add(postgisNear, s.Near.Lon, s.Near.Lat, s.Near.Radius)
+	}
+	if s.BBox != nil {
+		var conds []string
+		var args []interface{}
+		for _, b := range s.BBox.Split() {
+			conds = append(conds, postgisInEnvelope)
+			args = append(args, b.MinLon, b.MinLat, b.MaxLon, b.MaxLat)
+		}
+		w.add("("+strings.Join(conds, " OR ")+")", args...)
+	}
+	if s.Within != nil {
+		w.add(postgisWithin, s.Within.WKT())
+	}
+}
+
+type postgisMeteorites struct {
+	db *sqlx.DB
+}
+
+type postgisDatasets struct {
+	db *sqlx.DB
+}
+
+// NewPostGISRepositories wraps an open PostgreSQL connection.
+func NewPostGISRepositories(conn *sqlx.DB) (MeteoriteRepository, DatasetRepository) {
+	return &postgisMeteorites{db: conn}, &postgisDatasets{db: conn}
+}
+
+func (r *postgisMeteorites) List(ctx context.Context, f MeteoriteFilter) ([]models.Meteorite, error) {
+	w := meteoriteConditions(f)
+	addPostGISSpatial(w, f.SpatialFilter)
+	query := "SELECT " + postgisMeteoriteColumns + " FROM locations" + w.clause() +
+		meteoriteOrder + w.page(f.Limit, f.Offset, "ALL")
+
+	meteorites := make([]models.Meteorite, 0)
+	if err := r.db.SelectContext(ctx, &meteorites, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	return meteorites, nil
+}
+
+func (r *postgisMeteorites) Largest(ctx context.Context, limit int) ([]models.Meteorite, error) {
+	w := &whereBuilder{}
+	query := "SELECT " + postgisMeteoriteColumns + " FROM locations ORDER BY mass DESC" + w.page(limit, 0, "ALL")
+
+	meteorites := make([]models.Meteorite, 0)
+	if err := r.db.SelectContext(ctx, &meteorites, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	return meteorites, nil
+}
+
+func (r *postgisDatasets) List(ctx context.Context, f DatasetFilter) ([]models.Dataset, error) {
+	w := datasetConditions(f, postgisDialect)
+	addPostGISSpatial(w, f.SpatialFilter)
+	query := "SELECT " + f.selectValue(postgisDatasetColumns, postgisDialect) + " FROM datasets" + w.clause() +
+		f.order(postgisDialect) + w.page(f.Limit, f.Offset, "ALL")
+
+	datasets := make([]models.Dataset, 0)
+	if err := r.db.SelectContext(ctx, &datasets, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+func (r *postgisDatasets) Nearest(ctx context.Context, f DatasetFilter, lat, lon float64, k int) ([]Neighbor, error) {
+	neighbors := make([]Neighbor, 0)
+	if k <= 0 {
+		return neighbors, nil
+	}
+	f.Near, f.After, f.Located = nil, nil, true
+
+	// Pass 1: the k nearest by planar distance (index-assisted KNN). The
+	// farthest of them bounds the true k nearest by geodesic distance.
+	w := datasetConditions(f, postgisDialect)
+	addPostGISSpatial(w, f.SpatialFilter)
+	query := `
+		SELECT COALESCE(MAX(ST_Distance(geom::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography)), -1)
+		FROM (
+			SELECT geom FROM datasets` + w.clause() + `
+			ORDER BY geom <-> ST_SetSRID(ST_MakePoint(?, ?), 4326)
+			LIMIT ?
+		) knn
+	`
+	args := append([]interface{}{lon, lat}, w.args...)
+	args = append(args, lon, lat, k)
+	var radius float64
+	if err := r.db.GetContext(ctx, &radius, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	if radius < 0 {
+		return neighbors, nil
+	}
+
+	// Pass 2: exact geodesic ranking inside that radius, prefiltered by its box
+	w = datasetConditions(f, postgisDialect)
+	addPostGISSpatial(w, f.SpatialFilter)
+	box := geo.RadiusBBox(lat, lon, radius)
+	w.add(postgisEnvelope, box.MinLon, box.MinLat, box.MaxLon, box.MaxLat)
+	w.add(postgisNear, lon, lat, radius)
+	query = "SELECT " + postgisDatasetColumns +
+		", ST_Distance(geom::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography) AS distance_m FROM datasets" +
+		w.clause() + " ORDER BY distance_m, id" + w.page(k, 0, "ALL")
+	args = append([]interface{}{lon, lat}, w.args...)
+	if err := r.db.SelectContext(ctx, &neighbors, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return neighbors, nil
+}
+
+func (r *postgisDatasets) Containing(ctx context.Context, types []string, lat, lon float64) ([]models.Dataset, error) {
+	w := &whereBuilder{}
+	addTypes(w, types)
+	w.add(postgisCovers, lon, lat)
+	query := "SELECT " + postgisDatasetColumns + " FROM datasets" + w.clause() + containingOrder
+
+	datasets := make([]models.Dataset, 0)
+	if err := r.db.SelectContext(ctx, &datasets, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// Join counts in SQL and pages before pairing: JoinRecords selects the page
+// of matched left rows (EXISTS) and pairs only those, JoinCount groups the
+// matches by right row.
+func (r *postgisDatasets) Join(ctx context.Context, j JoinRequest) (*JoinResult, error) {
+	result := newJoinResult()
+	f := j.Left
+	f.After, f.Located = nil, true
+	w := datasetConditions(f, postgisDialect)
+	addPostGISSpatial(w, f.SpatialFilter)
+
+	cond, distance := postgisJoinWithin, "0"
+	var condArgs []interface{}
+	if j.Predicate == JoinDWithin {
+		cond, distance = postgisJoinDWithin, "ST_Distance(l.geom::geography, r.geom::geography)"
+		condArgs = []interface{}{j.Distance, j.Distance, j.Distance}
+	}
+	// match relates a left row l to the right rows r it satisfies the predicate against
+	match := "r.dataset_type = ? AND r.id <> l.id AND " + postgisJoinLocated + " AND " + cond
+	matchArgs := append([]interface{}{j.Right}, condArgs...)
+	join := " FROM (SELECT id, geom FROM datasets" + w.clause() + ") l JOIN datasets r ON " + match
+	joinArgs := append(append([]interface{}{}, w.args...), matchArgs...)
+
+	var totals struct {
+		Matched int `db:"matched"`
+		Pairs   int `db:"pairs"`
+	}
+	query := "SELECT COUNT(DISTINCT l.id) AS matched, COUNT(*) AS pairs" + join
+	if err := r.db.GetContext(ctx, &totals, r.db.Rebind(query), joinArgs...); err != nil {
+		return nil, err
+	}
+	result.PairCount = totals.Pairs
+
+	if j.Mode == JoinCount {
+		var counts []struct {
+			ID    int `db:"right_id"`
+			Count int `db:"count"`
+		}
+		query = "SELECT r.id AS right_id, COUNT(*) AS count" + join + " GROUP BY r.id"
+		if err := r.db.SelectContext(ctx, &counts, r.db.Rebind(query), joinArgs...); err != nil {
+			return nil, err
+		}
+		for _, c := range counts {
+			result.Count[c.ID] = c.Count
+		}
+		var ids []int
+		query = "SELECT id FROM datasets r WHERE dataset_type = ? AND " + postgisJoinLocated
+		if err := r.db.SelectContext(ctx, &ids, r.db.Rebind(query), j.Right); err != nil {
+			return nil, err
+		}
+		result.Total = len(ids)
+		page := countPage(ids, result.Count, f.Limit, f.Offset)
+		if err := r.byIDs(ctx, &result.Right, page); err != nil {
+			return nil, err
+		}
+		result.Right = orderByIDs(result.Right, page)
+		return result, nil
+	}
+	result.Total = totals.Matched
+
+	// The page of matched left rows; unqualified columns refer to l
+	w.add("EXISTS (SELECT 1 FROM datasets r WHERE "+match+")", matchArgs...)
+	query = "SELECT " + postgisDatasetColumns + " FROM datasets l" + w.clause() + datasetOrder + w.page(f.Limit, f.Offset, "ALL")
+	if err := r.db.SelectContext(ctx, &result.Left, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	if len(result.Left) == 0 {
+		return result, nil
+	}
+	ids := make([]int64, len(result.Left))
+	for i, d := range result.Left {
+		ids[i] = int64(d.ID)
+	}
+
+	query = "SELECT l.id AS left_id, r.id AS right_id, " + distance + " AS distance_m" +
+		" FROM datasets l JOIN datasets r ON " + match + " WHERE l.id = ANY(?) LIMIT ?"
+	args := append(append([]interface{}{}, matchArgs...), pq.Array(ids), MaxJoinPairs+1)
+	if err := r.db.SelectContext(ctx, &result.Pairs, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	if len(result.Pairs) > MaxJoinPairs {
+		return nil, ErrJoinTooLarge
+	}
+	sortPairs(result.Pairs)
+
+	right := make([]int, 0, len(result.Pairs))
+	for _, p := range result.Pairs {
+		right = append(right, p.RightID)
+	}
+	slices.Sort(right)
+	if err := r.byIDs(ctx, &result.Right, slices.Compact(right)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// byIDs loads the dataset rows with the given ids, by id.
+func (r *postgisDatasets) byIDs(ctx context.Context, dest *[]models.Dataset, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	ids64 := make([]int64, len(ids))
+	for i, id := range ids {
+		ids64[i] = int64(id)
+	}
+	query := "SELECT " + postgisDatasetColumns + " FROM datasets WHERE id = ANY(?) ORDER BY id"
+	return r.db.SelectContext(ctx, dest, r.db.Rebind(query), pq.Array(ids64))
+}
+
+func (r *postgisDatasets) Types(ctx context.Context) ([]DatasetTypeSummary, error) {
+	query := `
+		SELECT
+			dataset_type,
+			COUNT(*) as count,
+			MIN(value) as min_value,
+			MAX(value) as max_value,
+			MIN(timestamp) as min_date,
+			MAX(timestamp) as max_date
+		FROM datasets
+		GROUP BY dataset_type
+	`
+	var results []DatasetTypeSummary
+	if err := r.db.SelectContext(ctx, &results, query); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *postgisDatasets) Stats(ctx context.Context, s StatsRequest) (*DatasetStats, error) {
+	w := datasetConditions(s.Filter, postgisDialect)
+	addPostGISSpatial(w, s.Filter.SpatialFilter)
+	value := s.Filter.valueColumn(postgisDialect)
+	aggregates := "COUNT(" + value + ") AS value_count, AVG(" + value + ") AS avg_value, MIN(" + value +
+		") AS min_value, MAX(" + value + ") AS max_value"
+
+	var row struct {
+		TotalCount int      `db:"total_count"`
+		ValueCount int      `db:"value_count"`
+		AvgValue   *float64 `db:"avg_value"`
+		MinValue   *float64 `db:"min_value"`
+		MaxValue   *float64 `db:"max_value"`
+		StdDev     *float64 `db:"stddev"`
+		MinLon     *float64 `db:"min_lon"`
+		MinLat     *float64 `db:"min_lat"`
+		MaxLon     *float64 `db:"max_lon"`
+		MaxLat     *float64 `db:"max_lat"`
+	}
+	query := "SELECT COUNT(*) AS total_count, " + aggregates + ", STDDEV_SAMP(" + value + ") AS stddev," +
+		" ST_XMin(ST_Extent(geom)) AS min_lon, ST_YMin(ST_Extent(geom)) AS min_lat," +
+		" ST_XMax(ST_Extent(geom)) AS max_lon, ST_YMax(ST_Extent(geom)) AS max_lat" +
+		" FROM datasets" + w.clause()
+	if err := r.db.GetContext(ctx, &row, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	stats := &DatasetStats{
+		TotalCount:  row.TotalCount,
+		ValueCount:  row.ValueCount,
+		AvgValue:    row.AvgValue,
+		MinValue:    row.MinValue,
+		MaxValue:    row.MaxValue,
+		StdDev:      row.StdDev,
+		Percentiles: []Percentile{},
+		Histogram:   []HistogramBin{},
+		Units:       []UnitStats{},
+	}
+	if row.MinLon != nil {
+		stats.BBox = &geo.BBox{MinLon: *row.MinLon, MinLat: *row.MinLat, MaxLon: *row.MaxLon, MaxLat: *row.MaxLat}
+	}
+
+	query = "SELECT unit, COUNT(*) AS count, " + aggregates + " FROM datasets" + w.clause() +
+		" GROUP BY unit ORDER BY unit NULLS LAST"
+	if err := r.db.SelectContext(ctx, &stats.Units, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+
+	w.add(value + " IS NOT NULL")
+	if len(s.Percentiles) > 0 {
+		fractions := make([]float64, len(s.Percentiles))
+		for i, p := range s.Percentiles {
+			fractions[i] = p / 100
+		}
+		var values pq.Float64Array
+		query = "SELECT percentile_cont(?::float8[]) WITHIN GROUP (ORDER BY " + value + ") FROM datasets" + w.clause()
+		args := append([]interface{}{pq.Array(fractions)}, w.args...)
+		if err := r.db.GetContext(ctx, &values, r.db.Rebind(query), args...); err != nil {
+			return nil, err
+		}
+		for i, p := range s.Percentiles {
+			pc := Percentile{P: p}
+			if i < len(values) {
+				pc.Value = &values[i]
+			}
+			stats.Percentiles = append(stats.Percentiles, pc)
+		}
+	}
+	if s.Bins > 0 && stats.ValueCount > 0 {
+		lo, hi := *stats.MinValue, *stats.MaxValue
+		stats.Histogram = histogramBins(lo, hi, s.Bins)
+		if lo == hi {
+			stats.Histogram[0].Count = stats.ValueCount
+			return stats, nil
+		}
+		var counts []struct {
+			Bin   int `db:"bin"`
+			Count int `db:"count"`
+		}
+		// width_bucket puts max in bin n+1, so it is folded into the last bin
+		query = "SELECT LEAST(width_bucket(" + value + ", ?, ?, ?), ?) AS bin, COUNT(*) AS count FROM datasets" +
+			w.clause() + " GROUP BY bin"
+		args := append([]interface{}{lo, hi, s.Bins, s.Bins}, w.args...)
+		if err := r.db.SelectContext(ctx, &counts, r.db.Rebind(query), args...); err != nil {
+			return nil, err
+		}
+		for _, c := range counts {
+			stats.Histogram[c.Bin-1].Count = c.Count
+		}
+	}
+	return stats, nil
+}
+
+func (r *postgisDatasets) Timeseries(ctx context.Context, t TimeseriesRequest) ([]TimeBucket, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	w := datasetConditions(t.Filter, postgisDialect)
+	addPostGISSpatial(w, t.Filter.SpatialFilter)
+	w.add(postgisDialect.observed + " IS NOT NULL")
+	query := "SELECT date_trunc('" + string(t.Interval) + "', " + postgisDialect.observed + ") AS bucket," +
+		" COUNT(*) AS count, " + t.aggregate(postgisDialect) + "::float8 AS value" +
+		" FROM datasets" + w.clause() + " GROUP BY bucket ORDER BY bucket"
+	buckets := make([]TimeBucket, 0)
+	if err := r.db.SelectContext(ctx, &buckets, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+func (r *postgisDatasets) Tile(ctx context.Context, t TileRequest) ([]byte, error) {
+	var inner, outer string
+	for _, f := range t.fields() {
+		inner += ", " + tileFieldColumns[f] + ` AS "` + f + `"`
+		outer += `, "` + f + `"`
+	}
+	box := geo.TileBounds(t.Z, t.X, t.Y, float64(TileBuffer)/TileExtent)
+	grid := tileGrid(t.Z)
+
+	// Simplify and clip into tile space, then keep the highest-valued point
+	// per grid cell; lines and polygons are keyed by id so every one is kept.
+	// ST_Simplify leaves points alone and drops collapsed polygons
+	// (ST_AsMVTGeom then yields NULL), as the Go encoder does
+	query := `
+		SELECT ST_AsMVT(tile.*, ?, ?, 'geom', 'id') FROM (
+			SELECT DISTINCT ON (cell) id, geom` + outer + `
+			FROM (
+				SELECT *, CASE WHEN GeometryType(geom) = 'POINT'
+					THEN floor(ST_X(geom) / ?) || ':' || floor(ST_Y(geom) / ?)
+					ELSE 'id:' || id END AS cell
+				FROM (
+					SELECT id, value AS rank,
+						ST_AsMVTGeom(ST_Simplify(ST_Transform(geom, 3857), ?), ST_TileEnvelope(?, ?, ?), ?, ?, true) AS geom` + inner + `
+					FROM datasets
+					WHERE dataset_type = ? AND ` + postgisEnvelope + ` AND ` + locatedCondition(postgisDialect) + `
+				) clipped
+				WHERE geom IS NOT NULL
+			) keyed
+			ORDER BY cell, rank DESC NULLS LAST, id
+		) tile
+	`
+	args := []interface{}{
+		t.Type, TileExtent,
+		grid, grid,
+		tileTolerance(t.Z), t.Z, t.X, t.Y, TileExtent, TileBuffer,
+		t.Type, box.MinLon, box.MinLat, box.MaxLon, box.MaxLat,
+	}
+
+	var tile []byte
+	if err := r.db.GetContext(ctx, &tile, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+	return tile, nil
+}
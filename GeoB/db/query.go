@@ -0,0 +1,186 @@
+// query.go
+//
+// SQL assembly helpers shared by the storage backends
+// Compliance Level: High
+//
+// - Conditions are written with '?' markers and values are bound in the same call,
+//   so a condition and its arguments can never drift apart
+// - Backends pass the final SQL through sqlx Rebind, which renumbers '?' into the
+//   driver's placeholder style ($1, $2, ... for PostgreSQL)
+// - Column names are never taken from user input
+//
+// NOTE: Do not use the PostgreSQL jsonb '?' operator in conditions; Rebind would
+// treat it as a placeholder (use jsonb_exists instead)
+
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// whereBuilder accumulates AND-ed conditions and their bound values.
+type whereBuilder struct {
+	conds []string
+	args  []interface{}
+}
+
+// add appends a condition; len(args) must match the number of '?' markers in cond.
+func (w *whereBuilder) add(cond string, args ...interface{}) {
+	w.conds = append(w.conds, cond)
+	w.args = append(w.args, args...)
+}
+
+// addRange appends lower/upper bound conditions on column for the non-nil bounds.
+func addRange[T int | float64](w *whereBuilder, column string, min, max *T) {
+	if min != nil {
+		w.add(column+" >= ?", *min)
+	}
+	if max != nil {
+		w.add(column+" <= ?", *max)
+	}
+}
+
+// clause renders " WHERE a AND b", or an empty string when there are no conditions.
+func (w *whereBuilder) clause() string {
+	if len(w.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(w.conds, " AND ")
+}
+
+// page renders LIMIT/OFFSET and binds their values; limit <= 0 means no limit.
+// unbounded is the dialect's "no limit" literal, needed because SQLite only
+// accepts OFFSET after a LIMIT ("ALL" for PostgreSQL, "-1" for SQLite).
+func (w *whereBuilder) page(limit, offset int, unbounded string) string {
+	var sql string
+	if limit > 0 {
+		sql += " LIMIT ?"
+		w.args = append(w.args, limit)
+	}
+	if offset > 0 {
+		if limit <= 0 {
+			sql += " LIMIT " + unbounded
+		}
+		sql += " OFFSET ?"
+		w.args = append(w.args, offset)
+	}
+	return sql
+}
+
+// Sort orders matching the keysets in cursor.go.
+const (
+	meteoriteOrder = " ORDER BY COALESCE(year, 0) DESC, id DESC"
+	datasetOrder   = " ORDER BY id DESC"
+)
+
+// containingOrder sorts point-in-polygon matches by layer.
+const containingOrder = " ORDER BY dataset_type, id"
+
+// addTypes restricts rows to any of the dataset types; no types means all.
+func addTypes(w *whereBuilder, types []string) {
+	if len(types) == 0 {
+		return
+	}
+	args := make([]interface{}, len(types))
+	for i, t := range types {
+		args[i] = t
+	}
+	w.add("dataset_type IN (?"+strings.Repeat(", ?", len(types)-1)+")", args...)
+}
+
+// meteoriteConditions renders the attribute filters shared by both backends.
+func meteoriteConditions(f MeteoriteFilter) *whereBuilder {
+	w := &whereBuilder{}
+	addRange(w, "year", f.YearStart, f.YearEnd)
+	addRange(w, "mass", f.MassMin, f.MassMax)
+	if f.Recclass != "" {
+		w.add("recclass = ?", f.Recclass)
+	}
+	if f.After != nil {
+		w.add("(COALESCE(year, 0), id) < (?, ?)", f.After.Year, f.After.ID)
+	}
+	return w
+}
+
+// sqlDialect holds the expressions that differ between the backends.
+type sqlDialect struct {
+	text   string // reads a metadata key as text; %s is the key
+	number string // reads a metadata key as a number; %s is the key
+	// observed is the time of a row: timestamp, else January 1 of year
+	// (meteorites only carry a year)
+	observed string
+}
+
+// driverDialects is the dialect of each backend, by sqlx driver name, for
+// repositories shared by both (climate.go, wind.go).
+var driverDialects = map[string]sqlDialect{
+	"postgres": postgisDialect,
+	"sqlite3":  sqliteDialect,
+}
+
+// valueColumn is the expression f reads as the row value: the value column,
+// or the selected period read from metadata.
+func (f DatasetFilter) valueColumn(d sqlDialect) string {
+	if f.Period == "" {
+		return "value"
+	}
+	return fmt.Sprintf(d.number, f.Period)
+}
+
+// selectValue swaps the value column of a dataset column list for the
+// selected period.
+func (f DatasetFilter) selectValue(columns string, d sqlDialect) string {
+	if f.Period == "" {
+		return columns
+	}
+	return strings.Replace(columns, "value, unit,", f.valueColumn(d)+" AS value, unit,", 1)
+}
+
+// order renders the ORDER BY of a listing; rows without a value sort last.
+func (f DatasetFilter) order(d sqlDialect) string {
+	value := f.valueColumn(d)
+	switch f.Sort {
+	case SortValueAsc:
+		return " ORDER BY " + value + " IS NULL, " + value + ", id DESC"
+	case SortValueDesc:
+		return " ORDER BY " + value + " IS NULL, " + value + " DESC, id DESC"
+	}
+	return datasetOrder
+}
+
+// locatedCondition keeps rows with a real position: rows of files without
+// coordinates are imported at the fallback location (see ingest/sources.go)
+// and flagged with metadata location_source "fallback".
+func locatedCondition(d sqlDialect) string {
+	return "COALESCE(" + fmt.Sprintf(d.text, "location_source") + ", '') <> 'fallback'"
+}
+
+// datasetConditions renders the attribute filters shared by both backends.
+func datasetConditions(f DatasetFilter, d sqlDialect) *whereBuilder {
+	w := &whereBuilder{}
+	if f.Type != "" {
+		w.add("dataset_type = ?", f.Type)
+	}
+	if f.ClimateType != "" {
+		w.add(fmt.Sprintf(d.text, "climate_type")+" = ?", f.ClimateType)
+	}
+	if f.Located || f.active() {
+		w.add(locatedCondition(d))
+	}
+	value := f.valueColumn(d)
+	if f.Period != "" {
+		w.add(value + " IS NOT NULL")
+	}
+	addRange(w, value, f.ValueMin, f.ValueMax)
+	if f.From != nil {
+		w.add(d.observed+" >= ?", f.From.UTC())
+	}
+	if f.To != nil {
+		w.add(d.observed+" < ?", f.To.UTC())
+	}
+	if f.After != nil {
+		w.add("id < ?", f.After.ID)
+	}
+	return w
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestWhereBuilderClause(t *testing.T) {
+	w := &whereBuilder{}
+	if got := w.clause(); got != "" {
+		t.Fatalf("empty clause = %q, want \"\"", got)
+	}
+	w.add("a = ?", 1)
+	w.add("b BETWEEN ? AND ?", 2, 3)
+	addRange(w, "c", ptr(4.5), nil)
+	addRange[int](w, "d", nil, nil)
+
+	want := " WHERE a = ? AND b BETWEEN ? AND ? AND c >= ?"
+	if got := w.clause(); got != want {
+		t.Errorf("clause = %q, want %q", got, want)
+	}
+	if want := []interface{}{1, 2, 3, 4.5}; !reflect.DeepEqual(w.args, want) {
+		t.Errorf("args = %v, want %v", w.args, want)
+	}
+	// PostGIS numbers the markers in order
+	got := sqlx.Rebind(sqlx.DOLLAR, "SELECT id FROM t"+w.clause()+w.page(10, 20, "ALL"))
+	if want := "SELECT id FROM t WHERE a = $1 AND b BETWEEN $2 AND $3 AND c >= $4 LIMIT $5 OFFSET $6"; got != want {
+		t.Errorf("rebound = %q, want %q", got, want)
+	}
+}
+
+func TestWhereBuilderPage(t *testing.T) {
+	tests := []struct {
+		name          string
+		limit, offset int
+		unbounded     string
+		sql           string
+		args          []interface{}
+	}{
+		{"none", 0, 0, "-1", "", nil},
+		{"limit", 5, 0, "-1", " LIMIT ?", []interface{}{5}},
+		{"limit and offset", 5, 10, "-1", " LIMIT ? OFFSET ?", []interface{}{5, 10}},
+		{"offset sqlite", 0, 10, "-1", " LIMIT -1 OFFSET ?", []interface{}{10}},
+		{"offset postgis", -1, 10, "ALL", " LIMIT ALL OFFSET ?", []interface{}{10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &whereBuilder{}
+			if got := w.page(tt.limit, tt.offset, tt.unbounded); got != tt.sql {
+				t.Errorf("page = %q, want %q", got, tt.sql)
+			}
+			if !reflect.DeepEqual(w.args, tt.args) {
+				t.Errorf("args = %v, want %v", w.args, tt.args)
+			}
+		})
+	}
+}
+
+func TestDatasetConditions(t *testing.T) {
+	from := time.Date(1990, 1, 1, 0, 0, 0, 0, time.FixedZone("AEST", 10*3600))
+	to := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter DatasetFilter
+		d      sqlDialect
+		clause string
+		args   []interface{}
+	}{
+		{
+			name: "empty",
+			d:    postgisDialect,
+		},
+		{
+			name:   "type and value range",
+			filter: DatasetFilter{Type: "climate", ValueMin: ptr(0.0), ValueMax: ptr(1e7)},
+			d:      postgisDialect,
+			clause: " WHERE dataset_type = ? AND value >= ? AND value <= ?",
+			args:   []interface{}{"climate", 0.0, 1e7},
+		},
+		{
+			name:   "period postgis",
+			filter: DatasetFilter{Period: "jja", ClimateType: "tas", ValueMin: ptr(10.0)},
+			d:      postgisDialect,
+			clause: " WHERE metadata->>'climate_type' = ? AND (metadata->>'jja')::float8 IS NOT NULL AND (metadata->>'jja')::float8 >= ?",
+			args:   []interface{}{"tas", 10.0},
+		},
+		{
+			name:   "period sqlite",
+			filter: DatasetFilter{Period: "july", ValueMax: ptr(30.0)},
+			d:      sqliteDialect,
+			clause: " WHERE json_extract(metadata, '$.july') IS NOT NULL AND json_extract(metadata, '$.july') <= ?",
+			args:   []interface{}{30.0},
+		},
+		{
+			name:   "located",
+			filter: DatasetFilter{Type: "pipe", Located: true},
+			d:      sqliteDialect,
+			clause: " WHERE dataset_type = ? AND COALESCE(json_extract(metadata, '$.location_source'), '') <> 'fallback'",
+			args:   []interface{}{"pipe"},
+		},
+		{
+			name:   "spatial implies located",
+			filter: DatasetFilter{SpatialFilter: SpatialFilter{Near: &Proximity{Lat: -37.8, Lon: 145, Radius: 100}}},
+			d:      postgisDialect,
+			clause: " WHERE COALESCE(metadata->>'location_source', '') <> 'fallback'",
+		},
+		{
+			name:   "time range and cursor",
+			filter: DatasetFilter{From: &from, To: &to, After: &DatasetKey{ID: 42}},
+			d:      sqliteDialect,
+			clause: " WHERE " + sqliteDialect.observed + " >= ? AND " + sqliteDialect.observed + " < ? AND id < ?",
+			args:   []interface{}{from.UTC(), to, 42},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := datasetConditions(tt.filter, tt.d)
+			if got := w.clause(); got != tt.clause {
+				t.Errorf("clause = %q, want %q", got, tt.clause)
+			}
+			if !reflect.DeepEqual(w.args, tt.args) {
+				t.Errorf("args = %v, want %v", w.args, tt.args)
+			}
+		})
+	}
+}
+
+func TestMeteoriteConditions(t *testing.T) {
+	w := meteoriteConditions(MeteoriteFilter{
+		YearStart: ptr(1900), YearEnd: ptr(2000), MassMin: ptr(0.0),
+		Recclass: "L6", After: &MeteoriteKey{Year: 1950, ID: 7},
+	})
+	want := " WHERE year >= ? AND year <= ? AND mass >= ? AND recclass = ? AND (COALESCE(year, 0), id) < (?, ?)"
+	if got := w.clause(); got != want {
+		t.Errorf("clause = %q, want %q", got, want)
+	}
+	if want := []interface{}{1900, 2000, 0.0, "L6", 1950, 7}; !reflect.DeepEqual(w.args, want) {
+		t.Errorf("args = %v, want %v", w.args, want)
+	}
+}
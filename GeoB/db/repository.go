@@ -0,0 +1,242 @@
+// repository.go
+//
+// Repository layer for GeoGO
+// Typed query methods that decouple the HTTP handlers from SQL and the database engine.
+// Compliance Level: High
+//
+// - MeteoriteRepository and DatasetRepository are implemented by the PostGIS
+//   backend (postgis.go) and the embedded SQLite backend (sqlite.go);
+//   PipeRepository, ClimateRepository and WindRepository serve both (pipes.go,
+//   climate.go, wind.go)
+// - Filters are plain structs; geocoding and parameter parsing stay in the api package
+// - SQL is assembled with whereBuilder (query.go), never with hand-numbered placeholders
+// - All methods honour context cancellation
+//
+// Filter Semantics:
+// - nil range bounds and empty strings mean "no filter"
+// - Limit <= 0 means "no limit"
+// - After (keyset cursor, see cursor.go) and Offset may be combined but
+//   clients should use one or the other
+// - Radii are expressed in metres on both backends
+// - Spatial members (Near, BBox, Within) combine with AND
+// - Dataset rows may be lines or polygons; a spatial member matches when any
+//   part of the shape is within the radius, viewport or polygon (ST_Intersects)
+// - Rows imported at the fallback location (metadata location_source
+//   "fallback") have no real position: spatial filters, Nearest, Join and
+//   Tile leave them out, as does DatasetFilter.Located
+//
+// NOTE: Backends must return identical shapes so handlers stay engine-agnostic
+// NOTE: Handlers use the package-level Meteorites and Datasets; tests can swap in fakes
+
+package db
+
+import (
+	"GeoGO/geo"
+	"GeoGO/models"
+	"context"
+	"time"
+)
+
+// Proximity restricts results to points within Radius metres of (Lat, Lon).
+type Proximity struct {
+	Lat    float64
+	Lon    float64
+	Radius float64
+}
+
+// SpatialFilter restricts results by location. It is embedded in the
+// per-table filters so both backends can handle it in one place.
+type SpatialFilter struct {
+	Near   *Proximity       // radius around a point
+	BBox   *geo.BBox        // viewport; may cross the antimeridian
+	Within geo.MultiPolygon // drawn polygon(s); nil means no polygon filter
+}
+
+// active reports whether any spatial member is set.
+func (s SpatialFilter) active() bool {
+	return s.Near != nil || s.BBox != nil || s.Within != nil
+}
+
+// matches reports whether a point satisfies every spatial member.
+// Backends without native spatial predicates use it after an index prefilter.
+func (s SpatialFilter) matches(lat, lon float64) bool {
+	if s.Near != nil && geo.Haversine(s.Near.Lat, s.Near.Lon, lat, lon) > s.Near.Radius {
+		return false
+	}
+	if s.BBox != nil && !s.BBox.Contains(lat, lon) {
+		return false
+	}
+	if s.Within != nil && !s.Within.Contains(lat, lon) {
+		return false
+	}
+	return true
+}
+
+// matchesShape is matches for rows that may store a shape; g is nil for
+// points, which are tested at (lat, lon).
+func (s SpatialFilter) matchesShape(g *geo.Geometry, lat, lon float64) bool {
+	if g == nil {
+		return s.matches(lat, lon)
+	}
+	if s.Near != nil && g.Distance(s.Near.Lat, s.Near.Lon) > s.Near.Radius {
+		return false
+	}
+	if s.BBox != nil && !g.IntersectsBBox(*s.BBox) {
+		return false
+	}
+	if s.Within != nil && !g.Intersects(s.Within) {
+		return false
+	}
+	return true
+}
+
+// MeteoriteFilter selects rows from the legacy meteorite table.
+type MeteoriteFilter struct {
+	YearStart *int
+	YearEnd   *int
+	MassMin   *float64
+	MassMax   *float64
+	Recclass  string
+	SpatialFilter
+	After  *MeteoriteKey
+	Limit  int
+	Offset int
+}
+
+// DatasetFilter selects rows from the unified datasets table.
+type DatasetFilter struct {
+	Type     string
+	ValueMin *float64
+	ValueMax *float64
+	// From and To bound the row time (timestamp, else January 1 of year);
+	// To is exclusive
+	From *time.Time
+	To   *time.Time
+	// Period selects a climate period by its metadata key ("jja", "july", see
+	// models.ClimateSeasons); its value then stands in for value in the range,
+	// the sort and the returned rows, and rows without it are skipped. The
+	// key is rendered into SQL, so it must come from that list.
+	Period string
+	// ClimateType keeps climate rows of one variable (metadata climate_type)
+	ClimateType string
+	// Located leaves out rows placed at the import fallback location; implied
+	// by any spatial member
+	Located bool
+	Sort    DatasetSort
+	SpatialFilter
+	After  *DatasetKey // only valid with the default sort
+	Limit  int
+	Offset int
+}
+
+// DatasetSort orders a dataset listing; the zero value is newest (highest id) first.
+type DatasetSort string
+
+const (
+	SortValueAsc  DatasetSort = "value"
+	SortValueDesc DatasetSort = "-value"
+)
+
+// DatasetTypeSummary is the per-type aggregate behind /datasets/types.
+type DatasetTypeSummary struct {
+	Type     string   `db:"dataset_type"`
+	Count    int      `db:"count"`
+	MinValue *float64 `db:"min_value"`
+	MaxValue *float64 `db:"max_value"`
+	MinDate  *string  `db:"min_date"`
+	MaxDate  *string  `db:"max_date"`
+}
+
+// Neighbor is a dataset row with its geodesic distance from a query point
+// (to the nearest part of a line or polygon).
+type Neighbor struct {
+	models.Dataset
+	Distance float64 `db:"distance_m"`
+}
+
+// MeteoriteRepository queries the legacy meteorite table.
+type MeteoriteRepository interface {
+	// List returns meteorites matching f, newest first (ties by descending id).
+	List(ctx context.Context, f MeteoriteFilter) ([]models.Meteorite, error)
+	// Largest returns the heaviest meteorites.
+	Largest(ctx context.Context, limit int) ([]models.Meteorite, error)
+}
+
+// DatasetRepository queries the unified datasets table.
+type DatasetRepository interface {
+	// List returns dataset rows matching f, newest (highest id) first.
+	List(ctx context.Context, f DatasetFilter) ([]models.Dataset, error)
+	// Types summarises every dataset type present.
+	Types(ctx context.Context) ([]DatasetTypeSummary, error)
+	// Stats summarises the values and extent of the rows matching s.Filter
+	// (see stats.go).
+	Stats(ctx context.Context, s StatsRequest) (*DatasetStats, error)
+	// Timeseries aggregates the rows matching t.Filter into time buckets,
+	// oldest first (see timeseries.go).
+	Timeseries(ctx context.Context, t TimeseriesRequest) ([]TimeBucket, error)
+	// Nearest returns the k rows matching f closest to (lat, lon), nearest
+	// first. f.Near, f.After, f.Limit and f.Offset are ignored.
+	Nearest(ctx context.Context, f DatasetFilter, lat, lon float64, k int) ([]Neighbor, error)
+	// Containing returns the polygon rows of the given types (all types when
+	// empty) that contain (lat, lon), ordered by type then id.
+	Containing(ctx context.Context, types []string, lat, lon float64) ([]models.Dataset, error)
+	// Join pairs the rows selected by j.Left with the right rows they
+	// satisfy j.Predicate against (see join.go).
+	Join(ctx context.Context, j JoinRequest) (*JoinResult, error)
+	// Tile renders one Mapbox Vector Tile; an empty result means no features.
+	Tile(ctx context.Context, t TileRequest) ([]byte, error)
+	// BeginImport opens a bulk-load transaction (see import.go).
+	BeginImport(ctx context.Context) (DatasetImport, error)
+}
+
+// PipeRepository queries the drainage pipe network (see pipes.go).
+type PipeRepository interface {
+	// All returns every pipe ordered by id.
+	All(ctx context.Context) ([]models.Pipe, error)
+}
+
+// ClimateRepository reads climate station projections (see climate.go).
+type ClimateRepository interface {
+	// Projections returns every projection row of a station, ordered by
+	// variable, RCP, model and climatology.
+	Projections(ctx context.Context, stationID string) ([]models.ClimateData, error)
+	// StationValues returns the selected value of every station that has it,
+	// ordered by station id.
+	StationValues(ctx context.Context, s ClimateSelection) ([]StationValue, error)
+}
+
+// WindRepository reads wind observations (see wind.go).
+type WindRepository interface {
+	// Stations returns every wind location, by name.
+	Stations(ctx context.Context) ([]WindStation, error)
+	// Station returns one location, or nil when it has no observations.
+	Station(ctx context.Context, location string) (*WindStation, error)
+	// Observations returns the observations of a location in [from, to),
+	// oldest first; nil bounds are open.
+	Observations(ctx context.Context, location string, from, to *time.Time) ([]models.WindData, error)
+}
+
+// Active repositories, set by InitDB.
+var (
+	Meteorites MeteoriteRepository
+	Datasets   DatasetRepository
+	Pipes      PipeRepository
+	Climate    ClimateRepository
+	Wind       WindRepository
+)
+
+// paginate applies offset/limit to rows that were filtered in Go.
+// A non-positive limit returns everything after offset.
+func paginate[T any](rows []T, limit, offset int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(rows) {
+		return rows[:0]
+	}
+	rows = rows[offset:]
+	if limit > 0 && limit < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows
+}
@@ -0,0 +1,700 @@
+// sqlite.go
+//
+// Embedded SQLite storage backend for GeoGO
+// Lets developers and CI run the API without a PostgreSQL server.
+// Compliance Level: Moderate
+//
+// - Mirrors the PostGIS schema (datasets + legacy locations) in a single file
+// - R*Tree virtual tables index every row's extent (a point, or the bounds of
+//   a line or polygon); triggers keep them in sync
+// - Radius filters use the R*Tree for a bounding-box prefilter, then an exact
+//   haversine check in Go, so results match ST_DWithin on geography to within
+//   the spherical-Earth error
+// - Viewport filters are answered by the R*Tree alone unless shapes are among
+//   the candidates; polygon filters use the polygon's bounds as the prefilter
+//   and an intersection test in Go (geo.Geometry), matching ST_Intersects
+// - Point-in-polygon lookups take the shapes whose R*Tree extent holds the
+//   point and test containment in Go
+// - Spatial joins load the left rows once and bucket them on a one-degree
+//   grid; each right row is tested against the rows in the cells it covers
+// - Nearest-neighbour queries widen a radius search until k rows are found,
+//   then rank by haversine distance
+// - Vector tiles are encoded in Go (package mvt) with the rules from tile.go
+//
+// Schema Notes:
+// - locations uses latitude/longitude columns, matching the data/geogo.db file
+//   produced by utils/ParseData.py
+// - datasets and pipes mirror utils/SQL/create_unified_schema.sql without the geom columns;
+//   datasets stores non-point shapes as GeoJSON in geometry, with their bounds
+//   in min_lat/max_lat/min_lon/max_lon for the R*Tree (all NULL for points)
+// - Columns added after a file was created are added on open (sqliteMigrations)
+//
+// NOTE: Pagination for radius and polygon queries happens after the Go-side filter
+// NOTE: Intended for development and CI; use PostGIS for production workloads
+
+package db
+
+import (
+	"GeoGO/geo"
+	"GeoGO/models"
+	"GeoGO/mvt"
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteTables creates the tables if missing.
+const sqliteTables = `
+CREATE TABLE IF NOT EXISTS locations (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	nametype TEXT,
+	recclass TEXT,
+	mass REAL,
+	fall TEXT,
+	year INTEGER,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS datasets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	dataset_type TEXT NOT NULL,
+	name TEXT NOT NULL,
+	lat REAL NOT NULL,
+	lon REAL NOT NULL,
+	value REAL,
+	unit TEXT,
+	timestamp TIMESTAMP,
+	metadata TEXT,
+	recclass TEXT,
+	mass REAL,
+	year INTEGER,
+	nametype TEXT,
+	fall TEXT,
+	geometry TEXT,
+	min_lat REAL,
+	max_lat REAL,
+	min_lon REAL,
+	max_lon REAL
+);
+`
+
+// sqliteMigrations lists columns added since the first schema; files created
+// before them gain the columns on open.
+var sqliteMigrations = []struct{ table, column, definition string }{
+	{"datasets", "geometry", "TEXT"},
+	{"datasets", "min_lat", "REAL"},
+	{"datasets", "max_lat", "REAL"},
+	{"datasets", "min_lon", "REAL"},
+	{"datasets", "max_lon", "REAL"},
+}
+
+// sqliteIndexes creates the indexes, R*Tree tables and sync triggers, then
+// backfills the R*Trees for rows loaded before the triggers existed. The
+// datasets triggers are recreated so files from older versions index shape
+// extents too.
+const sqliteIndexes = `
+CREATE INDEX IF NOT EXISTS idx_datasets_type ON datasets (dataset_type);
+CREATE INDEX IF NOT EXISTS idx_datasets_name ON datasets (name);
+
+CREATE TABLE IF NOT EXISTS pipes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	compkey INTEGER NOT NULL,
+	pipe_unit_id TEXT,
+	from_pit TEXT NOT NULL,
+	to_pit TEXT NOT NULL,
+	unit_type TEXT,
+	catchment TEXT,
+	sub_catchment TEXT,
+	material TEXT,
+	shape TEXT,
+	parallel_lines INTEGER,
+	diameter_mm REAL,
+	height_mm REAL,
+	length_m REAL,
+	up_invert REAL,
+	down_invert REAL,
+	grade REAL,
+	average_depth REAL
+);
+CREATE INDEX IF NOT EXISTS idx_pipes_from_pit ON pipes (from_pit);
+CREATE INDEX IF NOT EXISTS idx_pipes_to_pit ON pipes (to_pit);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS locations_rtree USING rtree(id, min_lat, max_lat, min_lon, max_lon);
+CREATE VIRTUAL TABLE IF NOT EXISTS datasets_rtree USING rtree(id, min_lat, max_lat, min_lon, max_lon);
+
+CREATE TRIGGER IF NOT EXISTS locations_rtree_insert AFTER INSERT ON locations BEGIN
+	INSERT OR REPLACE INTO locations_rtree VALUES (NEW.id, NEW.latitude, NEW.latitude, NEW.longitude, NEW.longitude);
+END;
+CREATE TRIGGER IF NOT EXISTS locations_rtree_update AFTER UPDATE OF latitude, longitude ON locations BEGIN
+	INSERT OR REPLACE INTO locations_rtree VALUES (NEW.id, NEW.latitude, NEW.latitude, NEW.longitude, NEW.longitude);
+END;
+CREATE TRIGGER IF NOT EXISTS locations_rtree_delete AFTER DELETE ON locations BEGIN
+	DELETE FROM locations_rtree WHERE id = OLD.id;
+END;
+
+DROP TRIGGER IF EXISTS datasets_rtree_insert;
+CREATE TRIGGER datasets_rtree_insert AFTER INSERT ON datasets BEGIN
+	INSERT OR REPLACE INTO datasets_rtree VALUES (NEW.id,
+		COALESCE(NEW.min_lat, NEW.lat), COALESCE(NEW.max_lat, NEW.lat),
+		COALESCE(NEW.min_lon, NEW.lon), COALESCE(NEW.max_lon, NEW.lon));
+END;
+DROP TRIGGER IF EXISTS datasets_rtree_update;
+CREATE TRIGGER datasets_rtree_update AFTER UPDATE OF lat, lon, min_lat, max_lat, min_lon, max_lon ON datasets BEGIN
+	INSERT OR REPLACE INTO datasets_rtree VALUES (NEW.id,
+		COALESCE(NEW.min_lat, NEW.lat), COALESCE(NEW.max_lat, NEW.lat),
+		COALESCE(NEW.min_lon, NEW.lon), COALESCE(NEW.max_lon, NEW.lon));
+END;
+CREATE TRIGGER IF NOT EXISTS datasets_rtree_delete AFTER DELETE ON datasets BEGIN
+	DELETE FROM datasets_rtree WHERE id = OLD.id;
+END;
+
+INSERT INTO locations_rtree
+	SELECT id, latitude, latitude, longitude, longitude FROM locations
+	WHERE id NOT IN (SELECT id FROM locations_rtree);
+INSERT INTO datasets_rtree
+	SELECT id, COALESCE(min_lat, lat), COALESCE(max_lat, lat), COALESCE(min_lon, lon), COALESCE(max_lon, lon) FROM datasets
+	WHERE id NOT IN (SELECT id FROM datasets_rtree);
+`
+
+const sqliteMeteoriteColumns = `id, name, COALESCE(recclass, '') AS recclass, COALESCE(mass, 0) AS mass,
+	COALESCE(year, 0) AS year, longitude AS lon, latitude AS lat`
+
+const sqliteDatasetColumns = `id, dataset_type, name, lat, lon, geometry, value, unit, metadata,
+	timestamp, recclass, mass, year, nametype, fall`
+
+// sqliteDialect holds the SQLite metadata and row time expressions.
+// json_extract keeps the JSON type, so both metadata forms are the same.
+// Timestamps are stored as UTC text ("2006-01-02 15:04:05+00:00", see
+// import.go), so they compare correctly as strings.
+var sqliteDialect = sqlDialect{
+	text:     "json_extract(metadata, '$.%s')",
+	number:   "json_extract(metadata, '$.%s')",
+	observed: "COALESCE(timestamp, CASE WHEN year > 0 THEN printf('%04d-01-01 00:00:00+00:00', year) END)",
+}
+
+type sqliteMeteorites struct {
+	db *sqlx.DB
+}
+
+type sqliteDatasets struct {
+	db *sqlx.DB
+}
+
+// OpenSQLite opens (or creates) the SQLite file at path and ensures the schema.
+func OpenSQLite(path string) (*sqlx.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", path)
+	conn, err := sqlx.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := initSQLiteSchema(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("initialise sqlite schema: %w", err)
+	}
+	return conn, nil
+}
+
+// initSQLiteSchema creates missing tables, adds missing columns, then
+// (re)creates the indexes and triggers that depend on them.
+func initSQLiteSchema(conn *sqlx.DB) error {
+	if _, err := conn.Exec(sqliteTables); err != nil {
+		return err
+	}
+	for _, m := range sqliteMigrations {
+		var n int
+		if err := conn.Get(&n, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", m.table, m.column); err != nil {
+			return err
+		}
+		if n == 0 {
+			if _, err := conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+				return fmt.Errorf("add %s.%s: %w", m.table, m.column, err)
+			}
+		}
+	}
+	_, err := conn.Exec(sqliteIndexes)
+	return err
+}
+
+// NewSQLiteRepositories wraps a connection returned by OpenSQLite.
+func NewSQLiteRepositories(conn *sqlx.DB) (MeteoriteRepository, DatasetRepository) {
+	return &sqliteMeteorites{db: conn}, &sqliteDatasets{db: conn}
+}
+
+// addRTreeFilter restricts rows of table to those whose extent overlaps any
+// of boxes, using the table's R*Tree index. For points this is containment.
+func addRTreeFilter(w *whereBuilder, table string, boxes ...geo.BBox) {
+	var conds []string
+	var args []interface{}
+	for _, box := range boxes {
+		conds = append(conds, "(max_lat >= ? AND min_lat <= ? AND max_lon >= ? AND min_lon <= ?)")
+		args = append(args, box.MinLat, box.MaxLat, box.MinLon, box.MaxLon)
+	}
+	w.add("id IN (SELECT id FROM "+table+"_rtree WHERE "+strings.Join(conds, " OR ")+")", args...)
+}
+
+// addSQLiteSpatial appends R*Tree prefilters for every spatial member of s and
+// reports whether rows still need the exact check in Go (filterSpatial, or
+// SpatialFilter.matchesShape for datasets).
+func addSQLiteSpatial(w *whereBuilder, table string, s SpatialFilter) bool {
+	if s.Near != nil {
+		addRTreeFilter(w, table, geo.RadiusBBox(s.Near.Lat, s.Near.Lon, s.Near.Radius))
+	}
+	if s.BBox != nil {
+		addRTreeFilter(w, table, s.BBox.Split()...)
+	}
+	if s.Within != nil {
+		addRTreeFilter(w, table, s.Within.Bounds())
+	}
+	return s.Near != nil || s.Within != nil
+}
+
+// filterSpatial keeps rows matching s, in their original order.
+func filterSpatial[T any](rows []T, s SpatialFilter, point func(T) (float64, float64)) []T {
+	kept := rows[:0]
+	for _, row := range rows {
+		if s.matches(point(row)) {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
+
+func (r *sqliteMeteorites) List(ctx context.Context, f MeteoriteFilter) ([]models.Meteorite, error) {
+	w := meteoriteConditions(f)
+	postFilter := addSQLiteSpatial(w, "locations", f.SpatialFilter)
+	query := "SELECT " + sqliteMeteoriteColumns + " FROM locations" + w.clause() + meteoriteOrder
+	if !postFilter {
+		query += w.page(f.Limit, f.Offset, "-1")
+	}
+
+	meteorites := make([]models.Meteorite, 0)
+	if err := r.db.SelectContext(ctx, &meteorites, query, w.args...); err != nil {
+		return nil, err
+	}
+	if postFilter {
+		meteorites = filterSpatial(meteorites, f.SpatialFilter, func(m models.Meteorite) (float64, float64) { return m.Lat, m.Lon })
+		meteorites = paginate(meteorites, f.Limit, f.Offset)
+	}
+	return meteorites, nil
+}
+
+func (r *sqliteMeteorites) Largest(ctx context.Context, limit int) ([]models.Meteorite, error) {
+	w := &whereBuilder{}
+	query := "SELECT " + sqliteMeteoriteColumns + " FROM locations ORDER BY mass DESC" + w.page(limit, 0, "-1")
+
+	meteorites := make([]models.Meteorite, 0)
+	if err := r.db.SelectContext(ctx, &meteorites, query, w.args...); err != nil {
+		return nil, err
+	}
+	return meteorites, nil
+}
+
+func (r *sqliteDatasets) List(ctx context.Context, f DatasetFilter) ([]models.Dataset, error) {
+	w := datasetConditions(f, sqliteDialect)
+	postFilter := addSQLiteSpatial(w, "datasets", f.SpatialFilter)
+	if !postFilter && f.BBox != nil {
+		// A shape's extent can overlap the viewport without the shape doing so
+		var shapes bool
+		query := "SELECT EXISTS (SELECT 1 FROM datasets" + w.clause() + " AND geometry IS NOT NULL)"
+		if err := r.db.GetContext(ctx, &shapes, query, w.args...); err != nil {
+			return nil, err
+		}
+		postFilter = shapes
+	}
+	query := "SELECT " + f.selectValue(sqliteDatasetColumns, sqliteDialect) + " FROM datasets" + w.clause() +
+		f.order(sqliteDialect)
+	if !postFilter {
+		query += w.page(f.Limit, f.Offset, "-1")
+	}
+
+	datasets := make([]models.Dataset, 0)
+	if err := r.db.SelectContext(ctx, &datasets, query, w.args...); err != nil {
+		return nil, err
+	}
+	if postFilter {
+		kept := datasets[:0]
+		for _, d := range datasets {
+			g, err := datasetGeometry(d)
+			if err != nil {
+				return nil, err
+			}
+			if f.matchesShape(g, d.Lat, d.Lon) {
+				kept = append(kept, d)
+			}
+		}
+		datasets = paginate(kept, f.Limit, f.Offset)
+	}
+	return datasets, nil
+}
+
+// datasetGeometry decodes the stored shape of d, or returns nil for points.
+func datasetGeometry(d models.Dataset) (*geo.Geometry, error) {
+	if !d.Geometry.Valid {
+		return nil, nil
+	}
+	g, err := geo.ParseGeometry([]byte(d.Geometry.String))
+	if err != nil {
+		return nil, fmt.Errorf("dataset %d geometry: %w", d.ID, err)
+	}
+	return g, nil
+}
+
+// nearestStartRadius is the first search radius in metres for Nearest; it
+// grows fourfold until enough rows are found or the whole globe is covered.
+const nearestStartRadius = 10000.0
+
+func (r *sqliteDatasets) Nearest(ctx context.Context, f DatasetFilter, lat, lon float64, k int) ([]Neighbor, error) {
+	neighbors := make([]Neighbor, 0)
+	if k <= 0 {
+		return neighbors, nil
+	}
+
+	halfCircumference := math.Pi * geo.EarthRadius
+	q := f
+	q.After, q.Limit, q.Offset = nil, 0, 0
+	for radius := nearestStartRadius; ; radius *= 4 {
+		q.Near = &Proximity{Lat: lat, Lon: lon, Radius: min(radius, halfCircumference)}
+		rows, err := r.List(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) < k && radius < halfCircumference {
+			continue
+		}
+		for _, d := range rows {
+			g, err := datasetGeometry(d)
+			if err != nil {
+				return nil, err
+			}
+			distance := geo.Haversine(lat, lon, d.Lat, d.Lon)
+			if g != nil {
+				distance = g.Distance(lat, lon)
+			}
+			neighbors = append(neighbors, Neighbor{Dataset: d, Distance: distance})
+		}
+		break
+	}
+
+	slices.SortFunc(neighbors, func(a, b Neighbor) int {
+		if c := cmp.Compare(a.Distance, b.Distance); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return paginate(neighbors, k, 0), nil
+}
+
+func (r *sqliteDatasets) Containing(ctx context.Context, types []string, lat, lon float64) ([]models.Dataset, error) {
+	w := &whereBuilder{}
+	addTypes(w, types)
+	w.add("geometry IS NOT NULL")
+	addRTreeFilter(w, "datasets", geo.BBox{MinLon: lon, MinLat: lat, MaxLon: lon, MaxLat: lat})
+	query := "SELECT " + sqliteDatasetColumns + " FROM datasets" + w.clause() + containingOrder
+
+	var rows []models.Dataset
+	if err := r.db.SelectContext(ctx, &rows, query, w.args...); err != nil {
+		return nil, err
+	}
+	datasets := make([]models.Dataset, 0, len(rows))
+	for _, d := range rows {
+		g, err := datasetGeometry(d)
+		if err != nil {
+			return nil, err
+		}
+		// Boundary points count, as ST_Covers on PostGIS
+		if g.Covers(lat, lon) {
+			datasets = append(datasets, d)
+		}
+	}
+	return datasets, nil
+}
+
+// joinCell is the grid cell size in degrees used to bucket left rows in Join.
+const joinCell = 1.0
+
+// joinCells returns the range of grid cells covered by b.
+func joinCells(b geo.BBox) (x0, y0, x1, y1 int) {
+	cell := func(v float64) int { return int(math.Floor(v / joinCell)) }
+	return cell(b.MinLon), cell(b.MinLat), cell(b.MaxLon), cell(b.MaxLat)
+}
+
+// rowShape returns the stored shape of d, or its point.
+func rowShape(d models.Dataset) (*geo.Geometry, error) {
+	g, err := datasetGeometry(d)
+	if g == nil && err == nil {
+		g = geo.NewPoint(d.Lat, d.Lon)
+	}
+	return g, err
+}
+
+// Join loads the right rows once, buckets them on a grid of joinCell degrees
+// and tests each left row against the right rows in the cells it covers.
+// Only the pairs of the requested page are kept.
+func (r *sqliteDatasets) Join(ctx context.Context, j JoinRequest) (*JoinResult, error) {
+	result := newJoinResult()
+	rights, err := r.List(ctx, DatasetFilter{Type: j.Right, Located: true})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(rights)
+	shapes := make([]*geo.Geometry, len(rights))
+	grid := make(map[[2]int][]int)
+	for i, d := range rights {
+		if shapes[i], err = rowShape(d); err != nil {
+			return nil, err
+		}
+		if j.Predicate == JoinWithin && !shapes[i].IsArea() {
+			continue
+		}
+		x0, y0, x1, y1 := joinCells(shapes[i].Bounds())
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				grid[[2]int{x, y}] = append(grid[[2]int{x, y}], i)
+			}
+		}
+	}
+
+	left := j.Left
+	left.After, left.Limit, left.Offset, left.Located = nil, 0, 0, true
+	lefts, err := r.List(ctx, left)
+	if err != nil {
+		return nil, err
+	}
+	// seen holds the last left row (1-based) each right row was tested against
+	seen := make([]int, len(rights))
+	matched := make(map[int]bool)
+	for li, d := range lefts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		shape, err := rowShape(d)
+		if err != nil {
+			return nil, err
+		}
+		extent := shape.Bounds()
+		if j.Predicate == JoinDWithin {
+			extent = extent.Buffer(j.Distance)
+		}
+		var pairs []JoinPair
+		x0, y0, x1, y1 := joinCells(extent)
+		for x := x0; x <= x1; x++ {
+			for y := y0; y <= y1; y++ {
+				for _, i := range grid[[2]int{x, y}] {
+					if seen[i] == li+1 || rights[i].ID == d.ID {
+						continue
+					}
+					seen[i] = li + 1
+					pair := JoinPair{LeftID: d.ID, RightID: rights[i].ID}
+					if j.Predicate == JoinWithin {
+						if !shape.Within(shapes[i]) {
+							continue
+						}
+					} else if pair.Distance = shape.DistanceTo(shapes[i]); pair.Distance > j.Distance {
+						continue
+					}
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		result.PairCount += len(pairs)
+		if j.Mode == JoinCount {
+			for _, p := range pairs {
+				result.Count[p.RightID]++
+			}
+			continue
+		}
+		n := result.Total
+		result.Total++
+		if n < j.Left.Offset || (j.Left.Limit > 0 && n >= j.Left.Offset+j.Left.Limit) {
+			continue
+		}
+		if len(result.Pairs)+len(pairs) > MaxJoinPairs {
+			return nil, ErrJoinTooLarge
+		}
+		result.Left = append(result.Left, d)
+		result.Pairs = append(result.Pairs, pairs...)
+		for _, p := range pairs {
+			matched[p.RightID] = true
+		}
+	}
+
+	if j.Mode == JoinCount {
+		ids := make([]int, len(rights))
+		for i, d := range rights {
+			ids[i] = d.ID
+		}
+		result.Total = len(rights)
+		result.Right = orderByIDs(rights, countPage(ids, result.Count, j.Left.Limit, j.Left.Offset))
+		return result, nil
+	}
+	for _, d := range rights {
+		if matched[d.ID] {
+			result.Right = append(result.Right, d)
+		}
+	}
+	sortPairs(result.Pairs)
+	return result, nil
+}
+
+func (r *sqliteDatasets) Types(ctx context.Context) ([]DatasetTypeSummary, error) {
+	query := `
+		SELECT
+			dataset_type,
+			COUNT(*) as count,
+			MIN(value) as min_value,
+			MAX(value) as max_value,
+			MIN(timestamp) as min_date,
+			MAX(timestamp) as max_date
+		FROM datasets
+		GROUP BY dataset_type
+	`
+	var results []DatasetTypeSummary
+	if err := r.db.SelectContext(ctx, &results, query); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *sqliteDatasets) Stats(ctx context.Context, s StatsRequest) (*DatasetStats, error) {
+	// SQLite has no STDDEV or percentile_cont, so the matching rows are
+	// summarised in Go
+	f := s.Filter
+	f.Limit, f.Offset, f.After, f.Sort = 0, 0, nil, ""
+	rows, err := r.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return summarizeRows(rows, s)
+}
+
+func (r *sqliteDatasets) Timeseries(ctx context.Context, t TimeseriesRequest) ([]TimeBucket, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	// SQLite has no date_trunc, so the matching rows are bucketed in Go
+	f := t.Filter
+	f.Limit, f.Offset, f.After, f.Sort = 0, 0, nil, ""
+	rows, err := r.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return bucketRows(rows, t)
+}
+
+func (r *sqliteDatasets) Tile(ctx context.Context, t TileRequest) ([]byte, error) {
+	box := geo.TileBounds(t.Z, t.X, t.Y, float64(TileBuffer)/TileExtent)
+	rows, err := r.List(ctx, DatasetFilter{Type: t.Type, SpatialFilter: SpatialFilter{BBox: &box}})
+	if err != nil {
+		return nil, err
+	}
+
+	// Highest value first so it wins its grid cell, matching the PostGIS ordering
+	slices.SortStableFunc(rows, func(a, b models.Dataset) int {
+		if a.Value.Valid != b.Value.Valid {
+			if a.Value.Valid {
+				return -1
+			}
+			return 1
+		}
+		if c := cmp.Compare(b.Value.Float64, a.Value.Float64); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	fields := t.fields()
+	grid := tileGrid(t.Z)
+	layer := mvt.NewLayer(t.Type, TileExtent)
+	layer.Buffer = TileBuffer
+	layer.Tolerance = float64(grid)
+	seen := make(map[[2]int]bool)
+	for _, d := range rows {
+		props := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			props[f] = datasetTileAttribute(d, f)
+		}
+		g, err := datasetGeometry(d)
+		if err != nil {
+			return nil, err
+		}
+		if g != nil {
+			// Shapes are simplified rather than thinned; ones that collapse to
+			// nothing are skipped
+			if err := addTileShape(layer, t, d.ID, g, props); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		x, y := geo.TilePoint(t.Z, t.X, t.Y, TileExtent, d.Lat, d.Lon)
+		if x < -TileBuffer || x >= TileExtent+TileBuffer || y < -TileBuffer || y >= TileExtent+TileBuffer {
+			continue
+		}
+		cell := [2]int{floorDiv(x, grid), floorDiv(y, grid)}
+		if seen[cell] {
+			continue
+		}
+		seen[cell] = true
+
+		if err := layer.AddPoint(uint64(d.ID), x, y, props); err != nil {
+			return nil, err
+		}
+	}
+	return mvt.Encode(layer), nil
+}
+
+// addTileShape projects a line or polygon shape into tile t and adds it to
+// the layer. Multi-point shapes are added as their representative point.
+func addTileShape(layer *mvt.Layer, t TileRequest, id int, g *geo.Geometry, props map[string]interface{}) error {
+	project := func(pts []geo.Point) mvt.Path {
+		path := make(mvt.Path, len(pts))
+		for i, pt := range pts {
+			x, y := geo.TileCoords(t.Z, t.X, t.Y, TileExtent, pt.Lat, pt.Lon)
+			path[i] = [2]float64{x, y}
+		}
+		return path
+	}
+	var err error
+	switch {
+	case len(g.Lines) > 0:
+		lines := make([]mvt.Path, len(g.Lines))
+		for i, line := range g.Lines {
+			lines[i] = project(line)
+		}
+		_, err = layer.AddLineString(uint64(id), lines, props)
+	case len(g.Polygons) > 0:
+		polygons := make([][]mvt.Path, len(g.Polygons))
+		for i, p := range g.Polygons {
+			for _, ring := range p {
+				polygons[i] = append(polygons[i], project(ring))
+			}
+		}
+		_, err = layer.AddPolygon(uint64(id), polygons, props)
+	default:
+		lat, lon := g.Representative()
+		x, y := geo.TilePoint(t.Z, t.X, t.Y, TileExtent, lat, lon)
+		err = layer.AddPoint(uint64(id), x, y, props)
+	}
+	return err
+}
+
+// floorDiv divides rounding towards negative infinity, so buffer points left
+// of or above the tile get their own cells.
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
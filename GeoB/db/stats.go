@@ -0,0 +1,230 @@
+// stats.go
+//
+// Value statistics of dataset rows, shared by the storage backends
+// Compliance Level: Moderate
+//
+// - Every aggregate is nil when no matching row has a value, so empty or
+//   value-less selections never fail to scan
+// - stddev is the sample standard deviation (STDDEV_SAMP); nil below two values
+// - Percentiles interpolate linearly between the closest ranks, as
+//   percentile_cont
+// - The histogram has Bins equal-width bins from min to max; bin i covers
+//   [min+i*w, min+(i+1)*w) and the last bin includes max, as width_bucket
+// - PostGIS aggregates in SQL; SQLite summarises the filtered rows in Go
+//   (summarizeRows) with the same rules
+//
+// NOTE: The value is the selected climate period when Filter.Period is set
+
+package db
+
+import (
+	"GeoGO/geo"
+	"GeoGO/models"
+	"math"
+	"slices"
+	"strings"
+)
+
+// StatsRequest describes the statistics of the rows matching Filter.
+type StatsRequest struct {
+	// Filter selects the rows; After, Limit, Offset and Sort are ignored
+	Filter      DatasetFilter
+	Percentiles []float64 // in [0, 100]
+	Bins        int       // histogram bins; no histogram when <= 0
+}
+
+// DatasetStats is the aggregate behind /datasets/stats/:type.
+type DatasetStats struct {
+	TotalCount  int            `json:"total_count"`
+	ValueCount  int            `json:"value_count"` // rows with a value
+	AvgValue    *float64       `json:"avg_value"`
+	MinValue    *float64       `json:"min_value"`
+	MaxValue    *float64       `json:"max_value"`
+	StdDev      *float64       `json:"stddev"`
+	Percentiles []Percentile   `json:"percentiles"`
+	Histogram   []HistogramBin `json:"histogram"`
+	Units       []UnitStats    `json:"units"` // by unit, a nil unit last
+	BBox        *geo.BBox      `json:"bbox"`  // extent of the rows; nil when none match
+}
+
+// Percentile is the value below which P percent of the values fall.
+type Percentile struct {
+	P     float64  `json:"p"`
+	Value *float64 `json:"value"`
+}
+
+// HistogramBin counts the values in [Min, Max).
+type HistogramBin struct {
+	Min   float64 `db:"min" json:"min"`
+	Max   float64 `db:"max" json:"max"`
+	Count int     `db:"count" json:"count"`
+}
+
+// UnitStats summarises the rows of one unit.
+type UnitStats struct {
+	Unit       *string  `db:"unit" json:"unit"`
+	Count      int      `db:"count" json:"count"`
+	ValueCount int      `db:"value_count" json:"value_count"`
+	AvgValue   *float64 `db:"avg_value" json:"avg_value"`
+	MinValue   *float64 `db:"min_value" json:"min_value"`
+	MaxValue   *float64 `db:"max_value" json:"max_value"`
+}
+
+// histogramBins returns the empty bins between lo and hi, or a single bin
+// when every value is the same.
+func histogramBins(lo, hi float64, n int) []HistogramBin {
+	if lo == hi {
+		return []HistogramBin{{Min: lo, Max: hi}}
+	}
+	bins := make([]HistogramBin, n)
+	width := (hi - lo) / float64(n)
+	for i := range bins {
+		bins[i] = HistogramBin{Min: lo + float64(i)*width, Max: lo + float64(i+1)*width}
+	}
+	bins[n-1].Max = hi
+	return bins
+}
+
+// binOf returns the histogram bin of v, as width_bucket clamped to the last bin.
+func binOf(v, lo, hi float64, n int) int {
+	if lo == hi {
+		return 0
+	}
+	return min(int(float64(n)*(v-lo)/(hi-lo)), n-1)
+}
+
+// percentileOf interpolates the p-th percentile of ascending values.
+func percentileOf(sorted []float64, p float64) float64 {
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	if lo+1 >= len(sorted) {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (sorted[lo+1]-sorted[lo])*(pos-float64(lo))
+}
+
+// valueSummary accumulates count, mean, min, max and variance (Welford).
+type valueSummary struct {
+	n        int
+	mean, m2 float64
+	lo, hi   float64
+}
+
+func (s *valueSummary) add(v float64) {
+	s.n++
+	if s.n == 1 {
+		s.lo, s.hi = v, v
+	}
+	s.lo, s.hi = min(s.lo, v), max(s.hi, v)
+	delta := v - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (v - s.mean)
+}
+
+// result returns the count, mean, min and max; the pointers are nil without values.
+func (s valueSummary) result() (n int, avg, lo, hi *float64) {
+	if s.n == 0 {
+		return 0, nil, nil, nil
+	}
+	mean, low, high := s.mean, s.lo, s.hi
+	return s.n, &mean, &low, &high
+}
+
+// stddev returns the sample standard deviation, or nil below two values.
+func (s valueSummary) stddev() *float64 {
+	if s.n < 2 {
+		return nil
+	}
+	sd := math.Sqrt(s.m2 / float64(s.n-1))
+	return &sd
+}
+
+// summarizeRows computes the statistics of rows in Go with the rules of the
+// SQL backends.
+func summarizeRows(rows []models.Dataset, s StatsRequest) (*DatasetStats, error) {
+	stats := &DatasetStats{TotalCount: len(rows), Percentiles: []Percentile{}, Histogram: []HistogramBin{}, Units: []UnitStats{}}
+	type unitAcc struct {
+		stats  UnitStats
+		values valueSummary
+	}
+	var all valueSummary
+	units := make(map[string]*unitAcc)
+	var values []float64
+	for _, d := range rows {
+		key := "\x00" // rows without a unit
+		if d.Unit.Valid {
+			key = d.Unit.String
+		}
+		u := units[key]
+		if u == nil {
+			u = &unitAcc{}
+			if d.Unit.Valid {
+				unit := d.Unit.String
+				u.stats.Unit = &unit
+			}
+			units[key] = u
+		}
+		u.stats.Count++
+		if d.Value.Valid {
+			all.add(d.Value.Float64)
+			u.values.add(d.Value.Float64)
+			values = append(values, d.Value.Float64)
+		}
+
+		b := geo.BBox{MinLon: d.Lon, MinLat: d.Lat, MaxLon: d.Lon, MaxLat: d.Lat}
+		g, err := datasetGeometry(d)
+		if err != nil {
+			return nil, err
+		}
+		if g != nil {
+			b = g.Bounds()
+		}
+		if stats.BBox == nil {
+			stats.BBox = &b
+			continue
+		}
+		stats.BBox.MinLon, stats.BBox.MinLat = min(stats.BBox.MinLon, b.MinLon), min(stats.BBox.MinLat, b.MinLat)
+		stats.BBox.MaxLon, stats.BBox.MaxLat = max(stats.BBox.MaxLon, b.MaxLon), max(stats.BBox.MaxLat, b.MaxLat)
+	}
+
+	stats.ValueCount, stats.AvgValue, stats.MinValue, stats.MaxValue = all.result()
+	stats.StdDev = all.stddev()
+	for _, u := range units {
+		u.stats.ValueCount, u.stats.AvgValue, u.stats.MinValue, u.stats.MaxValue = u.values.result()
+		stats.Units = append(stats.Units, u.stats)
+	}
+	sortUnits(stats.Units)
+
+	slices.Sort(values)
+	for _, p := range s.Percentiles {
+		pc := Percentile{P: p}
+		if len(values) > 0 {
+			v := percentileOf(values, p)
+			pc.Value = &v
+		}
+		stats.Percentiles = append(stats.Percentiles, pc)
+	}
+	if s.Bins > 0 && len(values) > 0 {
+		stats.Histogram = histogramBins(all.lo, all.hi, s.Bins)
+		for _, v := range values {
+			stats.Histogram[binOf(v, all.lo, all.hi, len(stats.Histogram))].Count++
+		}
+	}
+	return stats, nil
+}
+
+// sortUnits orders unit breakdowns by unit with the rows without a unit last,
+// as ORDER BY unit NULLS LAST.
+func sortUnits(units []UnitStats) {
+	slices.SortFunc(units, func(a, b UnitStats) int {
+		switch {
+		case a.Unit == nil && b.Unit == nil:
+			return 0
+		case a.Unit == nil:
+			return 1
+		case b.Unit == nil:
+			return -1
+		}
+		return strings.Compare(*a.Unit, *b.Unit)
+	})
+}
@@ -0,0 +1,144 @@
+// tile.go
+//
+// Vector tile rules shared by the storage backends
+// Compliance Level: High
+//
+// - Tiles hold one layer named after the dataset type, extent 4096, 64-unit buffer
+// - Feature ids are dataset ids; attributes come from a fixed whitelist
+// - Per-zoom simplification keeps at most one point per grid cell, preferring
+//   the highest value, so low-zoom tiles stay small
+// - Lines and polygons are simplified (Douglas-Peucker) with the grid size as
+//   tolerance, then clipped to the buffered tile; shapes that collapse below
+//   one tile unit are dropped
+//
+// Simplification Grid (tile units, 16 units = 1 screen pixel on a 256px tile):
+// - z0-9:   16
+// - z10-13: 4
+// - z14+:   1 (only exact duplicates collapse; shapes lose sub-pixel detail)
+
+package db
+
+import (
+	"GeoGO/models"
+	"math"
+	"slices"
+)
+
+// Tile geometry parameters, matching the ST_AsMVTGeom defaults.
+const (
+	TileExtent = 4096
+	TileBuffer = 64
+)
+
+// TileRequest selects one vector tile of a dataset type.
+type TileRequest struct {
+	Type    string
+	Z, X, Y int
+	// Fields lists the attributes to include; nil means DefaultTileFields(Z).
+	Fields []string
+}
+
+// tileFieldColumns maps selectable attributes to PostGIS column expressions.
+// ST_AsMVT only accepts scalar types, so timestamps are sent as text.
+var tileFieldColumns = map[string]string{
+	"name":      "name",
+	"value":     "value",
+	"unit":      "unit",
+	"timestamp": "timestamp::text",
+	"recclass":  "recclass",
+	"mass":      "mass",
+	"year":      "year",
+	"nametype":  "nametype",
+	"fall":      "fall",
+}
+
+// IsTileField reports whether name may be requested as a tile attribute.
+func IsTileField(name string) bool {
+	_, ok := tileFieldColumns[name]
+	return ok
+}
+
+// DefaultTileFields returns the attributes sent when none are requested:
+// only the value at overview zooms, plus labels once features are distinguishable.
+func DefaultTileFields(z int) []string {
+	if z < 10 {
+		return []string{"value"}
+	}
+	return []string{"name", "value", "unit"}
+}
+
+// tileGrid returns the thinning cell size in tile units for zoom z.
+func tileGrid(z int) int {
+	switch {
+	case z < 10:
+		return 16
+	case z < 14:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// webMercatorWidth is the width of the EPSG:3857 world in metres.
+const webMercatorWidth = 2 * math.Pi * 6378137
+
+// tileTolerance returns the shape simplification tolerance for zoom z in
+// EPSG:3857 metres: one grid cell of tileGrid.
+func tileTolerance(z int) float64 {
+	return float64(tileGrid(z)) * webMercatorWidth / float64(int(1)<<z) / TileExtent
+}
+
+// fields resolves the requested attributes, dropping duplicates.
+func (t TileRequest) fields() []string {
+	if t.Fields == nil {
+		return DefaultTileFields(t.Z)
+	}
+	var out []string
+	for _, f := range t.Fields {
+		if !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// datasetTileAttribute returns the attribute value of d, or nil when NULL.
+func datasetTileAttribute(d models.Dataset, field string) interface{} {
+	switch field {
+	case "name":
+		return d.Name
+	case "value":
+		if d.Value.Valid {
+			return d.Value.Float64
+		}
+	case "unit":
+		if d.Unit.Valid {
+			return d.Unit.String
+		}
+	case "timestamp":
+		if d.Timestamp != nil {
+			return *d.Timestamp
+		}
+	case "recclass":
+		if d.Recclass.Valid {
+			return d.Recclass.String
+		}
+	case "mass":
+		if d.Mass.Valid {
+			return d.Mass.Float64
+		}
+	case "year":
+		if d.Year.Valid {
+			return d.Year.Int64
+		}
+	case "nametype":
+		if d.Nametype.Valid {
+			return d.Nametype.String
+		}
+	case "fall":
+		if d.Fall.Valid {
+			return d.Fall.String
+		}
+	}
+	return nil
+}
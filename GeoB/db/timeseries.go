@@ -0,0 +1,212 @@
+// timeseries.go
+//
+// Time aggregation of dataset rows, shared by the storage backends
+// Compliance Level: Moderate
+//
+// - A row's time is its timestamp, else January 1 of its year (meteorites
+//   only carry a year); rows with neither are left out
+// - Buckets are UTC calendar units: decade, year, month, day or hour
+// - PostGIS groups with date_trunc; SQLite buckets the filtered rows in Go
+//   with the same rules (bucketRows)
+// - Empty buckets are not returned
+//
+// NOTE: Decades start on years divisible by 10 (1990-1999), as date_trunc
+
+package db
+
+import (
+	"GeoGO/models"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// TimeInterval is the bucket size of a time series.
+type TimeInterval string
+
+const (
+	IntervalDecade TimeInterval = "decade"
+	IntervalYear   TimeInterval = "year"
+	IntervalMonth  TimeInterval = "month"
+	IntervalDay    TimeInterval = "day"
+	IntervalHour   TimeInterval = "hour"
+)
+
+// TimeAgg is the aggregate computed over each bucket.
+type TimeAgg string
+
+const (
+	AggCount TimeAgg = "count"
+	AggAvg   TimeAgg = "avg"
+	AggMin   TimeAgg = "min"
+	AggMax   TimeAgg = "max"
+	AggSum   TimeAgg = "sum"
+)
+
+// aggFunctions maps aggregates to SQL; count needs no field.
+var aggFunctions = map[TimeAgg]string{
+	AggCount: "",
+	AggAvg:   "AVG",
+	AggMin:   "MIN",
+	AggMax:   "MAX",
+	AggSum:   "SUM",
+}
+
+// TimeseriesFields are the fields a time series can aggregate: the value and
+// mass columns and numeric metadata keys.
+var TimeseriesFields = []string{"value", "mass", "gust_speed"}
+
+// TimeseriesRequest describes a time series over the rows matching Filter.
+type TimeseriesRequest struct {
+	// Filter selects the rows; After, Limit, Offset and Sort are ignored
+	Filter   DatasetFilter
+	Interval TimeInterval
+	Agg      TimeAgg
+	Field    string // one of TimeseriesFields; ignored for AggCount
+}
+
+// TimeBucket is one bucket of a time series.
+type TimeBucket struct {
+	Start time.Time `db:"bucket"`
+	Count int       `db:"count"` // matching rows in the bucket
+	// Value is the aggregate of Field over rows that have it; nil for
+	// AggCount or when no row has the field
+	Value *float64 `db:"value"`
+}
+
+// validate rejects intervals, aggregates and fields the backends cannot render.
+func (t TimeseriesRequest) validate() error {
+	switch t.Interval {
+	case IntervalDecade, IntervalYear, IntervalMonth, IntervalDay, IntervalHour:
+	default:
+		return fmt.Errorf("unknown time interval %q", t.Interval)
+	}
+	if _, ok := aggFunctions[t.Agg]; !ok {
+		return fmt.Errorf("unknown aggregate %q", t.Agg)
+	}
+	if t.Agg != AggCount && !slices.Contains(TimeseriesFields, t.Field) {
+		return fmt.Errorf("unknown time series field %q", t.Field)
+	}
+	return nil
+}
+
+// aggregate renders the SQL aggregate of the request's field.
+func (t TimeseriesRequest) aggregate(d sqlDialect) string {
+	if t.Agg == AggCount {
+		return "NULL"
+	}
+	var field string
+	switch t.Field {
+	case "value":
+		field = t.Filter.valueColumn(d)
+	case "mass":
+		field = "mass"
+	default:
+		field = fmt.Sprintf(d.number, t.Field)
+	}
+	return aggFunctions[t.Agg] + "(" + field + ")"
+}
+
+// Truncate returns the start of the bucket holding t (UTC).
+func (i TimeInterval) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch i {
+	case IntervalDecade:
+		y := t.Year()
+		return time.Date(y-((y%10)+10)%10, 1, 1, 0, 0, 0, 0, time.UTC)
+	case IntervalYear:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case IntervalDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// rowTime returns the time of a row, as sqlDialect.observed.
+func rowTime(d models.Dataset) (time.Time, bool) {
+	if d.Timestamp != nil {
+		return *d.Timestamp, true
+	}
+	if d.Year.Valid && d.Year.Int64 > 0 {
+		return time.Date(int(d.Year.Int64), 1, 1, 0, 0, 0, 0, time.UTC), true
+	}
+	return time.Time{}, false
+}
+
+// rowField returns the request's field of a row.
+func (t TimeseriesRequest) rowField(d models.Dataset) (float64, bool, error) {
+	switch t.Field {
+	case "value":
+		return d.Value.Float64, d.Value.Valid, nil
+	case "mass":
+		return d.Mass.Float64, d.Mass.Valid, nil
+	}
+	if !d.Metadata.Valid {
+		return 0, false, nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(d.Metadata.String), &m); err != nil {
+		return 0, false, fmt.Errorf("dataset %d metadata: %w", d.ID, err)
+	}
+	v, ok := m[t.Field].(float64)
+	return v, ok, nil
+}
+
+// bucketRows aggregates rows in Go with the rules of the SQL backends.
+func bucketRows(rows []models.Dataset, t TimeseriesRequest) ([]TimeBucket, error) {
+	type acc struct {
+		bucket TimeBucket
+		n      int
+		sum    float64
+	}
+	buckets := make(map[time.Time]*acc)
+	for _, d := range rows {
+		at, ok := rowTime(d)
+		if !ok {
+			continue
+		}
+		start := t.Interval.Truncate(at)
+		a := buckets[start]
+		if a == nil {
+			a = &acc{bucket: TimeBucket{Start: start}}
+			buckets[start] = a
+		}
+		a.bucket.Count++
+		if t.Agg == AggCount {
+			continue
+		}
+		v, ok, err := t.rowField(d)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		a.n++
+		a.sum += v
+		switch {
+		case a.bucket.Value == nil:
+			a.bucket.Value = &v
+		case t.Agg == AggMin && v < *a.bucket.Value, t.Agg == AggMax && v > *a.bucket.Value:
+			*a.bucket.Value = v
+		}
+	}
+
+	out := make([]TimeBucket, 0, len(buckets))
+	for _, a := range buckets {
+		if a.n > 0 && (t.Agg == AggAvg || t.Agg == AggSum) {
+			v := a.sum
+			if t.Agg == AggAvg {
+				v /= float64(a.n)
+			}
+			a.bucket.Value = &v
+		}
+		out = append(out, a.bucket)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out, nil
+}
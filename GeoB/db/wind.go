@@ -0,0 +1,113 @@
+// wind.go
+//
+// Wind observations stored in the datasets table
+// Compliance Level: High
+//
+// - Each wind row is one observation of one location: value is the average
+//   speed (m/s), timestamp the observation time, and gust_speed, wind_direction
+//   and wind_direction_cardinal live in metadata (see ingest/sources.go mapWind)
+// - Rows are named "wind_" + location_description, so a location is selected
+//   by name; the JSON accessor (sqlDialect) is the only difference between
+//   the backends
+//
+// NOTE: Time bounds are half-open [from, to)
+
+package db
+
+import (
+	"GeoGO/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// windNamePrefix prefixes the location in wind row names.
+const windNamePrefix = "wind_"
+
+type windRepository struct {
+	db      *sqlx.DB
+	dialect sqlDialect
+}
+
+// NewWindRepository returns the wind repository for either backend.
+func NewWindRepository(conn *sqlx.DB) WindRepository {
+	return &windRepository{db: conn, dialect: driverDialects[conn.DriverName()]}
+}
+
+// WindStation summarises the observations of one wind location.
+type WindStation struct {
+	Location string  `db:"location"`
+	Lat      float64 `db:"lat"`
+	Lon      float64 `db:"lon"`
+	Count    int     `db:"count"`
+	First    *string `db:"first_observation"`
+	Last     *string `db:"last_observation"`
+}
+
+func (r *windRepository) stations(ctx context.Context, location *string) ([]WindStation, error) {
+	w := &whereBuilder{}
+	w.add("dataset_type = 'wind'")
+	if location != nil {
+		w.add("name = ?", windNamePrefix+*location)
+	}
+	query := `SELECT name AS location, MIN(lat) AS lat, MIN(lon) AS lon, COUNT(*) AS count,
+		MIN(timestamp) AS first_observation, MAX(timestamp) AS last_observation
+		FROM datasets` + w.clause() + " GROUP BY name ORDER BY name"
+	stations := make([]WindStation, 0)
+	if err := r.db.SelectContext(ctx, &stations, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	for i := range stations {
+		stations[i].Location = strings.TrimPrefix(stations[i].Location, windNamePrefix)
+	}
+	return stations, nil
+}
+
+func (r *windRepository) Stations(ctx context.Context) ([]WindStation, error) {
+	return r.stations(ctx, nil)
+}
+
+func (r *windRepository) Station(ctx context.Context, location string) (*WindStation, error) {
+	stations, err := r.stations(ctx, &location)
+	if err != nil || len(stations) == 0 {
+		return nil, err
+	}
+	return &stations[0], nil
+}
+
+func (r *windRepository) Observations(ctx context.Context, location string, from, to *time.Time) ([]models.WindData, error) {
+	w := &whereBuilder{}
+	w.add("dataset_type = 'wind'")
+	w.add("name = ?", windNamePrefix+location)
+	w.add("timestamp IS NOT NULL")
+	if from != nil {
+		w.add("timestamp >= ?", from.UTC())
+	}
+	if to != nil {
+		w.add("timestamp < ?", to.UTC())
+	}
+	number := func(key string) string { return fmt.Sprintf(r.dialect.number, key) }
+	query := "SELECT timestamp AS date_time, lat, lon, COALESCE(value, 0) AS average_wind_speed, " +
+		number("gust_speed") + " AS gust_speed, " + number("wind_direction") + " AS wind_direction, " +
+		fmt.Sprintf(r.dialect.text, "wind_direction_cardinal") + " AS wind_direction_cardinal" +
+		" FROM datasets" + w.clause() + " ORDER BY timestamp, id"
+
+	var rows []struct {
+		models.WindData
+		Cardinal sql.NullString `db:"wind_direction_cardinal"`
+	}
+	if err := r.db.SelectContext(ctx, &rows, r.db.Rebind(query), w.args...); err != nil {
+		return nil, err
+	}
+	obs := make([]models.WindData, len(rows))
+	for i, row := range rows {
+		obs[i] = row.WindData
+		obs[i].LocationDescription = location
+		obs[i].WindDirectionCardinal = row.Cardinal.String
+	}
+	return obs, nil
+}
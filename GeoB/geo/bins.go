@@ -0,0 +1,248 @@
+// bins.go
+//
+// Spatial binning of points into hexagon or geohash cells for density maps
+// Compliance Level: Moderate
+//
+// - HexGrid: pointy-top hexagons on the Lambert cylindrical equal-area
+//   projection, so every cell covers the same ground area. Resolution r has
+//   the average cell area of H3 resolution r (4,357,449 km² at 0, a seventh
+//   of that per step), but cells are not H3 cells and ids are "res/q/r" axial
+//   coordinates
+// - GeohashGrid: standard base32 geohash cells of 1-12 characters
+// - Each bin reports its member count, value summary and cell outline
+// - Deterministic: bins are returned largest first, then by cell id
+//
+// NOTE: Hex cells do not wrap at the antimeridian; outlines are clamped to
+// ±180° and ±90°, so cells at the edges of the map are cut off
+
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Resolution bounds of the grids.
+const (
+	MaxHexResolution     = 15
+	MaxGeohashResolution = 12
+)
+
+// h3Res0Area is the average H3 cell area at resolution 0, in square metres.
+const h3Res0Area = 4357449.416078383e6
+
+// Hex outlines get a vertex every edgeStep metres, at most maxEdgeSteps per edge.
+const (
+	edgeStep     = 50000.0
+	maxEdgeSteps = 16
+)
+
+// Grid assigns points to cells.
+type Grid interface {
+	// Cell returns the id of the cell containing the point.
+	Cell(lat, lon float64) string
+	// Boundary returns the closed outline of a cell returned by Cell.
+	Boundary(cell string) Ring
+}
+
+// HexGrid is an equal-area hexagonal grid at one resolution.
+type HexGrid struct {
+	Resolution int
+	size       float64 // projected centre-to-vertex distance, metres
+}
+
+// NewHexGrid returns the hexagonal grid of resolution res (0-MaxHexResolution).
+func NewHexGrid(res int) HexGrid {
+	area := h3Res0Area / math.Pow(7, float64(res))
+	return HexGrid{Resolution: res, size: math.Sqrt(2 * area / (3 * math.Sqrt(3)))}
+}
+
+// project maps a point onto the equal-area plane, in metres.
+func project(lat, lon float64) (float64, float64) {
+	return EarthRadius * Radians(lon), EarthRadius * math.Sin(Radians(lat))
+}
+
+// unproject maps a plane position back to a clamped WGS84 point.
+func unproject(x, y float64) Point {
+	lon := math.Max(-180, math.Min(180, Degrees(x/EarthRadius)))
+	lat := Degrees(math.Asin(math.Max(-1, math.Min(1, y/EarthRadius))))
+	return Point{Lon: lon, Lat: lat}
+}
+
+func (g HexGrid) Cell(lat, lon float64) string {
+	x, y := project(lat, lon)
+	// Fractional axial coordinates, rounded through cube coordinates
+	q := (math.Sqrt(3)/3*x - y/3) / g.size
+	r := (2.0 / 3 * y) / g.size
+	s := -q - r
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+	switch {
+	case dq > dr && dq > ds:
+		rq = -rr - rs
+	case dr > ds:
+		rr = -rq - rs
+	}
+	return fmt.Sprintf("%d/%d/%d", g.Resolution, int64(rq), int64(rr))
+}
+
+func (g HexGrid) Boundary(cell string) Ring {
+	var res int
+	var q, r int64
+	if _, err := fmt.Sscanf(cell, "%d/%d/%d", &res, &q, &r); err != nil {
+		return nil
+	}
+	cx := g.size * math.Sqrt(3) * (float64(q) + float64(r)/2)
+	cy := g.size * 1.5 * float64(r)
+	// Edges are straight on the projection but curve in lon/lat, so long
+	// edges are split to follow them
+	steps := int(min(maxEdgeSteps, math.Ceil(g.size/edgeStep)))
+	vertex := func(i int) (float64, float64) {
+		angle := Radians(float64(60*i - 30))
+		return cx + g.size*math.Cos(angle), cy + g.size*math.Sin(angle)
+	}
+	ring := make(Ring, 0, 6*steps+1)
+	for i := 0; i < 6; i++ {
+		x0, y0 := vertex(i)
+		x1, y1 := vertex(i + 1)
+		for j := 0; j < steps; j++ {
+			f := float64(j) / float64(steps)
+			ring = append(ring, unproject(x0+(x1-x0)*f, y0+(y1-y0)*f))
+		}
+	}
+	return append(ring, ring[0])
+}
+
+// geohashAlphabet is the geohash base32 alphabet.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashGrid is the geohash grid of one precision (characters).
+type GeohashGrid struct {
+	Precision int
+}
+
+func (g GeohashGrid) Cell(lat, lon float64) string {
+	lats, lons := [2]float64{-90, 90}, [2]float64{-180, 180}
+	var hash strings.Builder
+	bit, ch, even := 0, 0, true
+	for hash.Len() < g.Precision {
+		rng, v := &lats, lat
+		if even {
+			rng, v = &lons, lon
+		}
+		mid := (rng[0] + rng[1]) / 2
+		ch <<= 1
+		if v >= mid {
+			ch |= 1
+			rng[0] = mid
+		} else {
+			rng[1] = mid
+		}
+		even = !even
+		if bit++; bit == 5 {
+			hash.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+// GeohashBounds returns the box covered by a geohash.
+func GeohashBounds(hash string) (BBox, error) {
+	lats, lons := [2]float64{-90, 90}, [2]float64{-180, 180}
+	even := true
+	for _, c := range hash {
+		idx := strings.IndexRune(geohashAlphabet, c)
+		if idx < 0 {
+			return BBox{}, fmt.Errorf("invalid geohash character %q", c)
+		}
+		for mask := 16; mask > 0; mask >>= 1 {
+			rng := &lats
+			if even {
+				rng = &lons
+			}
+			mid := (rng[0] + rng[1]) / 2
+			if idx&mask != 0 {
+				rng[0] = mid
+			} else {
+				rng[1] = mid
+			}
+			even = !even
+		}
+	}
+	return BBox{MinLon: lons[0], MinLat: lats[0], MaxLon: lons[1], MaxLat: lats[1]}, nil
+}
+
+func (g GeohashGrid) Boundary(cell string) Ring {
+	b, err := GeohashBounds(cell)
+	if err != nil {
+		return nil
+	}
+	return Ring{
+		{Lon: b.MinLon, Lat: b.MinLat}, {Lon: b.MaxLon, Lat: b.MinLat},
+		{Lon: b.MaxLon, Lat: b.MaxLat}, {Lon: b.MinLon, Lat: b.MaxLat},
+		{Lon: b.MinLon, Lat: b.MinLat},
+	}
+}
+
+// Bin aggregates the points of one grid cell.
+type Bin struct {
+	Cell     string
+	Boundary Ring
+	Count    int
+
+	// Value summary over members with a value; ValueCount may be below Count
+	ValueCount int
+	Min        float64
+	Max        float64
+	Sum        float64
+}
+
+// Avg returns the mean value, or false when no member has a value.
+func (b Bin) Avg() (float64, bool) {
+	if b.ValueCount == 0 {
+		return 0, false
+	}
+	return b.Sum / float64(b.ValueCount), true
+}
+
+// BinPoints groups points into the cells of g. Bins are returned largest
+// first, then by cell id.
+func BinPoints(points []ClusterPoint, g Grid) []Bin {
+	cells := make(map[string]*Bin)
+	for _, p := range points {
+		id := g.Cell(p.Lat, p.Lon)
+		b, ok := cells[id]
+		if !ok {
+			b = &Bin{Cell: id}
+			cells[id] = b
+		}
+		b.Count++
+		if p.Value != nil {
+			v := *p.Value
+			if b.ValueCount == 0 || v < b.Min {
+				b.Min = v
+			}
+			if b.ValueCount == 0 || v > b.Max {
+				b.Max = v
+			}
+			b.ValueCount++
+			b.Sum += v
+		}
+	}
+
+	bins := make([]Bin, 0, len(cells))
+	for _, b := range cells {
+		b.Boundary = g.Boundary(b.Cell)
+		bins = append(bins, *b)
+	}
+	sort.Slice(bins, func(i, j int) bool {
+		if bins[i].Count != bins[j].Count {
+			return bins[i].Count > bins[j].Count
+		}
+		return bins[i].Cell < bins[j].Cell
+	})
+	return bins
+}
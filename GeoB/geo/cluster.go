@@ -0,0 +1,114 @@
+// cluster.go
+//
+// Grid-based point clustering for map zoom levels
+// Compliance Level: Moderate
+//
+// - Points are bucketed into square cells of a fixed screen size in Web Mercator
+//   pixel space, so clusters look the same size at every zoom
+// - Each cluster reports its member count, centroid, extent and a value summary
+// - Deterministic: the same input always yields the same clusters in the same order
+//
+// NOTE: Cluster centroids are the arithmetic mean of member coordinates, which
+// is adequate at cell sizes of a few dozen pixels
+
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TileSize is the pixel size of one Web Mercator tile.
+const TileSize = 256
+
+// ClusterPoint is one input point.
+type ClusterPoint struct {
+	ID    int
+	Lat   float64
+	Lon   float64
+	Value *float64
+}
+
+// Cluster aggregates the points of one grid cell.
+type Cluster struct {
+	Lat    float64
+	Lon    float64
+	Count  int
+	Bounds BBox
+	// Cell is the "zoom/x/y" key of the grid cell, unique within one zoom
+	Cell string
+	// ID is the member id when Count == 1, so clients can link single points
+	ID int
+
+	// Value summary over members with a value; ValueCount may be below Count
+	ValueCount int
+	Min        float64
+	Max        float64
+	Sum        float64
+}
+
+// Avg returns the mean value, or false when no member has a value.
+func (c Cluster) Avg() (float64, bool) {
+	if c.ValueCount == 0 {
+		return 0, false
+	}
+	return c.Sum / float64(c.ValueCount), true
+}
+
+// GridCluster groups points into cells of cellPixels screen pixels at zoom.
+// Clusters are returned largest first.
+func GridCluster(points []ClusterPoint, zoom int, cellPixels float64) []Cluster {
+	cellsPerTile := TileSize / cellPixels
+	type acc struct {
+		Cluster
+		sumLat, sumLon float64
+	}
+	cells := make(map[[2]int64]*acc)
+	var order [][2]int64
+	for _, p := range points {
+		wx, wy := mercator(zoom, p.Lat, p.Lon)
+		key := [2]int64{int64(math.Floor(wx * cellsPerTile)), int64(math.Floor(wy * cellsPerTile))}
+		a, ok := cells[key]
+		if !ok {
+			a = &acc{Cluster: Cluster{
+				Cell:   fmt.Sprintf("%d/%d/%d", zoom, key[0], key[1]),
+				ID:     p.ID,
+				Bounds: BBox{MinLon: p.Lon, MinLat: p.Lat, MaxLon: p.Lon, MaxLat: p.Lat},
+			}}
+			cells[key] = a
+			order = append(order, key)
+		}
+		a.Count++
+		a.sumLat += p.Lat
+		a.sumLon += p.Lon
+		a.Bounds.MinLon = math.Min(a.Bounds.MinLon, p.Lon)
+		a.Bounds.MinLat = math.Min(a.Bounds.MinLat, p.Lat)
+		a.Bounds.MaxLon = math.Max(a.Bounds.MaxLon, p.Lon)
+		a.Bounds.MaxLat = math.Max(a.Bounds.MaxLat, p.Lat)
+		if p.Value != nil {
+			v := *p.Value
+			if a.ValueCount == 0 || v < a.Min {
+				a.Min = v
+			}
+			if a.ValueCount == 0 || v > a.Max {
+				a.Max = v
+			}
+			a.ValueCount++
+			a.Sum += v
+		}
+	}
+
+	clusters := make([]Cluster, 0, len(order))
+	for _, key := range order {
+		a := cells[key]
+		a.Lat = a.sumLat / float64(a.Count)
+		a.Lon = a.sumLon / float64(a.Count)
+		if a.Count > 1 {
+			a.ID = 0
+		}
+		clusters = append(clusters, a.Cluster)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].Count > clusters[j].Count })
+	return clusters
+}
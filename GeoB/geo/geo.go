@@ -0,0 +1,153 @@
+// geo.go
+//
+// Geodesic helpers shared across GeoGO
+// Provides distance calculations on the WGS84 sphere approximation.
+// Compliance Level: Moderate
+//
+// - Pure functions, no I/O
+// - Distances are returned in metres
+// - Bounding boxes may cross the antimeridian (MinLon > MaxLon)
+//
+// NOTE: Uses a spherical Earth (mean radius); error is below 0.5% which is
+// acceptable for proximity search and ranking
+
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// EarthRadius is the mean Earth radius in metres.
+const EarthRadius = 6371008.8
+
+// Haversine returns the great-circle distance in metres between two points
+// given in decimal degrees.
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	rLat1 := Radians(lat1)
+	rLat2 := Radians(lat2)
+	dLat := Radians(lat2 - lat1)
+	dLon := Radians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rLat1)*math.Cos(rLat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Bearing returns the initial great-circle bearing in degrees clockwise from
+// north, in [0, 360), for travel from point 1 to point 2.
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	rLat1 := Radians(lat1)
+	rLat2 := Radians(lat2)
+	dLon := Radians(lon2 - lon1)
+
+	y := math.Sin(dLon) * math.Cos(rLat2)
+	x := math.Cos(rLat1)*math.Sin(rLat2) - math.Sin(rLat1)*math.Cos(rLat2)*math.Cos(dLon)
+	return math.Mod(Degrees(math.Atan2(y, x))+360, 360)
+}
+
+// Radians converts decimal degrees to radians.
+func Radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Degrees converts radians to decimal degrees.
+func Degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// metresPerDegree is the length of one degree of latitude in metres.
+const metresPerDegree = EarthRadius * math.Pi / 180
+
+// BBox is an axis-aligned bounding box in decimal degrees.
+type BBox struct {
+	MinLon float64 `json:"min_lon"`
+	MinLat float64 `json:"min_lat"`
+	MaxLon float64 `json:"max_lon"`
+	MaxLat float64 `json:"max_lat"`
+}
+
+// RadiusBBox returns a box guaranteed to contain every point within radius
+// metres of (lat, lon). Boxes touching a pole or the antimeridian are widened
+// to the full longitude range rather than split in two.
+func RadiusBBox(lat, lon, radius float64) BBox {
+	dLat := radius / metresPerDegree
+	box := BBox{MinLat: lat - dLat, MaxLat: lat + dLat, MinLon: -180, MaxLon: 180}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		return box
+	}
+	dLon := dLat / math.Cos(Radians(math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat))))
+	if lon-dLon >= -180 && lon+dLon <= 180 {
+		box.MinLon, box.MaxLon = lon-dLon, lon+dLon
+	}
+	return box
+}
+
+// Buffer returns a box containing every point within radius metres of a
+// non-wrapping box b, widened like RadiusBBox at the poles and antimeridian.
+func (b BBox) Buffer(radius float64) BBox {
+	out := RadiusBBox(b.MinLat, b.MinLon, radius)
+	for _, corner := range [][2]float64{{b.MinLat, b.MaxLon}, {b.MaxLat, b.MinLon}, {b.MaxLat, b.MaxLon}} {
+		c := RadiusBBox(corner[0], corner[1], radius)
+		out.MinLat, out.MaxLat = min(out.MinLat, c.MinLat), max(out.MaxLat, c.MaxLat)
+		out.MinLon, out.MaxLon = min(out.MinLon, c.MinLon), max(out.MaxLon, c.MaxLon)
+	}
+	return out
+}
+
+// Contains reports whether the point lies inside the box (edges inclusive).
+// Boxes crossing the antimeridian are handled.
+func (b BBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return lon >= b.MinLon || lon <= b.MaxLon
+	}
+	return lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// CrossesAntimeridian reports whether the box wraps past ±180°, i.e. MinLon > MaxLon.
+func (b BBox) CrossesAntimeridian() bool {
+	return b.MinLon > b.MaxLon
+}
+
+// Split returns the box as one or two non-wrapping boxes, splitting at the
+// antimeridian when needed.
+func (b BBox) Split() []BBox {
+	if !b.CrossesAntimeridian() {
+		return []BBox{b}
+	}
+	return []BBox{
+		{MinLon: b.MinLon, MinLat: b.MinLat, MaxLon: 180, MaxLat: b.MaxLat},
+		{MinLon: -180, MinLat: b.MinLat, MaxLon: b.MaxLon, MaxLat: b.MaxLat},
+	}
+}
+
+// ParseBBox parses "minLon,minLat,maxLon,maxLat". A box whose minLon exceeds
+// maxLon is taken to cross the antimeridian.
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+	var v [4]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return BBox{}, fmt.Errorf("bbox value %q is not a finite number", p)
+		}
+		v[i] = f
+	}
+	b := BBox{MinLon: v[0], MinLat: v[1], MaxLon: v[2], MaxLat: v[3]}
+	if b.MinLon < -180 || b.MaxLon > 180 || b.MinLon > 180 || b.MaxLon < -180 {
+		return BBox{}, fmt.Errorf("bbox longitudes must be within ±180")
+	}
+	if b.MinLat < -90 || b.MaxLat > 90 || b.MinLat > b.MaxLat {
+		return BBox{}, fmt.Errorf("bbox latitudes must be within ±90 with minLat <= maxLat")
+	}
+	return b, nil
+}
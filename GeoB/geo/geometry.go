@@ -0,0 +1,663 @@
+// geometry.go
+//
+// Simple-features geometries for dataset rows
+// Points, line strings and polygons (and their Multi* forms) with GeoJSON and
+// WKT encoding, bounds and the predicates behind the spatial filters.
+// Compliance Level: Moderate
+//
+// - Coordinates are WGS84 decimal degrees in (lon, lat) order
+// - Intersects is planar on lon/lat, matching PostGIS ST_Intersects on geometry(4326)
+// - Distance is in metres: exact (haversine) to vertices, and measured on a
+//   local equirectangular projection along edges, matching ST_Distance on
+//   geography to well under 1% at dataset scales
+// - Representative returns a point on the geometry (inside polygons), used
+//   for the lat/lon columns and clustering; Closest returns the point nearest
+//   a query point, used for bearings
+//
+// NOTE: GeometryCollection is not supported
+
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Geometry is one dataset geometry. Exactly one of Points, Lines or Polygons
+// is populated, according to Type.
+type Geometry struct {
+	// Type is the GeoJSON type name, e.g. "Point" or "MultiPolygon"
+	Type     string
+	Points   []Point      // Point, MultiPoint
+	Lines    [][]Point    // LineString, MultiLineString
+	Polygons MultiPolygon // Polygon, MultiPolygon
+}
+
+// NewPoint returns a Point geometry.
+func NewPoint(lat, lon float64) *Geometry {
+	return &Geometry{Type: "Point", Points: []Point{{Lon: lon, Lat: lat}}}
+}
+
+// IsPoint reports whether g is a single point.
+func (g *Geometry) IsPoint() bool {
+	return g.Type == "Point"
+}
+
+// IsArea reports whether g is a Polygon or MultiPolygon.
+func (g *Geometry) IsArea() bool {
+	return len(g.Polygons) > 0
+}
+
+// Contains reports whether the point lies inside an area geometry (outside
+// its holes). Points and lines contain nothing.
+func (g *Geometry) Contains(lat, lon float64) bool {
+	return g.IsArea() && g.Polygons.Contains(lat, lon)
+}
+
+// Covers reports whether the point lies inside or on the boundary of an area
+// geometry, as ST_Covers. Points and lines cover nothing.
+func (g *Geometry) Covers(lat, lon float64) bool {
+	return g.IsArea() && g.Polygons.Covers(lat, lon)
+}
+
+// ParseGeometry parses a GeoJSON geometry object or a Feature carrying one.
+func ParseGeometry(data []byte) (*Geometry, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	if obj.Type == "Feature" {
+		if obj.Geometry == nil {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		obj = *obj.Geometry
+	}
+
+	g := &Geometry{Type: obj.Type}
+	var err error
+	switch obj.Type {
+	case "Point":
+		var c []float64
+		if err = json.Unmarshal(obj.Coordinates, &c); err == nil {
+			var pt Point
+			pt, err = pointFromCoords(c)
+			g.Points = []Point{pt}
+		}
+	case "MultiPoint":
+		var cs [][]float64
+		if err = json.Unmarshal(obj.Coordinates, &cs); err == nil {
+			g.Points, err = pointsFromCoords(cs)
+		}
+	case "LineString":
+		var cs [][]float64
+		if err = json.Unmarshal(obj.Coordinates, &cs); err == nil {
+			var line []Point
+			line, err = pointsFromCoords(cs)
+			g.Lines = [][]Point{line}
+		}
+	case "MultiLineString":
+		var lcs [][][]float64
+		if err = json.Unmarshal(obj.Coordinates, &lcs); err == nil {
+			for _, cs := range lcs {
+				var line []Point
+				if line, err = pointsFromCoords(cs); err != nil {
+					break
+				}
+				g.Lines = append(g.Lines, line)
+			}
+		}
+	case "Polygon", "MultiPolygon":
+		g.Polygons, err = parseGeoJSONPolygon(data)
+		if err == nil {
+			err = g.Polygons.validate()
+		}
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON geometry type %q", obj.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", obj.Type, err)
+	}
+	return g, g.validate()
+}
+
+func pointFromCoords(c []float64) (Point, error) {
+	if len(c) < 2 {
+		return Point{}, fmt.Errorf("position needs longitude and latitude")
+	}
+	return Point{Lon: c[0], Lat: c[1]}, nil
+}
+
+func pointsFromCoords(cs [][]float64) ([]Point, error) {
+	pts := make([]Point, 0, len(cs))
+	for _, c := range cs {
+		pt, err := pointFromCoords(c)
+		if err != nil {
+			return nil, err
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
+
+// validate checks part sizes and coordinate ranges (polygons are checked by MultiPolygon.validate).
+func (g *Geometry) validate() error {
+	if len(g.Points) == 0 && len(g.Lines) == 0 && len(g.Polygons) == 0 {
+		return fmt.Errorf("%s has no coordinates", g.Type)
+	}
+	for _, line := range g.Lines {
+		if len(line) < 2 {
+			return fmt.Errorf("%s needs at least 2 points per line", g.Type)
+		}
+	}
+	var err error
+	g.eachVertex(func(pt Point) {
+		if err == nil && (pt.Lat < -90 || pt.Lat > 90 || pt.Lon < -180 || pt.Lon > 180) {
+			err = fmt.Errorf("coordinate (%g, %g) out of range", pt.Lon, pt.Lat)
+		}
+	})
+	return err
+}
+
+// MarshalJSON encodes g as a GeoJSON geometry object.
+func (g *Geometry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}{g.Type, g.coordinates()})
+}
+
+func (g *Geometry) coordinates() interface{} {
+	switch g.Type {
+	case "Point":
+		return coords(g.Points)[0]
+	case "MultiPoint":
+		return coords(g.Points)
+	case "LineString":
+		return coords(g.Lines[0])
+	case "MultiLineString":
+		out := make([][][]float64, len(g.Lines))
+		for i, line := range g.Lines {
+			out[i] = coords(line)
+		}
+		return out
+	case "Polygon":
+		return polygonCoords(g.Polygons[0])
+	default:
+		out := make([][][][]float64, len(g.Polygons))
+		for i, p := range g.Polygons {
+			out[i] = polygonCoords(p)
+		}
+		return out
+	}
+}
+
+func coords(pts []Point) [][]float64 {
+	out := make([][]float64, len(pts))
+	for i, pt := range pts {
+		out[i] = []float64{pt.Lon, pt.Lat}
+	}
+	return out
+}
+
+func polygonCoords(p Polygon) [][][]float64 {
+	out := make([][][]float64, len(p))
+	for i, r := range p {
+		out[i] = coords(r)
+	}
+	return out
+}
+
+// WKT renders g as well-known text.
+func (g *Geometry) WKT() string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(g.Type))
+	switch g.Type {
+	case "Point", "LineString":
+		pts := g.Points
+		if g.Type == "LineString" {
+			pts = g.Lines[0]
+		}
+		writeWKTPoints(&b, pts)
+	case "MultiPoint":
+		b.WriteByte('(')
+		for i, pt := range g.Points {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeWKTPoints(&b, []Point{pt})
+		}
+		b.WriteByte(')')
+	case "MultiLineString":
+		b.WriteByte('(')
+		for i, line := range g.Lines {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeWKTPoints(&b, line)
+		}
+		b.WriteByte(')')
+	case "Polygon":
+		return "POLYGON" + polygonWKT(g.Polygons[0])
+	default:
+		return g.Polygons.WKT()
+	}
+	return b.String()
+}
+
+// polygonWKT renders the rings of p as "((x y, ...),(x y, ...))".
+func polygonWKT(p Polygon) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i, r := range p {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeWKTPoints(&b, r)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+func writeWKTPoints(b *strings.Builder, pts []Point) {
+	b.WriteByte('(')
+	for i, pt := range pts {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(pt.Lon, 'f', -1, 64))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(pt.Lat, 'f', -1, 64))
+	}
+	b.WriteByte(')')
+}
+
+// eachVertex calls fn for every coordinate of g, including hole vertices.
+func (g *Geometry) eachVertex(fn func(Point)) {
+	for _, pt := range g.Points {
+		fn(pt)
+	}
+	for _, line := range g.Lines {
+		for _, pt := range line {
+			fn(pt)
+		}
+	}
+	for _, p := range g.Polygons {
+		for _, r := range p {
+			for _, pt := range r {
+				fn(pt)
+			}
+		}
+	}
+}
+
+// eachSegment calls fn for every edge of g's lines and polygon rings.
+func (g *Geometry) eachSegment(fn func(a, b Point) bool) bool {
+	for _, line := range g.Lines {
+		for i := 1; i < len(line); i++ {
+			if fn(line[i-1], line[i]) {
+				return true
+			}
+		}
+	}
+	return eachRingSegment(g.Polygons, fn)
+}
+
+// eachRingSegment calls fn for every ring edge of m until fn returns true.
+func eachRingSegment(m MultiPolygon, fn func(a, b Point) bool) bool {
+	for _, p := range m {
+		for _, r := range p {
+			for i := 1; i < len(r); i++ {
+				if fn(r[i-1], r[i]) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Bounds returns the bounding box of every vertex.
+func (g *Geometry) Bounds() BBox {
+	b := BBox{MinLon: 180, MinLat: 90, MaxLon: -180, MaxLat: -90}
+	g.eachVertex(func(pt Point) {
+		b.MinLon = min(b.MinLon, pt.Lon)
+		b.MaxLon = max(b.MaxLon, pt.Lon)
+		b.MinLat = min(b.MinLat, pt.Lat)
+		b.MaxLat = max(b.MaxLat, pt.Lat)
+	})
+	return b
+}
+
+// overlaps reports whether two non-wrapping boxes share any point.
+func (b BBox) overlaps(o BBox) bool {
+	return b.MinLon <= o.MaxLon && o.MinLon <= b.MaxLon && b.MinLat <= o.MaxLat && o.MinLat <= b.MaxLat
+}
+
+// Intersects reports whether g and the polygon(s) m share any point.
+func (g *Geometry) Intersects(m MultiPolygon) bool {
+	if !g.Bounds().overlaps(m.Bounds()) {
+		return false
+	}
+	inside := false
+	g.eachVertex(func(pt Point) {
+		inside = inside || m.Contains(pt.Lat, pt.Lon)
+	})
+	if inside {
+		return true
+	}
+	crosses := g.eachSegment(func(a, b Point) bool {
+		return eachRingSegment(m, func(c, d Point) bool { return segmentsIntersect(a, b, c, d) })
+	})
+	if crosses {
+		return true
+	}
+	// m may lie entirely inside a polygon of g
+	for _, p := range m {
+		if len(p) > 0 && len(p[0]) > 0 && g.Polygons.Contains(p[0][0].Lat, p[0][0].Lon) {
+			return true
+		}
+	}
+	return false
+}
+
+// IntersectsBBox reports whether g and the box share any point. Boxes
+// crossing the antimeridian are handled.
+func (g *Geometry) IntersectsBBox(b BBox) bool {
+	if g.IsPoint() {
+		return b.Contains(g.Points[0].Lat, g.Points[0].Lon)
+	}
+	for _, part := range b.Split() {
+		if g.Intersects(MultiPolygon{part.polygon()}) {
+			return true
+		}
+	}
+	return false
+}
+
+// polygon returns the box as a closed rectangle.
+func (b BBox) polygon() Polygon {
+	return Polygon{Ring{
+		{b.MinLon, b.MinLat}, {b.MaxLon, b.MinLat}, {b.MaxLon, b.MaxLat}, {b.MinLon, b.MaxLat}, {b.MinLon, b.MinLat},
+	}}
+}
+
+// orientation returns the sign of the turn a -> b -> c (0 when collinear).
+func orientation(a, b, c Point) int {
+	v := (b.Lon-a.Lon)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lon-a.Lon)
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// onSegment reports whether c, collinear with a-b, lies within its extent.
+func onSegment(a, b, c Point) bool {
+	return min(a.Lon, b.Lon) <= c.Lon && c.Lon <= max(a.Lon, b.Lon) &&
+		min(a.Lat, b.Lat) <= c.Lat && c.Lat <= max(a.Lat, b.Lat)
+}
+
+// segmentsIntersect reports whether segments a-b and c-d touch or cross.
+func segmentsIntersect(a, b, c, d Point) bool {
+	o1, o2 := orientation(a, b, c), orientation(a, b, d)
+	o3, o4 := orientation(c, d, a), orientation(c, d, b)
+	if o1 != o2 && o3 != o4 {
+		return true
+	}
+	return (o1 == 0 && onSegment(a, b, c)) || (o2 == 0 && onSegment(a, b, d)) ||
+		(o3 == 0 && onSegment(c, d, a)) || (o4 == 0 && onSegment(c, d, b))
+}
+
+// Distance returns the distance in metres from (lat, lon) to the nearest
+// part of g; 0 when the point lies inside a polygon.
+func (g *Geometry) Distance(lat, lon float64) float64 {
+	_, d := g.Closest(lat, lon)
+	return d
+}
+
+// Closest returns the point of g nearest to (lat, lon) and its distance in
+// metres; the query point itself, at 0, when it lies inside a polygon.
+func (g *Geometry) Closest(lat, lon float64) (Point, float64) {
+	here := Point{Lon: lon, Lat: lat}
+	if g.Polygons.Contains(lat, lon) {
+		return here, 0
+	}
+	best, dist := here, math.Inf(1)
+	for _, pt := range g.Points {
+		if d := Haversine(lat, lon, pt.Lat, pt.Lon); d < dist {
+			best, dist = pt, d
+		}
+	}
+	g.eachSegment(func(a, b Point) bool {
+		pt := closestOnSegment(lat, lon, a, b)
+		if d := Haversine(lat, lon, pt.Lat, pt.Lon); d < dist {
+			best, dist = pt, d
+		}
+		return false
+	})
+	return best, dist
+}
+
+// DistanceTo returns the distance in metres between the nearest parts of g
+// and o; 0 when they touch, cross or one lies inside the other. Between
+// shapes that do not cross, the minimum is always at a vertex of one of them.
+func (g *Geometry) DistanceTo(o *Geometry) float64 {
+	crosses := g.eachSegment(func(a, b Point) bool {
+		return o.eachSegment(func(c, d Point) bool { return segmentsIntersect(a, b, c, d) })
+	})
+	if crosses {
+		return 0
+	}
+	best := math.Inf(1)
+	g.eachVertex(func(pt Point) {
+		best = min(best, o.Distance(pt.Lat, pt.Lon))
+	})
+	o.eachVertex(func(pt Point) {
+		best = min(best, g.Distance(pt.Lat, pt.Lon))
+	})
+	return best
+}
+
+// Within reports whether g lies inside or on the boundary of the area o, as
+// ST_CoveredBy: every vertex is covered, every edge stays covered between
+// the points where it meets o's boundary, and no hole of o lies inside g.
+func (g *Geometry) Within(o *Geometry) bool {
+	if !o.IsArea() {
+		return false
+	}
+	covered := true
+	g.eachVertex(func(pt Point) {
+		covered = covered && o.Covers(pt.Lat, pt.Lon)
+	})
+	if !covered {
+		return false
+	}
+	leaves := g.eachSegment(func(a, b Point) bool {
+		cuts := []float64{0, 1}
+		o.eachSegment(func(c, d Point) bool {
+			cuts = append(cuts, segmentCuts(a, b, c, d)...)
+			return false
+		})
+		sort.Float64s(cuts)
+		for i := 1; i < len(cuts); i++ {
+			if cuts[i] == cuts[i-1] {
+				continue
+			}
+			t := (cuts[i-1] + cuts[i]) / 2
+			if !o.Covers(a.Lat+t*(b.Lat-a.Lat), a.Lon+t*(b.Lon-a.Lon)) {
+				return true
+			}
+		}
+		return false
+	})
+	if leaves {
+		return false
+	}
+	for _, p := range o.Polygons {
+		for _, hole := range p[1:] {
+			if len(hole) > 0 && g.Polygons.Covers(interiorPoint(Polygon{hole})) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// segmentCuts returns the positions (0 at a, 1 at b) where segment a-b meets
+// segment c-d: the crossing point, or the ends of a collinear overlap.
+func segmentCuts(a, b, c, d Point) []float64 {
+	cross := func(p, q Point) float64 { return p.Lon*q.Lat - p.Lat*q.Lon }
+	sub := func(p, q Point) Point { return Point{Lon: p.Lon - q.Lon, Lat: p.Lat - q.Lat} }
+	ab, cd, ac := sub(b, a), sub(d, c), sub(c, a)
+	len2 := ab.Lon*ab.Lon + ab.Lat*ab.Lat
+	if len2 == 0 {
+		return nil
+	}
+	if denom := cross(ab, cd); denom != 0 {
+		t, u := cross(ac, cd)/denom, cross(ac, ab)/denom
+		if t < 0 || t > 1 || u < 0 || u > 1 {
+			return nil
+		}
+		return []float64{t}
+	}
+	if cross(ac, ab) != 0 {
+		return nil // parallel, not collinear
+	}
+	var cuts []float64
+	for _, p := range []Point{c, d} {
+		v := sub(p, a)
+		if t := (v.Lon*ab.Lon + v.Lat*ab.Lat) / len2; t >= 0 && t <= 1 {
+			cuts = append(cuts, t)
+		}
+	}
+	return cuts
+}
+
+// segmentDistance returns the distance in metres from (lat, lon) to segment a-b.
+func segmentDistance(lat, lon float64, a, b Point) float64 {
+	pt := closestOnSegment(lat, lon, a, b)
+	return Haversine(lat, lon, pt.Lat, pt.Lon)
+}
+
+// closestOnSegment returns the point of segment a-b closest to (lat, lon),
+// found on an equirectangular projection centred on the query point.
+func closestOnSegment(lat, lon float64, a, b Point) Point {
+	k := math.Cos(Radians(lat))
+	ax, ay := (a.Lon-lon)*k, a.Lat-lat
+	dx, dy := (b.Lon-a.Lon)*k, b.Lat-a.Lat
+	t := 0.0
+	if d2 := dx*dx + dy*dy; d2 > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/d2))
+	}
+	return Point{Lon: a.Lon + t*(b.Lon-a.Lon), Lat: a.Lat + t*(b.Lat-a.Lat)}
+}
+
+// Representative returns a point on g: the point itself, the midpoint along
+// the longest line, or a point inside the largest polygon (its centroid when
+// that falls inside, otherwise the middle of the widest interior span on the
+// centroid's latitude).
+func (g *Geometry) Representative() (lat, lon float64) {
+	switch {
+	case len(g.Points) > 0:
+		return g.Points[0].Lat, g.Points[0].Lon
+	case len(g.Lines) > 0:
+		longest, length := g.Lines[0], 0.0
+		for _, line := range g.Lines {
+			if l := planarLength(line); l > length {
+				longest, length = line, l
+			}
+		}
+		return alongLine(longest, length/2)
+	default:
+		largest, area := g.Polygons[0], 0.0
+		for _, p := range g.Polygons {
+			if len(p) > 0 {
+				if a := math.Abs(ringArea(p[0])); a > area {
+					largest, area = p, a
+				}
+			}
+		}
+		return interiorPoint(largest)
+	}
+}
+
+func planarLength(line []Point) float64 {
+	var l float64
+	for i := 1; i < len(line); i++ {
+		l += math.Hypot(line[i].Lon-line[i-1].Lon, line[i].Lat-line[i-1].Lat)
+	}
+	return l
+}
+
+// alongLine returns the point at planar distance d along line.
+func alongLine(line []Point, d float64) (float64, float64) {
+	for i := 1; i < len(line); i++ {
+		a, b := line[i-1], line[i]
+		seg := math.Hypot(b.Lon-a.Lon, b.Lat-a.Lat)
+		if seg > 0 && d <= seg {
+			t := d / seg
+			return a.Lat + t*(b.Lat-a.Lat), a.Lon + t*(b.Lon-a.Lon)
+		}
+		d -= seg
+	}
+	last := line[len(line)-1]
+	return last.Lat, last.Lon
+}
+
+// ringArea returns the signed planar area of a closed ring (shoelace formula).
+func ringArea(r Ring) float64 {
+	var a float64
+	for i := 1; i < len(r); i++ {
+		a += r[i-1].Lon*r[i].Lat - r[i].Lon*r[i-1].Lat
+	}
+	return a / 2
+}
+
+// interiorPoint returns a point inside polygon p.
+func interiorPoint(p Polygon) (float64, float64) {
+	ring := p[0]
+	area := ringArea(ring)
+	var cx, cy float64
+	if area != 0 {
+		for i := 1; i < len(ring); i++ {
+			f := ring[i-1].Lon*ring[i].Lat - ring[i].Lon*ring[i-1].Lat
+			cx += (ring[i-1].Lon + ring[i].Lon) * f
+			cy += (ring[i-1].Lat + ring[i].Lat) * f
+		}
+		cx, cy = cx/(6*area), cy/(6*area)
+		if p.Contains(cy, cx) {
+			return cy, cx
+		}
+	} else {
+		b := MultiPolygon{p}.Bounds()
+		cy = (b.MinLat + b.MaxLat) / 2
+	}
+
+	// Scan the centroid latitude: crossings pair up into interior spans
+	var xs []float64
+	for _, r := range p {
+		for i := 1; i < len(r); i++ {
+			a, b := r[i-1], r[i]
+			if (a.Lat > cy) != (b.Lat > cy) {
+				xs = append(xs, a.Lon+(cy-a.Lat)*(b.Lon-a.Lon)/(b.Lat-a.Lat))
+			}
+		}
+	}
+	sort.Float64s(xs)
+	best, width := -1, 0.0
+	for i := 0; i+1 < len(xs); i += 2 {
+		if w := xs[i+1] - xs[i]; w > width {
+			best, width = i, w
+		}
+	}
+	if best < 0 {
+		return ring[0].Lat, ring[0].Lon
+	}
+	return cy, (xs[best] + xs[best+1]) / 2
+}
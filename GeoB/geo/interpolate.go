@@ -0,0 +1,307 @@
+// interpolate.go
+//
+// Spatial interpolation of point samples
+// Compliance Level: Moderate
+//
+// - IDW: inverse-distance weighting of the k nearest samples
+// - Ordinary kriging: an exponential variogram fitted to every sample, then
+//   the kriging system solved over the k nearest samples
+// - Distances are great-circle metres (Haversine); variogram ranges too
+// - Deterministic: neighbours tie-break by sample index
+//
+// NOTE: A sample closer than ExactDistance to the target is returned as the
+// estimate by both methods (interpolators honour the data)
+
+package geo
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
+)
+
+// ExactDistance is the distance in metres below which a sample is taken as
+// coinciding with the target.
+const ExactDistance = 1.0
+
+// ErrTooFewSamples is returned when a variogram cannot be fitted.
+var ErrTooFewSamples = errors.New("at least 4 samples are needed to fit a variogram")
+
+// Sample is one observed value.
+type Sample struct {
+	Lat   float64
+	Lon   float64
+	Value float64
+}
+
+// Weight is the contribution of samples[Index] to an estimate.
+type Weight struct {
+	Index    int
+	Distance float64
+	Weight   float64
+}
+
+// nearestSamples returns the k samples closest to the target, nearest first.
+func nearestSamples(samples []Sample, lat, lon float64, k int) []Weight {
+	all := make([]Weight, len(samples))
+	for i, s := range samples {
+		all[i] = Weight{Index: i, Distance: Haversine(lat, lon, s.Lat, s.Lon)}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Distance != all[j].Distance {
+			return all[i].Distance < all[j].Distance
+		}
+		return all[i].Index < all[j].Index
+	})
+	if k > 0 && k < len(all) {
+		all = all[:k]
+	}
+	return all
+}
+
+// exact reports a coinciding sample, which then carries all the weight.
+func exact(samples []Sample, near []Weight) (float64, []Weight, bool) {
+	if len(near) == 0 || near[0].Distance >= ExactDistance {
+		return 0, nil, false
+	}
+	w := near[0]
+	w.Weight = 1
+	return samples[w.Index].Value, []Weight{w}, true
+}
+
+// IDW estimates the value at the target from the k nearest samples weighted
+// by 1/distance^power. It returns NaN when there are no samples.
+func IDW(samples []Sample, lat, lon float64, k int, power float64) (float64, []Weight) {
+	near := nearestSamples(samples, lat, lon, k)
+	if len(near) == 0 {
+		return math.NaN(), nil
+	}
+	if v, w, ok := exact(samples, near); ok {
+		return v, w
+	}
+	var total, estimate float64
+	for i := range near {
+		near[i].Weight = 1 / math.Pow(near[i].Distance, power)
+		total += near[i].Weight
+	}
+	for i := range near {
+		near[i].Weight /= total
+		estimate += near[i].Weight * samples[near[i].Index].Value
+	}
+	return estimate, near
+}
+
+// Variogram is an exponential semivariogram model:
+// gamma(h) = Nugget + Sill * (1 - exp(-3h / Range)) for h > 0, and 0 at h = 0.
+// Sill is the partial sill; Range is the practical range in metres.
+type Variogram struct {
+	Nugget float64
+	Sill   float64
+	Range  float64
+}
+
+// At returns the semivariance at distance h metres.
+func (v Variogram) At(h float64) float64 {
+	if h == 0 {
+		return 0
+	}
+	return v.Nugget + v.Sill*(1-math.Exp(-3*h/v.Range))
+}
+
+// Variogram fitting grid.
+const (
+	variogramBins   = 15
+	variogramRanges = 60
+)
+
+// FitVariogram fits an exponential variogram to the empirical semivariogram
+// of samples: pairs are binned by distance up to half the largest separation,
+// then nugget and sill are solved by least squares (weighted by pair count)
+// for each candidate range, keeping the best fit.
+func FitVariogram(samples []Sample) (Variogram, error) {
+	if len(samples) < 4 {
+		return Variogram{}, ErrTooFewSamples
+	}
+	type pair struct{ h, gamma float64 }
+	pairs := make([]pair, 0, len(samples)*(len(samples)-1)/2)
+	var maxH float64
+	for i := range samples {
+		for j := i + 1; j < len(samples); j++ {
+			h := Haversine(samples[i].Lat, samples[i].Lon, samples[j].Lat, samples[j].Lon)
+			d := samples[i].Value - samples[j].Value
+			pairs = append(pairs, pair{h, d * d / 2})
+			maxH = max(maxH, h)
+		}
+	}
+	maxLag := maxH / 2
+	if maxLag == 0 {
+		return Variogram{}, ErrTooFewSamples
+	}
+
+	var sums [variogramBins]lagBin
+	width := maxLag / variogramBins
+	for _, p := range pairs {
+		b := int(p.h / width)
+		if p.h == 0 || b >= variogramBins {
+			continue
+		}
+		sums[b].h += p.h
+		sums[b].gamma += p.gamma
+		sums[b].n++
+	}
+	bins := make([]lagBin, 0, variogramBins)
+	for _, b := range sums {
+		if b.n > 0 {
+			bins = append(bins, lagBin{h: b.h / b.n, gamma: b.gamma / b.n, n: b.n})
+		}
+	}
+
+	best, bestErr := Variogram{Range: maxLag}, math.Inf(1)
+	for r := 0; r < variogramRanges; r++ {
+		// Candidate ranges grow geometrically from one bin width to 3x the max lag
+		v := fitNuggetSill(width*math.Pow(3*variogramBins, float64(r)/(variogramRanges-1)), bins)
+		var sse float64
+		for _, b := range bins {
+			d := v.At(b.h) - b.gamma
+			sse += b.n * d * d
+		}
+		if sse < bestErr {
+			best, bestErr = v, sse
+		}
+	}
+	return best, nil
+}
+
+// lagBin is one distance class of the empirical semivariogram: the mean
+// separation, the mean semivariance and the number of pairs.
+type lagBin struct{ h, gamma, n float64 }
+
+// fitNuggetSill solves gamma = nugget + sill * f(h) by weighted least squares
+// for a fixed range, keeping both parameters non-negative.
+func fitNuggetSill(rng float64, bins []lagBin) Variogram {
+	var sw, sf, sff, sg, sfg float64
+	for _, b := range bins {
+		f := 1 - math.Exp(-3*b.h/rng)
+		sw += b.n
+		sf += b.n * f
+		sff += b.n * f * f
+		sg += b.n * b.gamma
+		sfg += b.n * f * b.gamma
+	}
+	v := Variogram{Range: rng}
+	if det := sw*sff - sf*sf; det > 0 {
+		v.Nugget = (sg*sff - sf*sfg) / det
+		v.Sill = (sw*sfg - sf*sg) / det
+	}
+	switch {
+	case v.Nugget < 0 && sff > 0:
+		v.Nugget, v.Sill = 0, sfg/sff
+	case v.Sill < 0 || sff == 0:
+		v.Nugget, v.Sill = sg/sw, 0
+	}
+	v.Nugget, v.Sill = max(v.Nugget, 0), max(v.Sill, 0)
+	return v
+}
+
+// Kriged is an ordinary kriging estimate.
+type Kriged struct {
+	Value    float64
+	Variance float64 // kriging variance, in squared value units
+	Weights  []Weight
+}
+
+// Krige estimates the value at the target by ordinary kriging over the k
+// nearest samples with variogram v. Weights sum to 1 and may be negative.
+func Krige(samples []Sample, v Variogram, lat, lon float64, k int) (Kriged, error) {
+	near := nearestSamples(samples, lat, lon, k)
+	if len(near) == 0 {
+		return Kriged{Value: math.NaN()}, nil
+	}
+	if value, w, ok := exact(samples, near); ok {
+		return Kriged{Value: value, Weights: w}, nil
+	}
+	n := len(near)
+	if v.Nugget+v.Sill == 0 {
+		// Constant field: every neighbour holds the same value
+		var mean float64
+		for i := range near {
+			near[i].Weight = 1 / float64(n)
+			mean += samples[near[i].Index].Value / float64(n)
+		}
+		return Kriged{Value: mean, Weights: near}, nil
+	}
+
+	// [gamma_ij 1; 1 0] [w; mu] = [gamma_i0; 1]
+	a := make([][]float64, n+1)
+	b := make([]float64, n+1)
+	for i := 0; i < n; i++ {
+		a[i] = make([]float64, n+1)
+		si := samples[near[i].Index]
+		for j := 0; j < n; j++ {
+			sj := samples[near[j].Index]
+			a[i][j] = v.At(Haversine(si.Lat, si.Lon, sj.Lat, sj.Lon))
+		}
+		a[i][n] = 1
+		b[i] = v.At(near[i].Distance)
+	}
+	a[n] = make([]float64, n+1)
+	for j := 0; j < n; j++ {
+		a[n][j] = 1
+	}
+	b[n] = 1
+	gamma0 := slices.Clone(b)
+
+	x, err := solve(a, b)
+	if err != nil {
+		return Kriged{}, err
+	}
+	est := Kriged{Variance: x[n]}
+	for i := 0; i < n; i++ {
+		near[i].Weight = x[i]
+		est.Value += x[i] * samples[near[i].Index].Value
+		est.Variance += x[i] * gamma0[i]
+	}
+	est.Variance = max(est.Variance, 0)
+	est.Weights = near
+	return est, nil
+}
+
+// ErrSingular is returned when the kriging system has no unique solution
+// (e.g. two neighbours at the same location).
+var ErrSingular = errors.New("kriging system is singular")
+
+// solve solves a x = b by Gaussian elimination with partial pivoting; a and b
+// are overwritten.
+func solve(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, ErrSingular
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+			b[r] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := b[r]
+		for c := r + 1; c < n; c++ {
+			s -= a[r][c] * x[c]
+		}
+		x[r] = s / a[r][r]
+	}
+	return x, nil
+}
@@ -0,0 +1,197 @@
+package geo
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// linearField is 10 + 100 per degree north + 50 per degree east of (-37.8, 144.9).
+func linearField(lat, lon float64) float64 {
+	return 10 + 100*(lat+37.8) + 50*(lon-144.9)
+}
+
+// grid samples linearField on n x n stations 0.01 degrees apart.
+func grid(n int) []Sample {
+	samples := make([]Sample, 0, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			lat, lon := -37.8+0.01*float64(i), 144.9+0.01*float64(j)
+			samples = append(samples, Sample{Lat: lat, Lon: lon, Value: linearField(lat, lon)})
+		}
+	}
+	return samples
+}
+
+func weightSum(weights []Weight) float64 {
+	var sum float64
+	for _, w := range weights {
+		sum += w.Weight
+	}
+	return sum
+}
+
+func TestFitVariogramTooFewSamples(t *testing.T) {
+	same := []Sample{{-37.8, 144.9, 1}, {-37.8, 144.9, 2}, {-37.8, 144.9, 3}, {-37.8, 144.9, 4}}
+	for name, samples := range map[string][]Sample{
+		"three samples": grid(2)[:3],
+		"one location":  same,
+		"no samples":    nil,
+		"one sample":    grid(1),
+	} {
+		if _, err := FitVariogram(samples); !errors.Is(err, ErrTooFewSamples) {
+			t.Errorf("%s: error = %v, want ErrTooFewSamples", name, err)
+		}
+	}
+}
+
+func TestKrigeExactHit(t *testing.T) {
+	samples := grid(4)
+	v, err := FitVariogram(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// About 0.1 m north of the station at index 5
+	s := samples[5]
+	lat, lon := s.Lat+1e-6, s.Lon
+
+	kriged, err := Krige(samples, v, lat, lon, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kriged.Value != s.Value || kriged.Variance != 0 {
+		t.Errorf("kriged = %v (variance %v), want %v exactly", kriged.Value, kriged.Variance, s.Value)
+	}
+	if len(kriged.Weights) != 1 || kriged.Weights[0].Index != 5 || kriged.Weights[0].Weight != 1 {
+		t.Errorf("kriging weights = %+v, want station 5 alone", kriged.Weights)
+	}
+
+	value, weights := IDW(samples, lat, lon, 8, 2)
+	if value != s.Value || len(weights) != 1 || weights[0].Index != 5 || weights[0].Weight != 1 {
+		t.Errorf("IDW = %v %+v, want station 5 alone", value, weights)
+	}
+}
+
+func TestKrigeLinearField(t *testing.T) {
+	samples := grid(6)
+	v, err := FitVariogram(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Sill <= 0 || v.Range <= 0 || v.Nugget < 0 {
+		t.Fatalf("variogram = %+v, want a positive sill and range", v)
+	}
+	// A linear trend has no noise: the semivariance grows with distance
+	if v.At(1000) >= v.At(3000) {
+		t.Errorf("variogram %+v does not grow with distance", v)
+	}
+
+	for _, target := range [][2]float64{{-37.775, 144.925}, {-37.765, 144.915}, {-37.782, 144.931}} {
+		lat, lon := target[0], target[1]
+		want := linearField(lat, lon)
+
+		kriged, err := Krige(samples, v, lat, lon, 12)
+		if err != nil {
+			t.Fatalf("(%v, %v): %v", lat, lon, err)
+		}
+		if sum := weightSum(kriged.Weights); math.Abs(sum-1) > 1e-9 {
+			t.Errorf("(%v, %v): kriging weights sum to %v, want 1", lat, lon, sum)
+		}
+		if kriged.Variance < 0 {
+			t.Errorf("(%v, %v): variance = %v", lat, lon, kriged.Variance)
+		}
+		idw, weights := IDW(samples, lat, lon, 12, 2)
+		if sum := weightSum(weights); math.Abs(sum-1) > 1e-9 {
+			t.Errorf("(%v, %v): IDW weights sum to %v, want 1", lat, lon, sum)
+		}
+
+		// Stations 0.01 degrees apart differ by 0.5 to 1; both estimates stay within a
+		// fraction of one station step of the field and of each other
+		if math.Abs(kriged.Value-want) > 0.05 {
+			t.Errorf("(%v, %v): kriged = %v, want %v", lat, lon, kriged.Value, want)
+		}
+		if math.Abs(idw-want) > 0.25 {
+			t.Errorf("(%v, %v): IDW = %v, want %v", lat, lon, idw, want)
+		}
+		if math.Abs(kriged.Value-idw) > 0.25 {
+			t.Errorf("(%v, %v): kriged %v and IDW %v disagree", lat, lon, kriged.Value, idw)
+		}
+	}
+}
+
+func TestKrigeCollinearStations(t *testing.T) {
+	// Stations along one meridian, 1 km apart
+	samples := make([]Sample, 8)
+	for i := range samples {
+		lat := -37.8 + 0.009*float64(i)
+		samples[i] = Sample{Lat: lat, Lon: 144.9, Value: linearField(lat, 144.9)}
+	}
+	v, err := FitVariogram(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Off the line only the northing is known and the estimate follows it,
+	// within one station step (0.9) of the value on the line
+	for _, target := range [][3]float64{{-37.7865, 144.9, 0.05}, {-37.7865, 144.91, 0.45}} {
+		lat, lon, tolerance := target[0], target[1], target[2]
+		kriged, err := Krige(samples, v, lat, lon, 6)
+		if err != nil {
+			t.Fatalf("(%v, %v): %v", lat, lon, err)
+		}
+		if sum := weightSum(kriged.Weights); math.Abs(sum-1) > 1e-9 {
+			t.Errorf("(%v, %v): weights sum to %v, want 1", lat, lon, sum)
+		}
+		if want := linearField(lat, 144.9); math.Abs(kriged.Value-want) > tolerance {
+			t.Errorf("(%v, %v): kriged = %v, want %v", lat, lon, kriged.Value, want)
+		}
+	}
+}
+
+func TestKrigeDuplicateStations(t *testing.T) {
+	samples := append(grid(4), Sample{Lat: -37.79, Lon: 144.91, Value: 99})
+	v, err := FitVariogram(samples)
+	if err != nil {
+		t.Fatalf("duplicate stations should not stop the fit: %v", err)
+	}
+
+	// Both copies are neighbours: the system has two equal rows
+	if _, err := Krige(samples, v, -37.785, 144.915, 6); !errors.Is(err, ErrSingular) {
+		t.Errorf("error = %v, want ErrSingular", err)
+	}
+	// IDW shares the weight between the copies instead
+	if value, weights := IDW(samples, -37.785, 144.915, 6, 2); math.IsNaN(value) || math.Abs(weightSum(weights)-1) > 1e-9 {
+		t.Errorf("IDW = %v %+v", value, weights)
+	}
+	// A hit on a duplicated station takes the nearest copy
+	if kriged, err := Krige(samples, v, -37.79, 144.91, 6); err != nil || len(kriged.Weights) != 1 {
+		t.Errorf("exact hit = %+v, %v", kriged, err)
+	}
+}
+
+func TestKrigeConstantField(t *testing.T) {
+	samples := grid(3)
+	for i := range samples {
+		samples[i].Value = 7
+	}
+	v, err := FitVariogram(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Nugget+v.Sill != 0 {
+		t.Fatalf("variogram = %+v, want flat", v)
+	}
+	kriged, err := Krige(samples, v, -37.795, 144.905, 4)
+	if err != nil || kriged.Value != 7 || math.Abs(weightSum(kriged.Weights)-1) > 1e-9 {
+		t.Errorf("kriged = %+v, %v, want 7", kriged, err)
+	}
+}
+
+func TestInterpolateNoSamples(t *testing.T) {
+	if value, weights := IDW(nil, 0, 0, 4, 2); !math.IsNaN(value) || weights != nil {
+		t.Errorf("IDW = %v %v, want NaN", value, weights)
+	}
+	if kriged, err := Krige(nil, Variogram{Sill: 1, Range: 1000}, 0, 0, 4); err != nil || !math.IsNaN(kriged.Value) {
+		t.Errorf("Krige = %+v, %v, want NaN", kriged, err)
+	}
+}
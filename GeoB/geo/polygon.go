@@ -0,0 +1,392 @@
+// polygon.go
+//
+// Polygon geometry for spatial filters
+// Provides point-in-polygon tests, bounds and WKT/GeoJSON parsing.
+// Compliance Level: Moderate
+//
+// - Coordinates are WGS84 decimal degrees in (lon, lat) order, as in GeoJSON and WKT
+// - Polygons may have holes; the first ring is the exterior
+// - Point-in-polygon uses the even-odd rule on planar coordinates, matching
+//   PostGIS ST_Intersects on geometry(4326)
+// - Covers also counts points on a ring (within boundaryTolerance degrees),
+//   matching ST_Covers; Contains leaves boundary points to the even-odd rule
+//
+// NOTE: Polygons crossing the antimeridian must be split by the client
+
+package geo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// boundaryTolerance is how far, in degrees, a point may lie from a ring and
+// still be on it (about 0.1 mm), absorbing rounding in stored coordinates.
+const boundaryTolerance = 1e-9
+
+// Point is a WGS84 coordinate.
+type Point struct {
+	Lon float64
+	Lat float64
+}
+
+// Ring is a closed sequence of points (first point equals last).
+type Ring []Point
+
+// Polygon is an exterior ring followed by zero or more holes.
+type Polygon []Ring
+
+// MultiPolygon is a set of polygons; a single polygon is a MultiPolygon of one.
+type MultiPolygon []Polygon
+
+// Contains reports whether the point lies inside the ring (even-odd rule).
+func (r Ring) Contains(lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(r)-1; i < len(r); j, i = i, i+1 {
+		a, b := r[i], r[j]
+		if (a.Lat > lat) != (b.Lat > lat) &&
+			lon < (b.Lon-a.Lon)*(lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// OnBoundary reports whether the point lies on an edge of the ring.
+func (r Ring) OnBoundary(lat, lon float64) bool {
+	for i := 1; i < len(r); i++ {
+		if onEdge(r[i-1], r[i], Point{Lon: lon, Lat: lat}) {
+			return true
+		}
+	}
+	return false
+}
+
+// onEdge reports whether p lies within boundaryTolerance of segment a-b, on
+// the plane.
+func onEdge(a, b, p Point) bool {
+	if p.Lon < min(a.Lon, b.Lon)-boundaryTolerance || p.Lon > max(a.Lon, b.Lon)+boundaryTolerance ||
+		p.Lat < min(a.Lat, b.Lat)-boundaryTolerance || p.Lat > max(a.Lat, b.Lat)+boundaryTolerance {
+		return false
+	}
+	dx, dy := b.Lon-a.Lon, b.Lat-a.Lat
+	t := 0.0
+	if d2 := dx*dx + dy*dy; d2 > 0 {
+		t = math.Max(0, math.Min(1, ((p.Lon-a.Lon)*dx+(p.Lat-a.Lat)*dy)/d2))
+	}
+	return math.Hypot(a.Lon+t*dx-p.Lon, a.Lat+t*dy-p.Lat) <= boundaryTolerance
+}
+
+// Contains reports whether the point lies inside the exterior ring and outside every hole.
+func (p Polygon) Contains(lat, lon float64) bool {
+	if len(p) == 0 || !p[0].Contains(lat, lon) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if hole.Contains(lat, lon) {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains reports whether the point lies inside any of the polygons.
+func (m MultiPolygon) Contains(lat, lon float64) bool {
+	for _, p := range m {
+		if p.Contains(lat, lon) {
+			return true
+		}
+	}
+	return false
+}
+
+// OnBoundary reports whether the point lies on any ring of the polygons,
+// holes included.
+func (m MultiPolygon) OnBoundary(lat, lon float64) bool {
+	for _, p := range m {
+		for _, r := range p {
+			if r.OnBoundary(lat, lon) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Covers reports whether the point lies inside or on the boundary of any of
+// the polygons (ST_Covers).
+func (m MultiPolygon) Covers(lat, lon float64) bool {
+	return m.OnBoundary(lat, lon) || m.Contains(lat, lon)
+}
+
+// Bounds returns the bounding box of all exterior rings.
+func (m MultiPolygon) Bounds() BBox {
+	b := BBox{MinLon: 180, MinLat: 90, MaxLon: -180, MaxLat: -90}
+	for _, p := range m {
+		if len(p) == 0 {
+			continue
+		}
+		for _, pt := range p[0] {
+			b.MinLon = min(b.MinLon, pt.Lon)
+			b.MaxLon = max(b.MaxLon, pt.Lon)
+			b.MinLat = min(b.MinLat, pt.Lat)
+			b.MaxLat = max(b.MaxLat, pt.Lat)
+		}
+	}
+	return b
+}
+
+// WKT renders the geometry as MULTIPOLYGON well-known text.
+func (m MultiPolygon) WKT() string {
+	var b strings.Builder
+	b.WriteString("MULTIPOLYGON(")
+	for i, p := range m {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		for j, r := range p {
+			if j > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteByte('(')
+			for k, pt := range r {
+				if k > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(strconv.FormatFloat(pt.Lon, 'f', -1, 64))
+				b.WriteByte(' ')
+				b.WriteString(strconv.FormatFloat(pt.Lat, 'f', -1, 64))
+			}
+			b.WriteByte(')')
+		}
+		b.WriteByte(')')
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// ParsePolygon parses a GeoJSON (Polygon, MultiPolygon, Feature or a
+// FeatureCollection of polygon features) or WKT (POLYGON, MULTIPOLYGON)
+// document. The format is detected from the first non-space character.
+func ParsePolygon(data []byte) (MultiPolygon, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty polygon")
+	}
+	var m MultiPolygon
+	var err error
+	if data[0] == '{' {
+		m, err = parseGeoJSONPolygon(data)
+	} else {
+		m, err = parseWKTPolygon(string(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, m.validate()
+}
+
+// validate closes open rings and checks ring sizes and coordinate ranges.
+func (m MultiPolygon) validate() error {
+	if len(m) == 0 {
+		return fmt.Errorf("polygon has no rings")
+	}
+	for pi, p := range m {
+		if len(p) == 0 {
+			return fmt.Errorf("polygon %d has no rings", pi)
+		}
+		for ri, r := range p {
+			if len(r) > 0 && r[0] != r[len(r)-1] {
+				r = append(r, r[0])
+				p[ri] = r
+			}
+			if len(r) < 4 {
+				return fmt.Errorf("polygon %d ring %d needs at least 3 distinct points", pi, ri)
+			}
+			for _, pt := range r {
+				if pt.Lat < -90 || pt.Lat > 90 || pt.Lon < -180 || pt.Lon > 180 {
+					return fmt.Errorf("coordinate (%g, %g) out of range", pt.Lon, pt.Lat)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type geoJSONObject struct {
+	Type        string            `json:"type"`
+	Coordinates json.RawMessage   `json:"coordinates"`
+	Geometry    *geoJSONObject    `json:"geometry"`
+	Features    []json.RawMessage `json:"features"`
+}
+
+func parseGeoJSONPolygon(data []byte) (MultiPolygon, error) {
+	var obj geoJSONObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+	switch obj.Type {
+	case "Polygon":
+		var coords [][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &coords); err != nil {
+			return nil, fmt.Errorf("invalid Polygon coordinates: %w", err)
+		}
+		p, err := polygonFromCoords(coords)
+		if err != nil {
+			return nil, err
+		}
+		return MultiPolygon{p}, nil
+	case "MultiPolygon":
+		var coords [][][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &coords); err != nil {
+			return nil, fmt.Errorf("invalid MultiPolygon coordinates: %w", err)
+		}
+		var m MultiPolygon
+		for _, pc := range coords {
+			p, err := polygonFromCoords(pc)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, p)
+		}
+		return m, nil
+	case "Feature":
+		if obj.Geometry == nil {
+			return nil, fmt.Errorf("feature has no geometry")
+		}
+		raw, _ := json.Marshal(obj.Geometry)
+		return parseGeoJSONPolygon(raw)
+	case "FeatureCollection":
+		var m MultiPolygon
+		for _, f := range obj.Features {
+			part, err := parseGeoJSONPolygon(f)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, part...)
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unsupported GeoJSON type %q (expected Polygon or MultiPolygon)", obj.Type)
+	}
+}
+
+func polygonFromCoords(coords [][][]float64) (Polygon, error) {
+	p := make(Polygon, 0, len(coords))
+	for _, rc := range coords {
+		r := make(Ring, 0, len(rc))
+		for _, c := range rc {
+			if len(c) < 2 {
+				return nil, fmt.Errorf("position needs longitude and latitude")
+			}
+			r = append(r, Point{Lon: c[0], Lat: c[1]})
+		}
+		p = append(p, r)
+	}
+	return p, nil
+}
+
+// parseWKTPolygon parses POLYGON((...),(...)) and MULTIPOLYGON(((...)),((...))).
+// An optional "SRID=4326;" prefix is accepted; other SRIDs are rejected.
+func parseWKTPolygon(s string) (MultiPolygon, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToUpper(s), "SRID=") {
+		i := strings.IndexByte(s, ';')
+		if i < 0 || strings.TrimSpace(s[5:i]) != "4326" {
+			return nil, fmt.Errorf("only SRID 4326 is supported")
+		}
+		s = s[i+1:]
+	}
+	upper := strings.ToUpper(s)
+	switch {
+	case strings.HasPrefix(upper, "MULTIPOLYGON"):
+		groups, err := splitParens(s[len("MULTIPOLYGON"):])
+		if err != nil {
+			return nil, err
+		}
+		var m MultiPolygon
+		for _, g := range groups {
+			p, err := parseWKTRings("(" + g + ")")
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, p)
+		}
+		return m, nil
+	case strings.HasPrefix(upper, "POLYGON"):
+		p, err := parseWKTRings(s[len("POLYGON"):])
+		if err != nil {
+			return nil, err
+		}
+		return MultiPolygon{p}, nil
+	default:
+		return nil, fmt.Errorf("unsupported WKT geometry (expected POLYGON or MULTIPOLYGON)")
+	}
+}
+
+// parseWKTRings parses "((x y, ...),(x y, ...))" into a polygon.
+func parseWKTRings(s string) (Polygon, error) {
+	rings, err := splitParens(s)
+	if err != nil {
+		return nil, err
+	}
+	var p Polygon
+	for _, rs := range rings {
+		var r Ring
+		for _, pair := range strings.Split(rs, ",") {
+			fields := strings.Fields(pair)
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("malformed coordinate %q", pair)
+			}
+			lon, errLon := strconv.ParseFloat(fields[0], 64)
+			lat, errLat := strconv.ParseFloat(fields[1], 64)
+			if errLon != nil || errLat != nil {
+				return nil, fmt.Errorf("malformed coordinate %q", pair)
+			}
+			r = append(r, Point{Lon: lon, Lat: lat})
+		}
+		p = append(p, r)
+	}
+	return p, nil
+}
+
+// splitParens takes a list of parenthesised groups wrapped in one outer pair,
+// e.g. "((a),(b))", and returns the group contents: ["a", "b"].
+func splitParens(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, fmt.Errorf("expected parenthesised list")
+	}
+	s = s[1 : len(s)-1]
+	var groups []string
+	depth, start := 0, -1
+	for i, r := range s {
+		switch r {
+		case '(':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+			if depth == 0 {
+				groups = append(groups, s[start:i])
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("expected parenthesised list")
+	}
+	return groups, nil
+}
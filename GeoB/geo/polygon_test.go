@@ -0,0 +1,75 @@
+package geo
+
+import "testing"
+
+// square is the polygon (0,0)-(2,2) with the hole (0.5,0.5)-(1,1).
+var square = MultiPolygon{{
+	{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 0}},
+	{{0.5, 0.5}, {1, 0.5}, {1, 1}, {0.5, 1}, {0.5, 0.5}},
+}}
+
+func TestMultiPolygonCovers(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     bool
+	}{
+		{"inside", 1.5, 1.5, true},
+		{"outside", 3, 1, false},
+		{"left edge", 1, 0, true},
+		{"right edge", 1, 2, true},
+		{"top edge", 2, 1.5, true},
+		{"corner", 2, 2, true},
+		{"within tolerance", 1, 2 + boundaryTolerance/2, true},
+		{"beyond tolerance", 1, 2 + 1e-6, false},
+		{"in hole", 0.75, 0.75, false},
+		{"hole edge", 0.75, 1, true},
+		{"edge extended", 0, 2.5, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := square.Covers(tt.lat, tt.lon); got != tt.want {
+				t.Errorf("Covers(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeometryWithin(t *testing.T) {
+	// A U shape: the notch (1,1)-(2,3) is outside
+	u := &Geometry{Type: "Polygon", Polygons: MultiPolygon{{
+		{{0, 0}, {3, 0}, {3, 3}, {2, 3}, {2, 1}, {1, 1}, {1, 3}, {0, 3}, {0, 0}},
+	}}}
+	holed := &Geometry{Type: "Polygon", Polygons: square}
+	line := func(pts ...Point) *Geometry { return &Geometry{Type: "LineString", Lines: [][]Point{pts}} }
+	tests := []struct {
+		name string
+		g, o *Geometry
+		want bool
+	}{
+		{"inside point", NewPoint(0.5, 0.5), u, true},
+		{"point on edge", NewPoint(0, 1.5), u, true},
+		{"point on corner", NewPoint(3, 3), u, true},
+		{"point in notch", NewPoint(2, 1.5), u, false},
+		{"line along edge", line(Point{0, 0}, Point{3, 0}), u, true},
+		{"line across notch", line(Point{0.5, 2}, Point{2.5, 2}), u, false},
+		// Both ends on the boundary, the middle outside
+		{"chord over notch", line(Point{1, 3}, Point{2, 3}), u, false},
+		{"line inside", line(Point{0.2, 0.2}, Point{2.8, 0.5}), u, true},
+		{"polygon equal", u, u, true},
+		{"polygon around hole", &Geometry{Type: "Polygon", Polygons: MultiPolygon{{
+			{{0.2, 0.2}, {1.8, 0.2}, {1.8, 1.8}, {0.2, 1.8}, {0.2, 0.2}},
+		}}}, holed, false},
+		{"polygon beside hole", &Geometry{Type: "Polygon", Polygons: MultiPolygon{{
+			{{1.2, 1.2}, {1.8, 1.2}, {1.8, 1.8}, {1.2, 1.8}, {1.2, 1.2}},
+		}}}, holed, true},
+		{"not an area", NewPoint(0, 0), NewPoint(0, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.g.Within(tt.o); got != tt.want {
+				t.Errorf("Within = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
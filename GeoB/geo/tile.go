@@ -0,0 +1,74 @@
+// tile.go
+//
+// Web Mercator tile maths (XYZ / slippy-map scheme)
+// Compliance Level: Moderate
+//
+// - Tile (0,0) is the north-west corner; y grows southwards
+// - Latitudes are clamped to the Mercator limit of ±85.0511°
+//
+// NOTE: Matches PostGIS ST_TileEnvelope so both storage backends cut tiles identically
+
+package geo
+
+import "math"
+
+// MaxMercatorLat is the latitude at which Web Mercator becomes square.
+const MaxMercatorLat = 85.05112877980659
+
+// MaxTileZoom bounds accepted zoom levels.
+const MaxTileZoom = 22
+
+// ValidTile reports whether z/x/y addresses an existing tile.
+func ValidTile(z, x, y int) bool {
+	if z < 0 || z > MaxTileZoom {
+		return false
+	}
+	n := 1 << z
+	return x >= 0 && x < n && y >= 0 && y < n
+}
+
+// mercator returns the fractional world position of (lat, lon) at zoom z,
+// in tile units.
+func mercator(z int, lat, lon float64) (float64, float64) {
+	n := float64(int(1) << z)
+	lat = math.Max(-MaxMercatorLat, math.Min(MaxMercatorLat, lat))
+	x := (lon + 180) / 360 * n
+	rLat := Radians(lat)
+	y := (1 - math.Log(math.Tan(rLat)+1/math.Cos(rLat))/math.Pi) / 2 * n
+	return x, y
+}
+
+// inverseMercator converts a fractional world position at zoom z back to lat/lon.
+func inverseMercator(z int, x, y float64) (float64, float64) {
+	n := float64(int(1) << z)
+	lon := x/n*360 - 180
+	lat := Degrees(math.Atan(math.Sinh(math.Pi * (1 - 2*y/n))))
+	return lat, lon
+}
+
+// TileBounds returns the WGS84 bounds of tile z/x/y, grown on every side by
+// buffer (a fraction of the tile width) and clamped to valid coordinates.
+func TileBounds(z, x, y int, buffer float64) BBox {
+	maxLat, minLon := inverseMercator(z, float64(x)-buffer, float64(y)-buffer)
+	minLat, maxLon := inverseMercator(z, float64(x+1)+buffer, float64(y+1)+buffer)
+	return BBox{
+		MinLon: math.Max(minLon, -180),
+		MinLat: math.Max(minLat, -90),
+		MaxLon: math.Min(maxLon, 180),
+		MaxLat: math.Min(maxLat, 90),
+	}
+}
+
+// TilePoint projects (lat, lon) into the local coordinates of tile z/x/y with
+// the given extent. Points outside the tile fall outside [0, extent).
+func TilePoint(z, x, y, extent int, lat, lon float64) (int, int) {
+	tx, ty := TileCoords(z, x, y, extent, lat, lon)
+	return int(math.Floor(tx)), int(math.Floor(ty))
+}
+
+// TileCoords is TilePoint without rounding, for shapes that are clipped
+// before quantisation.
+func TileCoords(z, x, y, extent int, lat, lon float64) (float64, float64) {
+	wx, wy := mercator(z, lat, lon)
+	return (wx - float64(x)) * float64(extent), (wy - float64(y)) * float64(extent)
+}
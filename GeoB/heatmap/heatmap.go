@@ -0,0 +1,149 @@
+// heatmap.go
+//
+// Kernel density heatmap rendering for raster map tiles
+// Compliance Level: Moderate
+//
+// - Points are in tile pixel coordinates and spread with a quartic (biweight)
+//   kernel of a fixed pixel radius, (1 - (d/r)²)², so the picture looks the
+//   same at every zoom
+// - Density is divided by Max (the tile maximum when Max is 0) and coloured
+//   through a Ramp; alpha grows with the square root of the scaled density so
+//   sparse edges fade out
+// - Uses only the standard image packages
+//
+// NOTE: With Max left at 0 every tile is scaled on its own, so neighbouring
+// tiles do not match; clients mosaicking tiles should pass a fixed Max
+
+package heatmap
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point is a weighted point in tile pixel coordinates.
+type Point struct {
+	X, Y   float64
+	Weight float64
+}
+
+// Ramp is a colour ramp of evenly spaced stops, low density first.
+type Ramp []color.NRGBA
+
+// Ramps are the named colour ramps.
+var Ramps = map[string]Ramp{
+	// Leaflet.heat's default gradient
+	"heat":    mustRamp("0000ff,00ffff,00ff00,ffff00,ff0000"),
+	"viridis": mustRamp("440154,3b528b,21918c,5ec962,fde725"),
+	"magma":   mustRamp("000004,51127c,b73779,fc8961,fcfdbf"),
+	"blues":   mustRamp("deebf7,9ecae1,4292c6,08519c,08306b"),
+}
+
+// ParseRamp reads a ramp name or at least two comma-separated hex colours
+// (RRGGBB, optionally prefixed with #).
+func ParseRamp(s string) (Ramp, error) {
+	if r, ok := Ramps[s]; ok {
+		return r, nil
+	}
+	if !strings.Contains(s, ",") {
+		return nil, fmt.Errorf("unknown ramp %q", s)
+	}
+	return parseColours(s)
+}
+
+// parseColours reads comma-separated hex colours.
+func parseColours(s string) (Ramp, error) {
+	parts := strings.Split(s, ",")
+	r := make(Ramp, len(parts))
+	for i, p := range parts {
+		p = strings.TrimPrefix(strings.TrimSpace(p), "#")
+		v, err := strconv.ParseUint(p, 16, 32)
+		if err != nil || len(p) != 6 {
+			return nil, fmt.Errorf("invalid ramp colour %q", p)
+		}
+		r[i] = color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}
+	}
+	return r, nil
+}
+
+func mustRamp(s string) Ramp {
+	r, err := parseColours(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// At returns the ramp colour at t in [0, 1].
+func (r Ramp) At(t float64) color.NRGBA {
+	t = math.Max(0, math.Min(1, t)) * float64(len(r)-1)
+	i := min(int(t), len(r)-2)
+	f := t - float64(i)
+	lerp := func(a, b uint8) uint8 { return uint8(math.Round(float64(a) + (float64(b)-float64(a))*f)) }
+	a, b := r[i], r[i+1]
+	return color.NRGBA{R: lerp(a.R, b.R), G: lerp(a.G, b.G), B: lerp(a.B, b.B), A: lerp(a.A, b.A)}
+}
+
+// Options control rendering.
+type Options struct {
+	Size    int     // tile width and height in pixels
+	Radius  float64 // kernel radius in pixels
+	Max     float64 // density of the last ramp colour; 0 scales to the tile maximum
+	Opacity float64 // alpha of the densest pixels, 0-1
+	Ramp    Ramp
+}
+
+// Density accumulates the kernel density of points on a Size x Size grid,
+// row by row. Points up to Radius outside the tile still contribute.
+func Density(points []Point, size int, radius float64) []float64 {
+	grid := make([]float64, size*size)
+	r2 := radius * radius
+	for _, p := range points {
+		if p.Weight <= 0 {
+			continue
+		}
+		x0, x1 := max(0, int(math.Floor(p.X-radius))), min(size-1, int(math.Ceil(p.X+radius)))
+		y0, y1 := max(0, int(math.Floor(p.Y-radius))), min(size-1, int(math.Ceil(p.Y+radius)))
+		for py := y0; py <= y1; py++ {
+			dy := float64(py) + 0.5 - p.Y
+			for px := x0; px <= x1; px++ {
+				dx := float64(px) + 0.5 - p.X
+				if d2 := dx*dx + dy*dy; d2 < r2 {
+					k := 1 - d2/r2
+					grid[py*size+px] += p.Weight * k * k
+				}
+			}
+		}
+	}
+	return grid
+}
+
+// Render draws the heatmap of points. It reports false when no pixel has any
+// density; the image is then fully transparent.
+func Render(points []Point, o Options) (*image.NRGBA, bool) {
+	img := image.NewNRGBA(image.Rect(0, 0, o.Size, o.Size))
+	grid := Density(points, o.Size, o.Radius)
+	scale := o.Max
+	if scale <= 0 {
+		for _, v := range grid {
+			scale = max(scale, v)
+		}
+	}
+	if scale <= 0 {
+		return img, false
+	}
+	for i, v := range grid {
+		if v <= 0 {
+			continue
+		}
+		t := math.Min(1, v/scale)
+		c := o.Ramp.At(t)
+		c.A = uint8(math.Round(float64(c.A) * o.Opacity * math.Sqrt(t)))
+		img.SetNRGBA(i%o.Size, i/o.Size, c)
+	}
+	return img, true
+}
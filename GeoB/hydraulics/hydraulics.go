@@ -0,0 +1,290 @@
+// hydraulics.go
+//
+// Full-bore pipe hydraulics and grade QA for the drainage network
+// Compliance Level: Moderate
+//
+// - Capacity uses Manning's equation, Q = (1/n)·A·R^(2/3)·S^(1/2), with the
+//   pipe flowing full and n taken from the material roughness table
+// - Slope comes from the invert levels when both ends are surveyed, otherwise
+//   from the recorded grade
+// - Recorded grades mix two conventions: values of 1 and above are "1 in X",
+//   smaller values are m/m fractions (e.g. 178.5 and 0.0056 are the same grade)
+// - QA flags reversed, flat and implausibly steep grades, recorded grades that
+//   disagree with the inverts, and invert steps between connected pipes
+//
+// NOTE: Slotted drains are treated as circular pipes of their nominal diameter
+// and box culverts as rectangles of diameter (width) by height
+
+package hydraulics
+
+import (
+	"GeoGO/models"
+	"GeoGO/network"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultManningN is used for materials missing from ManningN.
+	DefaultManningN = 0.013
+	// MinSlope is the flattest grade (m/m) not flagged as flat: 1 in 2000.
+	MinSlope = 1.0 / 2000
+	// MaxSlope is the steepest plausible grade (m/m): 1 in 4.
+	MaxSlope = 0.25
+	// InvertTolerance is the invert step in metres ignored between connected
+	// pipes; the register records levels to the centimetre.
+	InvertTolerance = 0.02
+)
+
+// ManningN is the Manning roughness coefficient by register material code.
+var ManningN = map[string]float64{
+	"RC":     0.013, // reinforced concrete
+	"CONC":   0.013, // concrete
+	"PC":     0.013, // precast concrete
+	"FIBRO":  0.011, // fibre (asbestos) cement
+	"EW":     0.013, // earthenware
+	"VC":     0.013, // vitrified clay
+	"PVC":    0.009,
+	"HDPE":   0.010,
+	"STEEL":  0.012,
+	"COPPER": 0.011,
+}
+
+// Issue codes.
+const (
+	IssueReversedGrade = "reversed_grade"
+	IssueFlatGrade     = "flat_grade"
+	IssueSteepGrade    = "steep_grade"
+	IssueGradeMismatch = "grade_mismatch"
+	IssueInvertStep    = "invert_step"
+	IssueMissingSlope  = "missing_slope"
+	IssueMissingSize   = "missing_size"
+)
+
+// Issue is one QA finding on a pipe.
+type Issue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// RelatedPipeID is the connected pipe for invert_step issues
+	RelatedPipeID int `json:"related_pipe_id,omitempty"`
+}
+
+// Result is the hydraulic analysis of one pipe.
+type Result struct {
+	PipeID   int    `json:"pipe_id"`
+	CompKey  int    `json:"compkey"`
+	FromPit  string `json:"from_pit"`
+	ToPit    string `json:"to_pit"`
+	Material string `json:"material,omitempty"`
+	// Section is the cross-section used: circular or box
+	Section  string  `json:"section"`
+	ManningN float64 `json:"manning_n"`
+	// Slope is the design slope in m/m, positive when falling downstream
+	Slope       *float64 `json:"slope,omitempty"`
+	SlopeSource string   `json:"slope_source,omitempty"`
+	// InvertSlope and RecordedSlope are the two slope estimates in m/m
+	InvertSlope   *float64 `json:"invert_slope,omitempty"`
+	RecordedSlope *float64 `json:"recorded_slope,omitempty"`
+	AreaM2        *float64 `json:"area_m2,omitempty"`
+	HydraulicRadM *float64 `json:"hydraulic_radius_m,omitempty"`
+	// CapacityM3S is the full-bore capacity of all parallel lines
+	CapacityM3S *float64 `json:"capacity_m3s,omitempty"`
+	VelocityMS  *float64 `json:"velocity_ms,omitempty"`
+	Issues      []Issue  `json:"issues"`
+}
+
+// Report is the QA summary of a whole network.
+type Report struct {
+	Pipes   int `json:"pipes"`
+	Flagged int `json:"flagged"`
+	// Issues counts flagged pipes per issue code
+	Issues map[string]int `json:"issues"`
+	// Results holds the flagged pipes only, ordered by pipe id
+	Results []Result `json:"results"`
+}
+
+// RecordedSlope converts a register grade to m/m.
+func RecordedSlope(grade float64) float64 {
+	if math.Abs(grade) >= 1 {
+		return 1 / grade
+	}
+	return grade
+}
+
+// InvertSlope returns the slope between the up and down inverts in m/m.
+func InvertSlope(p models.Pipe) (float64, bool) {
+	if p.UpInvert == nil || p.DownInvert == nil || p.Length() <= 0 {
+		return 0, false
+	}
+	return (*p.UpInvert - *p.DownInvert) / p.Length(), true
+}
+
+// Roughness returns Manning's n for a pipe material.
+func Roughness(material string) float64 {
+	if n, ok := ManningN[strings.ToUpper(material)]; ok {
+		return n
+	}
+	return DefaultManningN
+}
+
+// section returns the full-bore flow area (m²) and wetted perimeter (m).
+func section(p models.Pipe) (shape string, area, perimeter float64, ok bool) {
+	if strings.EqualFold(p.Shape, "BoxCulvert") {
+		if p.DiameterMM == nil || p.HeightMM == nil {
+			return "box", 0, 0, false
+		}
+		w, h := *p.DiameterMM/1000, *p.HeightMM/1000
+		return "box", w * h, 2 * (w + h), true
+	}
+	if p.DiameterMM == nil {
+		return "circular", 0, 0, false
+	}
+	d := *p.DiameterMM / 1000
+	return "circular", math.Pi * d * d / 4, math.Pi * d, true
+}
+
+// Analyse computes the capacity of p and checks its grade and its inverts
+// against the pipes connected at either end. g may be nil to skip the
+// connection checks.
+func Analyse(g *network.Graph, p models.Pipe) Result {
+	r := Result{
+		PipeID:   p.ID,
+		CompKey:  p.CompKey,
+		FromPit:  p.FromPit,
+		ToPit:    p.ToPit,
+		Material: p.Material,
+		ManningN: Roughness(p.Material),
+		Issues:   []Issue{},
+	}
+
+	invert, hasInvert := InvertSlope(p)
+	if hasInvert {
+		r.InvertSlope = round(invert, 6)
+		r.Slope, r.SlopeSource = r.InvertSlope, "inverts"
+	}
+	if p.Grade != nil {
+		r.RecordedSlope = round(RecordedSlope(*p.Grade), 6)
+		if r.Slope == nil {
+			r.Slope, r.SlopeSource = r.RecordedSlope, "grade"
+		}
+	}
+	if hasInvert && r.RecordedSlope != nil && gradeMismatch(*r.RecordedSlope, invert, p.Length()) {
+		r.addIssue(IssueGradeMismatch, "recorded grade %g (%.4f m/m) disagrees with the inverts (%.4f m/m)",
+			*p.Grade, *r.RecordedSlope, invert)
+	}
+
+	switch s := r.Slope; {
+	case s == nil:
+		r.addIssue(IssueMissingSlope, "no inverts or grade recorded")
+	case *s < 0:
+		r.addIssue(IssueReversedGrade, "slope %.4f m/m falls upstream (from %s)", *s, r.SlopeSource)
+	case *s < MinSlope:
+		r.addIssue(IssueFlatGrade, "slope %.5f m/m is flatter than 1 in %.0f", *s, 1/MinSlope)
+	case *s > MaxSlope:
+		r.addIssue(IssueSteepGrade, "slope %.3f m/m is steeper than 1 in %.0f", *s, 1/MaxSlope)
+	}
+
+	shape, area, perimeter, ok := section(p)
+	r.Section = shape
+	if !ok {
+		r.addIssue(IssueMissingSize, "no %s dimensions recorded", shape)
+	} else {
+		radius := area / perimeter
+		r.AreaM2 = round(area, 4)
+		r.HydraulicRadM = round(radius, 4)
+		if r.Slope != nil && *r.Slope > 0 {
+			velocity := math.Pow(radius, 2.0/3) * math.Sqrt(*r.Slope) / r.ManningN
+			r.VelocityMS = round(velocity, 3)
+			r.CapacityM3S = round(velocity*area*float64(max(p.ParallelLines, 1)), 4)
+		}
+	}
+
+	if g != nil {
+		r.checkInverts(g, p)
+	}
+	return r
+}
+
+// gradeMismatch reports whether a recorded slope disagrees with the invert
+// slope beyond centimetre rounding of the levels and a 25% margin.
+func gradeMismatch(recorded, invert, length float64) bool {
+	tolerance := 0.25*math.Abs(invert) + 0.01/length
+	return math.Abs(recorded-invert) > tolerance
+}
+
+// checkInverts flags steps up at either end: a downstream pipe whose inlet
+// sits above this pipe's outlet, or this pipe's inlet above an upstream outlet.
+func (r *Result) checkInverts(g *network.Graph, p models.Pipe) {
+	if p.DownInvert != nil {
+		for _, next := range g.From(p.ToPit) {
+			if next.ID == p.ID || next.UpInvert == nil {
+				continue
+			}
+			if step := *next.UpInvert - *p.DownInvert; step > InvertTolerance {
+				r.Issues = append(r.Issues, Issue{
+					Code:          IssueInvertStep,
+					Message:       fmt.Sprintf("downstream pipe %d starts %.2f m above this outlet at %s", next.ID, step, p.ToPit),
+					RelatedPipeID: next.ID,
+				})
+			}
+		}
+	}
+	if p.UpInvert != nil {
+		for _, prev := range g.Into(p.FromPit) {
+			if prev.ID == p.ID || prev.DownInvert == nil {
+				continue
+			}
+			if step := *p.UpInvert - *prev.DownInvert; step > InvertTolerance {
+				r.Issues = append(r.Issues, Issue{
+					Code:          IssueInvertStep,
+					Message:       fmt.Sprintf("inlet sits %.2f m above the outlet of upstream pipe %d at %s", step, prev.ID, p.FromPit),
+					RelatedPipeID: prev.ID,
+				})
+			}
+		}
+	}
+}
+
+func (r *Result) addIssue(code, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// QA analyses every pipe in g and returns the flagged ones.
+func QA(g *network.Graph) *Report {
+	report := &Report{Pipes: g.Len(), Issues: make(map[string]int), Results: []Result{}}
+	for _, p := range g.Pipes() {
+		r := Analyse(g, p)
+		if len(r.Issues) == 0 {
+			continue
+		}
+		report.Flagged++
+		counted := make(map[string]bool)
+		for _, issue := range r.Issues {
+			if !counted[issue.Code] {
+				counted[issue.Code] = true
+				report.Issues[issue.Code]++
+			}
+		}
+		report.Results = append(report.Results, r)
+	}
+	sort.Slice(report.Results, func(i, j int) bool { return report.Results[i].PipeID < report.Results[j].PipeID })
+	return report
+}
+
+// HasIssue reports whether r carries an issue with the given code.
+func (r Result) HasIssue(code string) bool {
+	for _, issue := range r.Issues {
+		if issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func round(v float64, places int) *float64 {
+	scale := math.Pow(10, float64(places))
+	v = math.Round(v*scale) / scale
+	return &v
+}
@@ -0,0 +1,220 @@
+package hydraulics
+
+import (
+	"GeoGO/models"
+	"GeoGO/network"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func issueCodes(r Result) []string {
+	var codes []string
+	for _, issue := range r.Issues {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestRecordedSlope(t *testing.T) {
+	tests := []struct {
+		grade, want float64
+	}{
+		{200, 0.005},
+		{0.005, 0.005},
+		{178.5, 1 / 178.5},
+		{1, 1},
+		{0.99, 0.99},
+		// The register stores reversed grades as negative "1 in X"
+		{-499.5, -1 / 499.5},
+		{-0.004, -0.004},
+	}
+	for _, tt := range tests {
+		if got := RecordedSlope(tt.grade); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("RecordedSlope(%v) = %v, want %v", tt.grade, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyse(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipe     models.Pipe
+		section  string
+		slope    *float64
+		source   string
+		velocity *float64
+		capacity *float64
+		issues   []string
+	}{
+		{
+			name:     "300 RC at 1 in 200",
+			pipe:     models.Pipe{Material: "RC", DiameterMM: ptr(300), LengthM: ptr(20), Grade: ptr(200)},
+			section:  "circular",
+			slope:    ptr(0.005),
+			source:   "grade",
+			velocity: ptr(0.967),
+			capacity: ptr(0.0684),
+		},
+		{
+			name:     "1200x600 box culvert at 1 in 100",
+			pipe:     models.Pipe{Shape: "BoxCulvert", Material: "CONC", DiameterMM: ptr(1200), HeightMM: ptr(600), LengthM: ptr(20), Grade: ptr(0.01)},
+			section:  "box",
+			slope:    ptr(0.01),
+			source:   "grade",
+			velocity: ptr(2.631),
+			capacity: ptr(1.8941),
+		},
+		{
+			name:     "twin 375 PVC from inverts",
+			pipe:     models.Pipe{Material: "pvc", ParallelLines: 2, DiameterMM: ptr(375), LengthM: ptr(50), UpInvert: ptr(10.1), DownInvert: ptr(10)},
+			section:  "circular",
+			slope:    ptr(0.002),
+			source:   "inverts",
+			velocity: ptr(1.025),
+			capacity: ptr(0.2265),
+		},
+		{
+			// Register pipe 1 (WcwWint0034MH to WcwWint0036MH): no inverts, grade -499.5
+			name:    "register reversed grade",
+			pipe:    models.Pipe{Material: "RC", DiameterMM: ptr(300), LengthM: ptr(29.33), Grade: ptr(-499.5)},
+			section: "circular",
+			slope:   ptr(-0.002002),
+			source:  "grade",
+			issues:  []string{IssueReversedGrade},
+		},
+		{
+			name:    "flat",
+			pipe:    models.Pipe{DiameterMM: ptr(300), LengthM: ptr(20), Grade: ptr(5000)},
+			section: "circular",
+			slope:   ptr(0.0002),
+			source:  "grade",
+			// Still flowing, just flagged
+			velocity: ptr(0.193),
+			capacity: ptr(0.0137),
+			issues:   []string{IssueFlatGrade},
+		},
+		{
+			name:     "steep",
+			pipe:     models.Pipe{DiameterMM: ptr(300), LengthM: ptr(20), Grade: ptr(2)},
+			section:  "circular",
+			slope:    ptr(0.5),
+			source:   "grade",
+			velocity: ptr(9.673),
+			capacity: ptr(0.6838),
+			issues:   []string{IssueSteepGrade},
+		},
+		{
+			name:    "no slope or size",
+			pipe:    models.Pipe{Shape: "BoxCulvert", DiameterMM: ptr(600)},
+			section: "box",
+			issues:  []string{IssueMissingSlope, IssueMissingSize},
+		},
+		{
+			// Inverts fall 0.1 m over 20 m (1 in 200); the grade says 1 in 50
+			name:     "grade disagrees with inverts",
+			pipe:     models.Pipe{DiameterMM: ptr(300), LengthM: ptr(20), UpInvert: ptr(5.1), DownInvert: ptr(5), Grade: ptr(50)},
+			section:  "circular",
+			slope:    ptr(0.005),
+			source:   "inverts",
+			velocity: ptr(0.967),
+			capacity: ptr(0.0684),
+			issues:   []string{IssueGradeMismatch},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Analyse(nil, tt.pipe)
+			if r.Section != tt.section {
+				t.Errorf("section = %q, want %q", r.Section, tt.section)
+			}
+			if !reflect.DeepEqual(r.Slope, tt.slope) || r.SlopeSource != tt.source {
+				t.Errorf("slope = %v from %q, want %v from %q", deref(r.Slope), r.SlopeSource, deref(tt.slope), tt.source)
+			}
+			if !reflect.DeepEqual(r.VelocityMS, tt.velocity) {
+				t.Errorf("velocity = %v, want %v", deref(r.VelocityMS), deref(tt.velocity))
+			}
+			if !reflect.DeepEqual(r.CapacityM3S, tt.capacity) {
+				t.Errorf("capacity = %v, want %v", deref(r.CapacityM3S), deref(tt.capacity))
+			}
+			if got := issueCodes(r); !reflect.DeepEqual(got, tt.issues) {
+				t.Errorf("issues = %v, want %v", got, tt.issues)
+			}
+		})
+	}
+}
+
+// deref shows a nil pointer as nil in messages.
+func deref(p *float64) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func TestGradeMismatch(t *testing.T) {
+	tests := []struct {
+		name                     string
+		recorded, invert, length float64
+		want                     bool
+	}{
+		{"same", 0.005, 0.005, 20, false},
+		{"within 25%", 0.006, 0.005, 100, false},
+		{"beyond 25%", 0.007, 0.005, 100, true},
+		// 1 cm over 2 m is 0.005 m/m of rounding in the levels
+		{"short pipe rounding", 0.01, 0.005, 2, false},
+		{"opposite signs", -0.002, 0.002, 100, true},
+		{"1 in 200 in either convention", RecordedSlope(0.005), RecordedSlope(200), 50, false},
+	}
+	for _, tt := range tests {
+		if got := gradeMismatch(tt.recorded, tt.invert, tt.length); got != tt.want {
+			t.Errorf("%s: gradeMismatch(%v, %v, %v) = %v, want %v", tt.name, tt.recorded, tt.invert, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestCheckInverts(t *testing.T) {
+	pipe := func(id int, from, to string, up, down float64) models.Pipe {
+		return models.Pipe{ID: id, FromPit: from, ToPit: to, DiameterMM: ptr(300), LengthM: ptr(20), UpInvert: ptr(up), DownInvert: ptr(down)}
+	}
+	// B: pipe 2 starts 5 cm above the outlet of pipe 1; C: pipe 3 starts
+	// 1 cm above the outlet of pipe 2, within the levels' rounding
+	g := network.New([]models.Pipe{
+		pipe(1, "A", "B", 10.2, 10.1),
+		pipe(2, "B", "C", 10.15, 10.05),
+		pipe(3, "C", "D", 10.06, 9.96),
+	})
+	tests := []struct {
+		id      int
+		related []int
+	}{
+		{1, []int{2}},
+		{2, []int{1}},
+		{3, nil},
+	}
+	for _, tt := range tests {
+		var p models.Pipe
+		for _, q := range g.Pipes() {
+			if q.ID == tt.id {
+				p = q
+			}
+		}
+		r := Analyse(g, p)
+		var related []int
+		for _, issue := range r.Issues {
+			if issue.Code == IssueInvertStep {
+				related = append(related, issue.RelatedPipeID)
+			}
+		}
+		if !reflect.DeepEqual(related, tt.related) {
+			t.Errorf("pipe %d: invert steps against %v, want %v (%v)", tt.id, related, tt.related, r.Issues)
+		}
+	}
+
+	report := QA(g)
+	if report.Pipes != 3 || report.Flagged != 2 || report.Issues[IssueInvertStep] != 2 {
+		t.Errorf("report = %d pipes, %d flagged, issues %v", report.Pipes, report.Flagged, report.Issues)
+	}
+}
@@ -0,0 +1,149 @@
+// geojson.go
+//
+// GeoJSON FeatureCollection import
+// Compliance Level: Moderate
+//
+// - A .geojson file is mapped by its own Source (e.g. Catchments_*.geojson),
+//   else by the Source of the CSV with the same name, so
+//   VegetationZones_*.geojson uses the vegetation column mapping; feature
+//   properties play the role of CSV columns
+// - Feature geometries are kept: lines and polygons are stored as shapes with
+//   a representative point in lat/lon, points replace missing coordinate columns
+// - Report "lines" are 1-based feature numbers
+//
+// NOTE: The whole file is decoded at once; GeoJSON exports of council layers
+// are small
+
+package ingest
+
+import (
+	"GeoGO/db"
+	"GeoGO/geo"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func isGeoJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".geojson")
+}
+
+// geoJSONSource returns the Source matching the GeoJSON file itself, or the
+// one whose CSV files share its name.
+func geoJSONSource(path string) *Source {
+	if src := DetectSource(path); src != nil {
+		return src
+	}
+	return DetectSource(strings.TrimSuffix(path, filepath.Ext(path)) + ".csv")
+}
+
+func prefixGeoJSON(prefix string) func(string) bool {
+	return func(base string) bool {
+		return strings.HasPrefix(base, prefix) && isGeoJSON(base)
+	}
+}
+
+type geoJSONFeature struct {
+	Geometry   json.RawMessage            `json:"geometry"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// readGeoJSON passes every feature of a FeatureCollection to row as a Record
+// carrying the feature geometry, recording each feature's outcome.
+func readGeoJSON(ctx context.Context, path string, report *FileReport, row func(r *Record) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fc struct {
+		Type     string           `json:"type"`
+		Features []geoJSONFeature `json:"features"`
+	}
+	if err := json.Unmarshal(data, &fc); err != nil {
+		return fmt.Errorf("decode GeoJSON: %w", err)
+	}
+	if fc.Type != "FeatureCollection" {
+		return fmt.Errorf("expected a FeatureCollection, got %q", fc.Type)
+	}
+
+	for i, f := range fc.Features {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line := i + 1
+		r, err := featureRecord(line, f)
+		if err == nil {
+			err = row(r)
+		}
+		if err := report.outcome(line, err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// featureRecord turns feature properties into a Record, sorted by name.
+func featureRecord(line int, f geoJSONFeature) (*Record, error) {
+	names := make([]string, 0, len(f.Properties))
+	for name := range f.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = propertyText(f.Properties[name])
+	}
+	header, index := newHeader(names)
+	r := &Record{Line: line, header: header, index: index, fields: fields}
+
+	raw := bytes.TrimSpace(f.Geometry)
+	if len(raw) > 0 && !bytes.Equal(raw, []byte("null")) {
+		g, err := geo.ParseGeometry(raw)
+		if err != nil {
+			return nil, reject("invalid geometry")
+		}
+		r.Geometry = g
+	}
+	return r, nil
+}
+
+// propertyText renders a property value as CSV-like text; null becomes "".
+func propertyText(raw json.RawMessage) string {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return ""
+	}
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	default:
+		return string(raw)
+	}
+}
+
+// withFeatureGeometry attaches the feature's shape to a mapped row. Lines and
+// polygons move lat/lon to a point on the shape.
+func withFeatureGeometry(r *Record, rec db.DatasetRecord) db.DatasetRecord {
+	if r.Geometry == nil || rec.Geometry != nil {
+		return rec
+	}
+	rec.Geometry = r.Geometry
+	if !r.Geometry.IsPoint() {
+		rec.Lat, rec.Lon = r.Geometry.Representative()
+	}
+	return rec
+}
@@ -0,0 +1,411 @@
+// ingest.go
+//
+// Streaming dataset import for GeoGO
+// Loads the CSV, GeoJSON and XLSX source files in data/Datasets into the datasets table.
+// Compliance Level: High
+//
+// - Files are read row by row and appended to an open db.DatasetImport, so
+//   memory use does not grow with file size
+// - CSV and GeoJSON files are matched to a Source by name, workbooks to
+//   SheetMappings; unknown files are skipped
+// - Byte-identical files (e.g. browser "(1)" re-downloads) are imported once
+// - Rejected rows are counted per reason with the first few line numbers
+// - With Replace, existing rows of each imported type are deleted first
+//   inside the same transaction
+//
+// NOTE: A file that cannot be opened or read aborts the run; bad rows never do
+
+package ingest
+
+import (
+	"GeoGO/db"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxReasonLines is how many example line numbers are kept per reject reason.
+const maxReasonLines = 5
+
+// Options controls an import run.
+type Options struct {
+	// Types limits the run to these dataset types (all when empty)
+	Types []string
+	// FallbackLocation is the lat,lon used for rows of files without coordinates
+	FallbackLocation *[2]float64
+	// Replace deletes existing rows of each imported type first
+	Replace bool
+	// Workbooks are extra sheet mappings, tried before DefaultWorkbooks
+	Workbooks []SheetMapping
+	// Geocode resolves sheet join keys to coordinates (optional)
+	Geocode func(location string) (lat, lon float64, err error)
+
+	// file is the base name of the file being mapped, set by Run
+	file string
+	// geocoded caches Geocode results for the run
+	geocoded map[string]geocodeResult
+}
+
+type geocodeResult struct {
+	lat, lon float64
+	err      error
+}
+
+// geocode resolves a join key once per run.
+func (o Options) geocode(location string) (float64, float64, error) {
+	if o.Geocode == nil {
+		return 0, 0, errors.New("no geocoder configured")
+	}
+	if r, ok := o.geocoded[location]; ok {
+		return r.lat, r.lon, r.err
+	}
+	lat, lon, err := o.Geocode(location)
+	if o.geocoded != nil {
+		o.geocoded[location] = geocodeResult{lat, lon, err}
+	}
+	return lat, lon, err
+}
+
+func (o Options) wants(datasetType string) bool {
+	if len(o.Types) == 0 {
+		return true
+	}
+	for _, t := range o.Types {
+		if t == datasetType {
+			return true
+		}
+	}
+	return false
+}
+
+// RejectReason counts the rows rejected for one reason.
+type RejectReason struct {
+	Reason string
+	Count  int
+	Lines  []int
+}
+
+// FileReport summarises one input file.
+type FileReport struct {
+	Path     string
+	Sheet    string
+	Type     string
+	Skipped  string
+	Accepted int
+	Rejected int
+	Reasons  []*RejectReason
+}
+
+func (f *FileReport) rejectRow(line int, reason string) {
+	f.Rejected++
+	for _, r := range f.Reasons {
+		if r.Reason == reason {
+			r.Count++
+			if len(r.Lines) < maxReasonLines {
+				r.Lines = append(r.Lines, line)
+			}
+			return
+		}
+	}
+	f.Reasons = append(f.Reasons, &RejectReason{Reason: reason, Count: 1, Lines: []int{line}})
+}
+
+// Report summarises an import run.
+type Report struct {
+	Files   []*FileReport
+	Deleted map[string]int64
+}
+
+// Totals returns the accepted and rejected row counts over all files.
+func (r *Report) Totals() (accepted, rejected int) {
+	for _, f := range r.Files {
+		accepted += f.Accepted
+		rejected += f.Rejected
+	}
+	return accepted, rejected
+}
+
+// Print writes a human-readable summary.
+func (r *Report) Print(w io.Writer) {
+	for _, f := range r.Files {
+		name := filepath.Base(f.Path)
+		if f.Sheet != "" {
+			name += " [" + f.Sheet + "]"
+		}
+		if f.Skipped != "" {
+			fmt.Fprintf(w, "⏭️  %s: skipped (%s)\n", name, f.Skipped)
+			continue
+		}
+		fmt.Fprintf(w, "📄 %s -> %s: %d accepted, %d rejected\n", name, f.Type, f.Accepted, f.Rejected)
+		sort.SliceStable(f.Reasons, func(i, j int) bool { return f.Reasons[i].Count > f.Reasons[j].Count })
+		for _, reason := range f.Reasons {
+			fmt.Fprintf(w, "     %6d  %s (lines %s)\n", reason.Count, reason.Reason, formatLines(reason.Lines, reason.Count))
+		}
+	}
+	types := make([]string, 0, len(r.Deleted))
+	for t := range r.Deleted {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		fmt.Fprintf(w, "🗑️  Replaced %d existing %s rows\n", r.Deleted[t], t)
+	}
+	accepted, rejected := r.Totals()
+	fmt.Fprintf(w, "✅ %d rows accepted, %d rejected\n", accepted, rejected)
+}
+
+func formatLines(lines []int, total int) string {
+	parts := make([]string, len(lines))
+	for i, l := range lines {
+		parts[i] = fmt.Sprint(l)
+	}
+	s := strings.Join(parts, ", ")
+	if total > len(lines) {
+		s += ", ..."
+	}
+	return s
+}
+
+// importer loads one CSV file or one workbook sheet into one table.
+type importer struct {
+	typ     string
+	run     func(ctx context.Context, imp db.DatasetImport, opts Options, report *FileReport) error
+	replace func(imp db.DatasetImport) (int64, error)
+}
+
+func datasetImporter(typ string, run func(ctx context.Context, imp db.DatasetImport, opts Options, report *FileReport) error) importer {
+	return importer{typ: typ, run: run, replace: func(imp db.DatasetImport) (int64, error) {
+		return imp.DeleteType(typ)
+	}}
+}
+
+// importersFor returns the importers that apply to a file.
+func importersFor(path string, opts Options) []importer {
+	var out []importer
+	if isWorkbook(path) {
+		for _, m := range opts.sheetMappings(filepath.Base(path)) {
+			out = append(out, datasetImporter(m.Type, func(ctx context.Context, imp db.DatasetImport, opts Options, report *FileReport) error {
+				return importSheet(ctx, imp, path, m, opts, report)
+			}))
+		}
+		return out
+	}
+	if isGeoJSON(path) {
+		if src := geoJSONSource(path); src != nil {
+			out = append(out, datasetImporter(src.Type, func(ctx context.Context, imp db.DatasetImport, opts Options, report *FileReport) error {
+				return readGeoJSON(ctx, path, report, func(r *Record) error {
+					rec, err := src.Map(r, opts)
+					if err != nil {
+						return err
+					}
+					return appendDataset(imp, withFeatureGeometry(r, rec))
+				})
+			}))
+		}
+		return out
+	}
+	if src := DetectSource(path); src != nil {
+		out = append(out, datasetImporter(src.Type, func(ctx context.Context, imp db.DatasetImport, opts Options, report *FileReport) error {
+			return readCSV(ctx, path, report, func(r *Record) error {
+				rec, err := src.Map(r, opts)
+				if err != nil {
+					return err
+				}
+				return appendDataset(imp, rec)
+			})
+		}))
+	}
+	if isPipeNetwork(path) {
+		out = append(out, pipeNetworkImporter(path))
+	}
+	return out
+}
+
+// Run imports every supported file under paths. imp may be nil for a dry run,
+// in which case rows are mapped and validated but not written.
+func Run(ctx context.Context, imp db.DatasetImport, paths []string, opts Options) (*Report, error) {
+	files, err := expandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Deleted: make(map[string]int64)}
+	seen := make(map[[sha256.Size]byte]string)
+	opts.geocoded = make(map[string]geocodeResult)
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		importers := importersFor(path, opts)
+		if len(importers) == 0 {
+			report.Files = append(report.Files, &FileReport{Path: path, Skipped: "unsupported file"})
+			continue
+		}
+		var selected []importer
+		for _, im := range importers {
+			if opts.wants(im.typ) {
+				selected = append(selected, im)
+			} else {
+				report.Files = append(report.Files, &FileReport{Path: path, Type: im.typ, Skipped: "type " + im.typ + " not selected"})
+			}
+		}
+		if len(selected) == 0 {
+			continue
+		}
+		sum, err := fileHash(path)
+		if err != nil {
+			return report, err
+		}
+		if first, dup := seen[sum]; dup {
+			report.Files = append(report.Files, &FileReport{Path: path, Skipped: "duplicate of " + filepath.Base(first)})
+			continue
+		}
+		seen[sum] = path
+
+		opts.file = filepath.Base(path)
+		for _, im := range selected {
+			file := &FileReport{Path: path, Type: im.typ}
+			report.Files = append(report.Files, file)
+			if opts.Replace && imp != nil {
+				if _, done := report.Deleted[im.typ]; !done {
+					n, err := im.replace(imp)
+					if err != nil {
+						return report, fmt.Errorf("delete %s rows: %w", im.typ, err)
+					}
+					report.Deleted[im.typ] = n
+				}
+			}
+			if err := im.run(ctx, imp, opts, file); err != nil {
+				return report, fmt.Errorf("%s: %w", filepath.Base(path), err)
+			}
+			if imp != nil {
+				if err := imp.Flush(); err != nil {
+					return report, fmt.Errorf("%s: %w", filepath.Base(path), err)
+				}
+			}
+		}
+	}
+	return report, nil
+}
+
+// readCSV streams a CSV file through row, recording each row's outcome.
+func readCSV(ctx context.Context, path string, report *FileReport, row func(r *Record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	names, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("read header: %w", err)
+	}
+	header, index := newHeader(names)
+
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.rejectRow(parseErr.StartLine, "malformed CSV row")
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		err = row(&Record{Line: line, header: header, index: index, fields: fields})
+		if err := report.outcome(line, err); err != nil {
+			return err
+		}
+	}
+}
+
+// outcome counts a row as accepted or rejected; other errors abort the run.
+func (f *FileReport) outcome(line int, err error) error {
+	var rejected *RejectError
+	if errors.As(err, &rejected) {
+		f.rejectRow(line, rejected.Reason)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("line %d: %w", line, err)
+	}
+	f.Accepted++
+	return nil
+}
+
+// appendDataset queues a row unless this is a dry run.
+func appendDataset(imp db.DatasetImport, rec db.DatasetRecord) error {
+	if imp == nil {
+		return nil
+	}
+	return imp.Append(rec)
+}
+
+// expandPaths replaces directories by their regular files, sorted by name
+// without extension so "x.csv" is read before its copy "x(1).csv".
+func expandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, e := range entries {
+			if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+				names = append(names, e.Name())
+			}
+		}
+		sort.SliceStable(names, func(i, j int) bool { return stem(names[i]) < stem(names[j]) })
+		for _, n := range names {
+			files = append(files, filepath.Join(p, n))
+		}
+	}
+	return files, nil
+}
+
+func stem(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+func fileHash(path string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	f, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
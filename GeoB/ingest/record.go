@@ -0,0 +1,168 @@
+// record.go
+//
+// CSV record access for the dataset importers
+// Compliance Level: Moderate
+//
+// - Columns are looked up by header name, so column order in the files does not matter
+// - A leading UTF-8 byte order mark (ArcGIS exports) is stripped from the header
+// - Helpers return *RejectError with a short, stable reason so reports can
+//   group rejected rows
+//
+// NOTE: Header names are matched exactly (after trimming spaces)
+
+package ingest
+
+import (
+	"GeoGO/geo"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RejectError marks a row as rejected; Reason is grouped in the import report.
+type RejectError struct {
+	Reason string
+}
+
+func (e *RejectError) Error() string {
+	return e.Reason
+}
+
+func reject(format string, args ...interface{}) error {
+	return &RejectError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Record is one CSV row (or GeoJSON feature) addressed by header name.
+type Record struct {
+	Line int
+	// Geometry is the feature geometry of GeoJSON rows, nil for CSV
+	Geometry *geo.Geometry
+	header   []string
+	index    map[string]int
+	fields   []string
+}
+
+// newHeader builds the column index, stripping a BOM from the first name.
+func newHeader(names []string) ([]string, map[string]int) {
+	header := make([]string, len(names))
+	index := make(map[string]int, len(names))
+	for i, n := range names {
+		n = strings.TrimSpace(strings.TrimPrefix(n, "\ufeff"))
+		header[i] = n
+		if _, dup := index[n]; !dup && n != "" {
+			index[n] = i
+		}
+	}
+	return header, index
+}
+
+// Get returns the trimmed value of a column, or "" when absent.
+func (r *Record) Get(column string) string {
+	i, ok := r.index[column]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+// Float parses an optional numeric column; empty values yield nil.
+func (r *Record) Float(column string) (*float64, error) {
+	v := r.Get(column)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, reject("invalid %s", column)
+	}
+	return &f, nil
+}
+
+// Coordinates parses and validates a latitude/longitude column pair. GeoJSON
+// rows without the columns use a point on the feature geometry.
+func (r *Record) Coordinates(latColumn, lonColumn string) (float64, float64, error) {
+	lat, err := r.Float(latColumn)
+	if err != nil {
+		return 0, 0, reject("invalid coordinates")
+	}
+	lon, err := r.Float(lonColumn)
+	if err != nil {
+		return 0, 0, reject("invalid coordinates")
+	}
+	if lat == nil && lon == nil && r.Geometry != nil {
+		lat, lon := r.Geometry.Representative()
+		return lat, lon, nil
+	}
+	if lat == nil || lon == nil {
+		return 0, 0, reject("missing coordinates")
+	}
+	if *lat < -90 || *lat > 90 || *lon < -180 || *lon > 180 {
+		return 0, 0, reject("coordinates out of range")
+	}
+	if *lat == 0 && *lon == 0 {
+		return 0, 0, reject("coordinates are 0,0 (unknown location)")
+	}
+	return *lat, *lon, nil
+}
+
+// Metadata returns every non-empty column except those listed, keyed by
+// snake_case header name. Numeric values are stored as numbers unless they
+// have leading zeros (identifiers such as station numbers).
+func (r *Record) Metadata(exclude ...string) map[string]interface{} {
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		skip[e] = true
+	}
+	out := make(map[string]interface{})
+	for i, name := range r.header {
+		if name == "" || skip[name] || i >= len(r.fields) {
+			continue
+		}
+		v := strings.TrimSpace(r.fields[i])
+		if v == "" {
+			continue
+		}
+		out[snakeCase(name)] = scalar(v)
+	}
+	return out
+}
+
+// scalar converts numeric strings to float64, keeping identifiers with leading zeros.
+func scalar(v string) interface{} {
+	if len(v) > 1 && v[0] == '0' && v[1] != '.' {
+		return v
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+	return v
+}
+
+// snakeCase turns a header such as "Pipe Unit ID" or "mass (g)" into "pipe_unit_id" / "mass_g".
+// A percent sign becomes the word "pct" so "Rural (%)" and "Rural (km^2)" stay distinct.
+func snakeCase(s string) string {
+	var b strings.Builder
+	pendingSep := false
+	for _, c := range s {
+		if c == '%' {
+			if b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteString("pct")
+			pendingSep = true
+			continue
+		}
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			if pendingSep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pendingSep = false
+			b.WriteRune(unicode.ToLower(c))
+		} else {
+			pendingSep = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,367 @@
+// sources.go
+//
+// Per-file column mappings for the bundled datasets
+// Compliance Level: High
+//
+// - Each Source recognises its files by name and maps one CSV row to one datasets row
+// - Mappings mirror utils/process_datasets.py so imported rows look the same
+//   to the API as rows loaded from the generated SQL files
+// - Columns that are not mapped to a table column are kept in metadata
+//
+// Supported Files:
+// - Meteorite_Landings.csv                    -> meteorite (value = mass in g)
+// - <variable>_aus-station_*.csv              -> climate (value = Annual; tas, tasmax,
+//                                                tasmin, hurs15, pan-evap; every season
+//                                                and month kept in metadata)
+// - wind-observations*.csv                    -> wind (value = average_wind_speed; rows need
+//                                                date_time, cardinal derived when missing)
+// - VegetationZones_*.csv                     -> vegetation (value = SHAPE_area)
+// - INF_DRN_PIPES_*.csv                       -> infrastructure (value = Diameter in mm)
+// - Catchments_*.geojson                      -> catchment boundaries (value = SHAPE_area)
+//
+// NOTE: The vegetation and drainage pipe CSV exports carry no coordinates; their
+// rows are rejected unless a fallback location is configured; rows placed there
+// are left out of spatial queries (see db/repository.go). The GeoJSON
+// exports of the same layers (e.g. VegetationZones_*.geojson) carry their
+// shapes and need no fallback (see geojson.go)
+
+package ingest
+
+import (
+	"GeoGO/db"
+	"GeoGO/models"
+	"GeoGO/wind"
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source maps the rows of one family of files into the datasets table.
+type Source struct {
+	Name  string
+	Type  string
+	Match func(base string) bool
+	Map   func(r *Record, opts Options) (db.DatasetRecord, error)
+}
+
+// Sources lists every supported file family, most specific first.
+var Sources = []Source{
+	{
+		Name:  "NASA meteorite landings",
+		Type:  "meteorite",
+		Match: func(base string) bool { return strings.EqualFold(base, "Meteorite_Landings.csv") },
+		Map:   mapMeteorite,
+	},
+	{
+		Name:  "CSIRO climate station projections",
+		Type:  "climate",
+		Match: func(base string) bool { return climateVariable(base) != "" },
+		Map:   mapClimate,
+	},
+	{
+		Name:  "Wind observations",
+		Type:  "wind",
+		Match: prefixCSV("wind-observations"),
+		Map:   mapWind,
+	},
+	{
+		Name:  "Vegetation zones",
+		Type:  "vegetation",
+		Match: prefixCSV("VegetationZones_"),
+		Map:   mapVegetation,
+	},
+	{
+		Name:  "Drainage pipes",
+		Type:  "infrastructure",
+		Match: prefixCSV("INF_DRN_PIPES_"),
+		Map:   mapPipe,
+	},
+	{
+		Name:  "Catchment boundaries",
+		Type:  "catchment",
+		Match: prefixGeoJSON("Catchments_"),
+		Map:   mapCatchmentBoundary,
+	},
+}
+
+// DetectSource returns the source for a file name, or nil.
+func DetectSource(path string) *Source {
+	base := filepath.Base(path)
+	for i := range Sources {
+		if Sources[i].Match(base) {
+			return &Sources[i]
+		}
+	}
+	return nil
+}
+
+func prefixCSV(prefix string) func(string) bool {
+	return func(base string) bool {
+		return strings.HasPrefix(base, prefix) && strings.EqualFold(filepath.Ext(base), ".csv")
+	}
+}
+
+func mapMeteorite(r *Record, _ Options) (db.DatasetRecord, error) {
+	rec := db.DatasetRecord{
+		Type:     "meteorite",
+		Name:     r.Get("name"),
+		Unit:     "g",
+		Recclass: r.Get("recclass"),
+		Nametype: r.Get("nametype"),
+		Fall:     r.Get("fall"),
+	}
+	if rec.Name == "" {
+		return rec, reject("missing name")
+	}
+	var err error
+	if rec.Lat, rec.Lon, err = r.Coordinates("reclat", "reclong"); err != nil {
+		return rec, err
+	}
+	if rec.Mass, err = r.Float("mass (g)"); err != nil {
+		return rec, err
+	}
+	if rec.Mass != nil && *rec.Mass < 0 {
+		return rec, reject("negative mass")
+	}
+	rec.Value = rec.Mass
+	if y := r.Get("year"); y != "" {
+		year, err := strconv.Atoi(y)
+		if err != nil {
+			return rec, reject("invalid year")
+		}
+		rec.Year = &year
+	}
+
+	rec.Metadata = map[string]interface{}{
+		"source_id": r.Get("id"),
+		"recclass":  rec.Recclass,
+		"nametype":  rec.Nametype,
+		"fall":      rec.Fall,
+	}
+	if rec.Mass != nil {
+		rec.Metadata["mass"] = *rec.Mass
+	}
+	if rec.Year != nil {
+		rec.Metadata["year"] = *rec.Year
+	}
+	return rec, nil
+}
+
+// climateVariables maps the file name prefix to the climate_type and unit
+// used by process_datasets.py.
+var climateVariables = map[string][2]string{
+	"tas":      {"avg_temperature", "°C"},
+	"tasmax":   {"max_temperature", "°C"},
+	"tasmin":   {"min_temperature", "°C"},
+	"hurs15":   {"humidity", "%"},
+	"pan-evap": {"evaporation", "mm"},
+}
+
+// climateVariable returns the variable prefix of a climate station file, or "".
+func climateVariable(base string) string {
+	variable, _, ok := strings.Cut(base, "_aus-station_")
+	if !ok || !strings.EqualFold(filepath.Ext(base), ".csv") {
+		return ""
+	}
+	if _, known := climateVariables[variable]; !known {
+		return ""
+	}
+	return variable
+}
+
+func mapClimate(r *Record, opts Options) (db.DatasetRecord, error) {
+	info := climateVariables[climateVariable(opts.file)]
+	climateType, unit := info[0], info[1]
+	station := r.Get("STATION_NAME")
+	rec := db.DatasetRecord{Type: "climate", Name: climateType + "_" + station, Unit: unit}
+	if station == "" {
+		return rec, reject("missing STATION_NAME")
+	}
+	var err error
+	if rec.Lat, rec.Lon, err = r.Coordinates("LAT", "LON"); err != nil {
+		return rec, err
+	}
+	if rec.Value, err = r.Float("Annual"); err != nil {
+		return rec, err
+	}
+
+	rec.Metadata = map[string]interface{}{
+		"station_name": station,
+		"station_id":   r.Get("STN_ID"),
+		"model":        r.Get("MODEL"),
+		"rcp":          r.Get("RCP"),
+		"ensemble":     r.Get("ENSEMBLE"),
+		"climatology":  r.Get("CLIMATOLOGY"),
+		"climate_type": climateType,
+		"unit":         unit,
+	}
+	for _, period := range slices.Concat(models.ClimateSeasons, models.ClimateMonths) {
+		v, err := r.Float(period)
+		if err != nil {
+			return rec, err
+		}
+		if v != nil {
+			rec.Metadata[strings.ToLower(period)] = *v
+		}
+	}
+	return rec, nil
+}
+
+func mapWind(r *Record, _ Options) (db.DatasetRecord, error) {
+	location := r.Get("location_description")
+	rec := db.DatasetRecord{Type: "wind", Name: "wind_" + location, Unit: "m/s"}
+	if location == "" {
+		return rec, reject("missing location_description")
+	}
+	var err error
+	if rec.Lat, rec.Lon, err = r.Coordinates("latitude", "longitude"); err != nil {
+		return rec, err
+	}
+	if rec.Value, err = r.Float("average_wind_speed"); err != nil {
+		return rec, err
+	}
+	if rec.Value == nil {
+		return rec, reject("missing average_wind_speed")
+	}
+	// Observations are only useful in time order, so the timestamp is required
+	v := r.Get("date_time")
+	if v == "" {
+		return rec, reject("missing date_time")
+	}
+	ts, err := parseTime(v)
+	if err != nil {
+		return rec, reject("invalid date_time")
+	}
+	rec.Timestamp = &ts
+	direction, err := r.Float("wind_direction")
+	if err != nil {
+		return rec, err
+	}
+	if direction != nil && (*direction < 0 || *direction > 360) {
+		return rec, reject("wind_direction out of range")
+	}
+	if _, err := r.Float("gust_speed"); err != nil {
+		return rec, err
+	}
+
+	rec.Metadata = r.Metadata("latitude", "longitude", "average_wind_speed")
+	if direction != nil {
+		rec.Metadata["wind_direction"] = math.Mod(*direction, 360)
+		if r.Get("wind_direction_cardinal") == "" {
+			rec.Metadata["wind_direction_cardinal"] = wind.Cardinal(*direction)
+		}
+	}
+	return rec, nil
+}
+
+func mapVegetation(r *Record, opts Options) (db.DatasetRecord, error) {
+	kind := r.Get("Type")
+	rec := db.DatasetRecord{Type: "vegetation", Name: "vegetation_" + kind, Unit: "m²"}
+	if kind == "" {
+		return rec, reject("missing Type")
+	}
+	var err error
+	if rec.Value, err = r.Float("SHAPE_area"); err != nil {
+		return rec, err
+	}
+	rec.Metadata = map[string]interface{}{
+		"zone":         scalar(r.Get("Zone")),
+		"type":         kind,
+		"shape_area":   derefOr(rec.Value, 0),
+		"shape_length": scalar(r.Get("SHAPE_len")),
+		"link":         r.Get("Link"),
+	}
+	return rec, opts.placeWithoutCoordinates(r, &rec)
+}
+
+func mapPipe(r *Record, opts Options) (db.DatasetRecord, error) {
+	// Service lines have no unit ID; fall back to the asset key
+	name := r.Get("Pipe Unit ID")
+	if name == "" && r.Get("COMPKEY") != "" {
+		name = "pipe_" + r.Get("COMPKEY")
+	}
+	rec := db.DatasetRecord{Type: "infrastructure", Name: name, Unit: "mm"}
+	if name == "" {
+		return rec, reject("missing Pipe Unit ID and COMPKEY")
+	}
+	var err error
+	if rec.Value, err = r.Float("Diameter"); err != nil {
+		return rec, err
+	}
+	rec.Metadata = r.Metadata()
+	rec.Metadata["asset"] = "drainage_pipe"
+	return rec, opts.placeWithoutCoordinates(r, &rec)
+}
+
+// catchmentNameColumns are the properties tried, in order, for a catchment name.
+var catchmentNameColumns = []string{"Catchment", "CATCHMENT", "Name", "NAME"}
+
+func mapCatchmentBoundary(r *Record, _ Options) (db.DatasetRecord, error) {
+	rec := db.DatasetRecord{Type: "catchment", Unit: "m²"}
+	for _, column := range catchmentNameColumns {
+		if rec.Name = r.Get(column); rec.Name != "" {
+			break
+		}
+	}
+	if rec.Name == "" {
+		return rec, reject("missing catchment name")
+	}
+	if r.Geometry == nil || !r.Geometry.IsArea() {
+		return rec, reject("boundary is not a polygon")
+	}
+	var err error
+	if rec.Value, err = r.Float("SHAPE_area"); err != nil {
+		return rec, err
+	}
+	rec.Metadata = r.Metadata()
+	rec.Metadata["boundary"] = true
+	rec = withFeatureGeometry(r, rec)
+	return rec, nil
+}
+
+// placeWithoutCoordinates positions rows from files that have no coordinate
+// columns: on the feature geometry for GeoJSON, otherwise at the configured
+// fallback location, flagging them in metadata.
+func (o Options) placeWithoutCoordinates(r *Record, rec *db.DatasetRecord) error {
+	if r.Geometry != nil {
+		*rec = withFeatureGeometry(r, *rec)
+		return nil
+	}
+	if o.FallbackLocation == nil {
+		return reject("no coordinates in source file (set a fallback location)")
+	}
+	rec.Lat, rec.Lon = o.FallbackLocation[0], o.FallbackLocation[1]
+	rec.Metadata["location_source"] = "fallback"
+	return nil
+}
+
+func derefOr(f *float64, def float64) float64 {
+	if f == nil {
+		return def
+	}
+	return *f
+}
+
+// timeLayouts are the date formats seen in the source exports.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"1/2/2006 3:04:05 PM",
+	"02/01/2006 15:04",
+}
+
+func parseTime(v string) (time.Time, error) {
+	var err error
+	for _, layout := range timeLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
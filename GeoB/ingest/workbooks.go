@@ -0,0 +1,282 @@
+// workbooks.go
+//
+// Column mappings for Excel workbooks
+// Compliance Level: High
+//
+// - A SheetMapping says which sheet of which workbook holds a dataset type and
+//   which columns become name, lat/lon, value, unit and metadata
+// - Built-in mappings cover the bundled fire projection and catchment
+//   workbooks; more can be supplied in a YAML or TOML file (-mapping)
+// - Rows without coordinate columns can be placed by a join key, a column
+//   whose value is geocoded (e.g. a town name), or by the fallback location
+//
+// Layouts:
+// - table:   first row is the header, one dataset row per sheet row
+// - profile: one dataset row per sheet; column B holds item names and column C
+//            their values (column A section labels are ignored)
+//
+// NOTE: Join values are geocoded once per run; a trailing parenthesised
+// qualifier such as "Wonthaggi (Catchment)" is dropped before lookup
+
+package ingest
+
+import (
+	"GeoGO/db"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// SheetMapping maps the columns of one workbook sheet to datasets rows.
+type SheetMapping struct {
+	// File is the workbook base name; * and ? wildcards are allowed
+	File string `yaml:"file" toml:"file"`
+	// Sheet is the sheet name (default: first visible sheet)
+	Sheet  string `yaml:"sheet" toml:"sheet"`
+	Type   string `yaml:"type" toml:"type"`
+	Layout string `yaml:"layout" toml:"layout"`
+
+	Name       string `yaml:"name" toml:"name"`
+	NamePrefix string `yaml:"name_prefix" toml:"name_prefix"`
+	Lat        string `yaml:"lat" toml:"lat"`
+	Lon        string `yaml:"lon" toml:"lon"`
+	// Join is geocoded when Lat/Lon are not mapped; JoinSuffix narrows the
+	// lookup (e.g. ", AU")
+	Join       string `yaml:"join" toml:"join"`
+	JoinSuffix string `yaml:"join_suffix" toml:"join_suffix"`
+	Value      string `yaml:"value" toml:"value"`
+	Unit       string `yaml:"unit" toml:"unit"`
+	// Metadata lists the columns kept in metadata (default: every column)
+	Metadata []string `yaml:"metadata" toml:"metadata"`
+}
+
+const (
+	layoutTable   = "table"
+	layoutProfile = "profile"
+)
+
+// DefaultWorkbooks maps the workbooks shipped in data/Datasets.
+var DefaultWorkbooks = []SheetMapping{
+	{
+		File:  "NRM_fire_proj_summary.xlsx",
+		Type:  "fire",
+		Name:  "Site Name",
+		Lat:   "Lat",
+		Lon:   "Lon",
+		Value: "Avg Ann CFFDI",
+		Unit:  "CFFDI",
+	},
+	{
+		File:       "wonthaggi-catchment*.xlsx",
+		Sheet:      "data",
+		Type:       "catchment",
+		Layout:     layoutProfile,
+		Name:       "Community Name",
+		Join:       "Community Name",
+		JoinSuffix: ", AU",
+		Value:      "2012 ERP, total",
+		Unit:       "persons",
+	},
+}
+
+// LoadMappings reads sheet mappings from a YAML or TOML file with a top-level
+// "sheets" list.
+func LoadMappings(path string) ([]SheetMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Sheets []SheetMapping `yaml:"sheets" toml:"sheets"`
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &file)
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	default:
+		return nil, fmt.Errorf("unsupported mapping format %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i, m := range file.Sheets {
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("%s: sheet mapping %d: %w", path, i+1, err)
+		}
+	}
+	return file.Sheets, nil
+}
+
+func (m SheetMapping) validate() error {
+	switch {
+	case m.File == "":
+		return fmt.Errorf("file is required")
+	case m.Type == "":
+		return fmt.Errorf("type is required")
+	case m.Name == "":
+		return fmt.Errorf("name column is required")
+	case (m.Lat == "") != (m.Lon == ""):
+		return fmt.Errorf("lat and lon must be mapped together")
+	case m.Layout != "" && m.Layout != layoutTable && m.Layout != layoutProfile:
+		return fmt.Errorf("unknown layout %q (expected table or profile)", m.Layout)
+	}
+	if _, err := filepath.Match(m.File, ""); err != nil {
+		return fmt.Errorf("invalid file pattern %q", m.File)
+	}
+	return nil
+}
+
+// sheetMappings returns the mappings for a workbook, custom mappings first.
+// Only the first mapping per sheet applies.
+func (o Options) sheetMappings(base string) []SheetMapping {
+	var out []SheetMapping
+	seen := make(map[string]bool)
+	for _, m := range append(append([]SheetMapping(nil), o.Workbooks...), DefaultWorkbooks...) {
+		if ok, _ := filepath.Match(m.File, base); !ok || seen[strings.ToLower(m.Sheet)] {
+			continue
+		}
+		seen[strings.ToLower(m.Sheet)] = true
+		out = append(out, m)
+	}
+	return out
+}
+
+func isWorkbook(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".xlsx")
+}
+
+// importSheet streams one workbook sheet through m.
+func importSheet(ctx context.Context, imp db.DatasetImport, path string, m SheetMapping, opts Options, report *FileReport) error {
+	wb, err := openWorkbook(path)
+	if err != nil {
+		return err
+	}
+	defer wb.Close()
+	sheet, err := wb.sheet(m.Sheet)
+	if err != nil {
+		return err
+	}
+	report.Sheet = sheet.Name
+
+	if m.Layout == layoutProfile {
+		var names, values []string
+		count := make(map[string]int)
+		err := wb.eachRow(sheet, func(_ int, cells []string) error {
+			if len(cells) < 3 || strings.TrimSpace(cells[1]) == "" {
+				return nil
+			}
+			// Repeated item names get a numeric suffix so none are lost
+			name := strings.TrimSpace(cells[1])
+			if count[name]++; count[name] > 1 {
+				name += " " + strconv.Itoa(count[name])
+			}
+			names, values = append(names, name), append(values, cells[2])
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		header, index := newHeader(names)
+		return report.outcome(1, m.appendRow(imp, &Record{Line: 1, header: header, index: index, fields: values}, opts))
+	}
+
+	var header []string
+	var index map[string]int
+	return wb.eachRow(sheet, func(line int, cells []string) error {
+		if header == nil {
+			header, index = newHeader(cells)
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if blank(cells) {
+			return nil
+		}
+		return report.outcome(line, m.appendRow(imp, &Record{Line: line, header: header, index: index, fields: cells}, opts))
+	})
+}
+
+func (m SheetMapping) appendRow(imp db.DatasetImport, r *Record, opts Options) error {
+	rec, err := m.mapRecord(r, opts)
+	if err != nil {
+		return err
+	}
+	return appendDataset(imp, rec)
+}
+
+func blank(cells []string) bool {
+	for _, c := range cells {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// mapRecord converts one sheet row according to the mapping.
+func (m SheetMapping) mapRecord(r *Record, opts Options) (db.DatasetRecord, error) {
+	name := r.Get(m.Name)
+	rec := db.DatasetRecord{Type: m.Type, Name: m.NamePrefix + name, Unit: m.Unit}
+	if name == "" {
+		return rec, reject("missing %s", m.Name)
+	}
+
+	var err error
+	if m.Value != "" {
+		if rec.Value, err = r.Float(m.Value); err != nil {
+			return rec, err
+		}
+	}
+	if len(m.Metadata) == 0 {
+		rec.Metadata = r.Metadata(m.Lat, m.Lon)
+	} else {
+		rec.Metadata = make(map[string]interface{}, len(m.Metadata))
+		for _, col := range m.Metadata {
+			if v := r.Get(col); v != "" {
+				rec.Metadata[snakeCase(col)] = scalar(v)
+			}
+		}
+	}
+
+	switch {
+	case m.Lat != "":
+		rec.Lat, rec.Lon, err = r.Coordinates(m.Lat, m.Lon)
+		return rec, err
+	case m.Join != "":
+		key := joinKey(r.Get(m.Join))
+		if key == "" {
+			return rec, opts.placeWithoutCoordinates(r, &rec)
+		}
+		lat, lon, err := opts.geocode(key + m.JoinSuffix)
+		if err != nil {
+			if opts.FallbackLocation != nil {
+				return rec, opts.placeWithoutCoordinates(r, &rec)
+			}
+			return rec, reject("could not geocode %s %q", m.Join, key)
+		}
+		rec.Lat, rec.Lon = lat, lon
+		rec.Metadata["location_source"] = "geocoded"
+		return rec, nil
+	default:
+		return rec, opts.placeWithoutCoordinates(r, &rec)
+	}
+}
+
+// joinKey drops a trailing parenthesised qualifier: "Wonthaggi (Catchment)" -> "Wonthaggi".
+func joinKey(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.HasSuffix(v, ")") {
+		if i := strings.LastIndex(v, "("); i > 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+	}
+	return v
+}
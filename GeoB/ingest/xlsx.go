@@ -0,0 +1,295 @@
+// xlsx.go
+//
+// Minimal streaming reader for Office Open XML workbooks (.xlsx)
+// Compliance Level: Moderate
+//
+// - Uses only archive/zip and encoding/xml; no spreadsheet dependency
+// - Sheets are resolved by name through workbook.xml and its relationships
+// - Rows are streamed one at a time, so large sheets are not held in memory
+// - Cell values are returned as text: shared and inline strings, numbers as
+//   stored (e.g. "7.39E-2"), booleans as TRUE/FALSE, formula results as cached
+//
+// NOTE: Date cells are returned as Excel serial numbers; number formats and
+// styles are not interpreted
+
+package ingest
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// workbook is an open .xlsx file.
+type workbook struct {
+	zip     *zip.ReadCloser
+	strings []string
+	// sheets lists the sheets in workbook order
+	sheets []sheetEntry
+}
+
+// sheetEntry is a sheet name and its worksheet part in the zip.
+type sheetEntry struct {
+	Name   string
+	Path   string
+	Hidden bool
+}
+
+func openWorkbook(file string) (*workbook, error) {
+	z, err := zip.OpenReader(file)
+	if err != nil {
+		return nil, err
+	}
+	wb := &workbook{zip: z}
+	if err := wb.readSheets(); err != nil {
+		z.Close()
+		return nil, err
+	}
+	if err := wb.readSharedStrings(); err != nil {
+		z.Close()
+		return nil, err
+	}
+	return wb, nil
+}
+
+func (wb *workbook) Close() error {
+	return wb.zip.Close()
+}
+
+// sheet returns the named sheet, or the first visible sheet when name is "".
+func (wb *workbook) sheet(name string) (sheetEntry, error) {
+	for _, s := range wb.sheets {
+		if (name == "" && !s.Hidden) || (name != "" && strings.EqualFold(s.Name, name)) {
+			return s, nil
+		}
+	}
+	if name == "" {
+		return sheetEntry{}, fmt.Errorf("workbook has no visible sheets")
+	}
+	return sheetEntry{}, fmt.Errorf("sheet %q not found", name)
+}
+
+func (wb *workbook) decodeXML(name string, v interface{}) error {
+	f, err := wb.zip.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return xml.NewDecoder(f).Decode(v)
+}
+
+func (wb *workbook) readSheets() error {
+	var book struct {
+		Sheets []struct {
+			Name  string `xml:"name,attr"`
+			State string `xml:"state,attr"`
+			RID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := wb.decodeXML("xl/workbook.xml", &book); err != nil {
+		return fmt.Errorf("read workbook.xml: %w", err)
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := wb.decodeXML("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return fmt.Errorf("read workbook relationships: %w", err)
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, r := range rels.Relationships {
+		// Targets are relative to xl/ unless absolute within the package
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+	for _, s := range book.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			return fmt.Errorf("sheet %q has no relationship %s", s.Name, s.RID)
+		}
+		wb.sheets = append(wb.sheets, sheetEntry{Name: s.Name, Path: target, Hidden: s.State != "" && s.State != "visible"})
+	}
+	return nil
+}
+
+// readSharedStrings loads the shared string table; rich text runs are joined
+// and phonetic hints (rPh) are dropped.
+func (wb *workbook) readSharedStrings() error {
+	f, err := wb.zip.Open("xl/sharedStrings.xml")
+	if err != nil {
+		// Workbooks with only numbers have no shared strings
+		return nil
+	}
+	defer f.Close()
+
+	dec := xml.NewDecoder(f)
+	var b strings.Builder
+	inText, phonetic := false, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read sharedStrings.xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				b.Reset()
+			case "rPh":
+				phonetic++
+			case "t":
+				inText = phonetic == 0
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				wb.strings = append(wb.strings, b.String())
+			case "rPh":
+				phonetic--
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+}
+
+// xlsxCell is one <c> element of a worksheet.
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline struct {
+		T    []string `xml:"t"`
+		Runs []string `xml:"r>t"`
+	} `xml:"is"`
+}
+
+type xlsxRow struct {
+	Number int        `xml:"r,attr"`
+	Cells  []xlsxCell `xml:"c"`
+}
+
+// eachRow streams the rows of a sheet, calling fn with the 1-based row number
+// and the cell texts indexed by column (gaps are filled with "").
+func (wb *workbook) eachRow(sheet sheetEntry, fn func(line int, cells []string) error) error {
+	f, err := wb.zip.Open(sheet.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := xml.NewDecoder(f)
+	line := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read sheet %q: %w", sheet.Name, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRow
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return fmt.Errorf("read sheet %q: %w", sheet.Name, err)
+		}
+		if row.Number > 0 {
+			line = row.Number
+		} else {
+			line++
+		}
+		cells, err := wb.cellValues(row.Cells)
+		if err != nil {
+			return fmt.Errorf("sheet %q row %d: %w", sheet.Name, line, err)
+		}
+		if err := fn(line, cells); err != nil {
+			return err
+		}
+	}
+}
+
+func (wb *workbook) cellValues(cells []xlsxCell) ([]string, error) {
+	var out []string
+	for i, c := range cells {
+		col := i
+		if c.Ref != "" {
+			var err error
+			if col, err = columnIndex(c.Ref); err != nil {
+				return nil, err
+			}
+		}
+		for len(out) <= col {
+			out = append(out, "")
+		}
+		v, err := wb.cellText(c)
+		if err != nil {
+			return nil, err
+		}
+		out[col] = v
+	}
+	return out, nil
+}
+
+func (wb *workbook) cellText(c xlsxCell) (string, error) {
+	switch c.Type {
+	case "s":
+		if c.Value == "" {
+			return "", nil
+		}
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(wb.strings) {
+			return "", fmt.Errorf("cell %s: invalid shared string index %q", c.Ref, c.Value)
+		}
+		return wb.strings[i], nil
+	case "inlineStr":
+		return strings.Join(append(c.Inline.T, c.Inline.Runs...), ""), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "e":
+		// Error values such as #N/A are treated as empty
+		return "", nil
+	default:
+		return c.Value, nil
+	}
+}
+
+// columnIndex converts a cell reference such as "AB12" to a 0-based column.
+func columnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r >= 'A' && r <= 'Z' {
+			col = col*26 + int(r-'A'+1)
+			n++
+			continue
+		}
+		break
+	}
+	// XFD is the last column Excel allows
+	if n == 0 || col > 16384 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
@@ -2,36 +2,98 @@ package main
 
 import (
 	"GeoGO/api"
+	"GeoGO/api/geocoding"
+	"GeoGO/config"
 	"GeoGO/db"
+	"flag"
 	"log"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	db.InitDB()
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(os.Args[2:])
+		return
+	}
+
+	configPath := flag.String("config", "", "path to a YAML or TOML config file (default $GEOGO_CONFIG)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("❌ ", err)
+	}
+
+	if err := geocoding.Init(cfg.Geocoder, cfg.Redis); err != nil {
+		log.Fatal("❌ Geocoder initialisation failed:", err)
+	}
+	db.InitDB(cfg.Database)
 	r := gin.Default()
 
 	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"http://localhost:3000", "http://127.0.0.1:3000"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	r.Use(cors.New(config))
+	corsConfig := cors.DefaultConfig()
+	if len(cfg.Server.AllowOrigins) == 1 && cfg.Server.AllowOrigins[0] == "*" {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = cfg.Server.AllowOrigins
+	}
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	r.Use(cors.New(corsConfig))
 
 	// Legacy meteorite endpoints (backward compatibility)
 	r.GET("/meteorites", api.GetAllMeteorites)
+	r.POST("/meteorites", api.GetAllMeteorites)
 	r.GET("/meteorites/largest", api.GetLargestMeteorites)
 	r.GET("/meteorites/nearby", api.GetNearbyMeteorites)
 	r.GET("/meteorites/location", api.GetMeteoriteLocation)
 
 	// New unified dataset endpoints
 	r.GET("/datasets", api.GetDatasets)
+	r.POST("/datasets", api.GetDatasets)
 	r.GET("/datasets/types", api.GetDatasetTypes)
+	r.GET("/datasets/nearest", api.GetNearestDatasets)
 	r.GET("/datasets/stats/:type", api.GetDatasetStats)
+	r.POST("/datasets/stats/:type", api.GetDatasetStats)
 	r.GET("/datasets/:type", api.GetDatasetsByType)
+	r.GET("/datasets/:type/clusters", api.GetDatasetClusters)
+	r.GET("/datasets/:type/bins", api.GetDatasetBins)
+	r.POST("/datasets/:type/bins", api.GetDatasetBins)
+	r.GET("/datasets/:type/timeseries", api.GetDatasetTimeseries)
+	r.POST("/datasets/:type", api.GetDatasetsByType)
+
+	// Point-in-polygon lookup across area layers
+	r.GET("/lookup", api.LookupPoint)
+
+	// Cross-type spatial analysis
+	r.GET("/analysis/join", api.JoinDatasets)
+	r.POST("/analysis/join", api.JoinDatasets)
+
+	// Climate station projections and interpolation
+	r.GET("/climate/stations/:stn_id/projections", api.GetClimateProjections)
+	r.GET("/climate/interpolate", api.InterpolateClimate)
+
+	// Wind observations
+	r.GET("/wind/stations", api.GetWindStations)
+	r.GET("/wind/stations/:location/series", api.GetWindSeries)
+	r.GET("/wind/stations/:location/rose", api.GetWindRose)
+
+	// Drainage pipe network
+	r.GET("/infrastructure/pipes/trace", api.TracePipes)
+	r.GET("/infrastructure/pipes/qa", api.GetPipeQA)
+	r.GET("/infrastructure/pipes/:id/hydraulics", api.GetPipeHydraulics)
+
+	// Vector tiles (/tiles/:type/:z/:x/:y.mvt)
+	r.GET("/tiles/:type/:z/:x/:y", api.GetDatasetTile)
+
+	// Heatmap raster tiles (/heatmap/:type/:z/:x/:y.png)
+	r.GET("/heatmap/:type/:z/:x/:y", api.GetHeatmapTile)
 
-	log.Println("🚀 Server running on http://localhost:8080")
-	r.Run(":8080")
+	log.Printf("🚀 Server running on %s", cfg.Server.Addr)
+	if err := r.Run(cfg.Server.Addr); err != nil {
+		log.Fatal("❌ Server stopped:", err)
+	}
 }
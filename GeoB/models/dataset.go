@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"encoding/json"
+	"slices"
 	"time"
 )
 
@@ -16,8 +17,25 @@ const (
 	DatasetTypeVegetation     DatasetType = "vegetation"
 	DatasetTypeInfrastructure DatasetType = "infrastructure"
 	DatasetTypeFire           DatasetType = "fire"
+	DatasetTypeCatchment      DatasetType = "catchment"
 )
 
+// DatasetTypes lists every dataset type the importers write.
+var DatasetTypes = []DatasetType{
+	DatasetTypeMeteorite,
+	DatasetTypeClimate,
+	DatasetTypeWind,
+	DatasetTypeVegetation,
+	DatasetTypeInfrastructure,
+	DatasetTypeFire,
+	DatasetTypeCatchment,
+}
+
+// Valid reports whether t is one of DatasetTypes.
+func (t DatasetType) Valid() bool {
+	return slices.Contains(DatasetTypes, t)
+}
+
 // Dataset represents a unified geospatial data point
 type Dataset struct {
 	ID          int         `db:"id" json:"id"`
@@ -26,6 +44,10 @@ type Dataset struct {
 	Lat         float64     `db:"lat" json:"lat"`
 	Lon         float64     `db:"lon" json:"lon"`
 
+	// Geometry is the GeoJSON geometry of non-point rows (lines, polygons and
+	// Multi* shapes); NULL for points. Lat/Lon then hold a point on the shape.
+	Geometry sql.NullString `db:"geometry" json:"-"`
+
 	// Common fields
 	Value     sql.NullFloat64 `db:"value" json:"-"`
 	Unit      sql.NullString  `db:"unit" json:"-"`
@@ -44,15 +66,49 @@ type Dataset struct {
 
 // MarshalJSON implements custom JSON marshaling for Dataset
 func (d Dataset) MarshalJSON() ([]byte, error) {
-	type Alias Dataset // Avoid recursive marshaling
+	return json.Marshal(d.Fields())
+}
+
+// Fields returns the flat JSON representation of the row, omitting NULLs.
+// Callers may add fields before marshaling.
+func (d Dataset) Fields() map[string]interface{} {
+	output := d.properties()
+	output["id"] = d.ID
+	output["lat"] = d.Lat
+	output["lon"] = d.Lon
+	output["geometry"] = d.Shape()
+	if d.Metadata.Valid {
+		output["metadata"] = d.Metadata.String
+	}
+	return output
+}
+
+// Feature renders the dataset row as a GeoJSON feature with parsed metadata.
+func (d Dataset) Feature() Feature {
+	props := d.properties()
+	if d.Metadata.Valid {
+		props["metadata"] = parseMetadata(d.Metadata.String)
+	}
+	return Feature{Type: "Feature", ID: d.ID, Geometry: d.Shape(), Properties: props}
+}
 
-	// Create a map for the JSON output
+// Shape returns the row's geometry: the stored shape, or a Point at Lat/Lon.
+func (d Dataset) Shape() Geometry {
+	if d.Geometry.Valid {
+		var g Geometry
+		if err := json.Unmarshal([]byte(d.Geometry.String), &g); err == nil && g.Type != "" {
+			return g
+		}
+	}
+	return PointGeometry(d.Lat, d.Lon)
+}
+
+// properties collects the non-spatial fields, omitting NULLs.
+// Metadata is left to the caller since its encoding differs per output format.
+func (d Dataset) properties() map[string]interface{} {
 	output := map[string]interface{}{
-		"id":           d.ID,
 		"dataset_type": d.DatasetType,
 		"name":         d.Name,
-		"lat":          d.Lat,
-		"lon":          d.Lon,
 	}
 
 	// Add nullable fields only if they are valid
@@ -65,9 +121,6 @@ func (d Dataset) MarshalJSON() ([]byte, error) {
 	if d.Timestamp != nil {
 		output["timestamp"] = d.Timestamp
 	}
-	if d.Metadata.Valid {
-		output["metadata"] = d.Metadata.String
-	}
 	if d.Recclass.Valid {
 		output["recclass"] = d.Recclass.String
 	}
@@ -83,8 +136,7 @@ func (d Dataset) MarshalJSON() ([]byte, error) {
 	if d.Fall.Valid {
 		output["fall"] = d.Fall.String
 	}
-
-	return json.Marshal(output)
+	return output
 }
 
 // DatasetInfo represents metadata about available datasets
@@ -103,15 +155,39 @@ type ClimateData struct {
 	StationID   string  `db:"station_id" json:"station_id"`
 	Lat         float64 `db:"lat" json:"lat"`
 	Lon         float64 `db:"lon" json:"lon"`
-	Annual      float64 `db:"annual" json:"annual"`
-	DJF         float64 `db:"djf" json:"djf"` // Summer
-	MAM         float64 `db:"mam" json:"mam"` // Autumn
-	JJA         float64 `db:"jja" json:"jja"` // Winter
-	SON         float64 `db:"son" json:"son"` // Spring
-	Model       string  `db:"model" json:"model"`
-	RCP         string  `db:"rcp" json:"rcp"`
+	// Period values are nil when the source leaves them blank
+	Annual      *float64 `db:"annual" json:"annual"`
+	DJF         *float64 `db:"djf" json:"djf"` // Summer
+	MAM         *float64 `db:"mam" json:"mam"` // Autumn
+	JJA         *float64 `db:"jja" json:"jja"` // Winter
+	SON         *float64 `db:"son" json:"son"` // Spring
+	Model       string   `db:"model" json:"model"`
+	RCP         string   `db:"rcp" json:"rcp"`
+	Ensemble    string   `db:"ensemble" json:"ensemble,omitempty"`
+	Climatology string   `db:"climatology" json:"climatology"` // 20-year period, e.g. 2080-2099
+	Variable    string   `db:"variable" json:"variable"`       // CSIRO code, see ClimateVariables
+	Unit        string   `db:"unit" json:"unit"`
+}
+
+// ClimateVariables maps the climate_type stored in climate row metadata to
+// the CSIRO variable code of its source file.
+var ClimateVariables = map[string]string{
+	"avg_temperature": "tas",
+	"max_temperature": "tasmax",
+	"min_temperature": "tasmin",
+	"humidity":        "hurs",
+	"evaporation":     "pan-evap",
 }
 
+// ClimateSeasons and ClimateMonths are the period columns of the CSIRO
+// station CSVs. Climate rows keep every period in metadata under the
+// lower-cased column name (e.g. "jja", "july"); value holds Annual.
+var (
+	ClimateSeasons = []string{"Annual", "DJF", "MAM", "JJA", "SON", "NDJFMA", "MJJASO"}
+	ClimateMonths  = []string{"January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December"}
+)
+
 // WindData represents wind observation data
 type WindData struct {
 	DateTime              time.Time `db:"date_time" json:"date_time"`
@@ -119,8 +195,8 @@ type WindData struct {
 	Lat                   float64   `db:"lat" json:"lat"`
 	Lon                   float64   `db:"lon" json:"lon"`
 	AverageWindSpeed      float64   `db:"average_wind_speed" json:"average_wind_speed"`
-	GustSpeed             float64   `db:"gust_speed" json:"gust_speed"`
-	WindDirection         float64   `db:"wind_direction" json:"wind_direction"`
+	GustSpeed             *float64  `db:"gust_speed" json:"gust_speed"`
+	WindDirection         *float64  `db:"wind_direction" json:"wind_direction"` // degrees the wind blows from
 	WindDirectionCardinal string    `db:"wind_direction_cardinal" json:"wind_direction_cardinal"`
 }
 
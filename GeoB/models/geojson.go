@@ -0,0 +1,114 @@
+package models
+
+import "encoding/json"
+
+// GeoJSON data model definition (RFC 7946)
+// Compliance Level: High
+// - Positions are [lon, lat] in WGS84
+// - bbox is [minLon, minLat, maxLon, maxLat] over every position of all features
+// - Feature properties carry every non-spatial attribute of the source row
+
+// Geometry is a GeoJSON geometry object.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// Feature is a GeoJSON feature. ID is a number or a string (RFC 7946 3.2)
+// and is left out when nil, so features without an identity never share one.
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   Geometry               `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// FeatureCollection is a GeoJSON feature collection with an optional bbox.
+// NextCursor is a foreign member used by paginated listings.
+type FeatureCollection struct {
+	Type       string    `json:"type"`
+	BBox       []float64 `json:"bbox,omitempty"`
+	Features   []Feature `json:"features"`
+	NextCursor *string   `json:"next_cursor,omitempty"`
+}
+
+// Featurer is implemented by rows that can be rendered as GeoJSON features.
+type Featurer interface {
+	Feature() Feature
+}
+
+// PointGeometry returns a GeoJSON Point.
+func PointGeometry(lat, lon float64) Geometry {
+	return Geometry{Type: "Point", Coordinates: []float64{lon, lat}}
+}
+
+// PolygonGeometry returns a GeoJSON Polygon from [lon, lat] rings, the
+// exterior first.
+func PolygonGeometry(rings ...[][]float64) Geometry {
+	return Geometry{Type: "Polygon", Coordinates: rings}
+}
+
+// NewFeatureCollection wraps rows as features and computes the collection bbox.
+func NewFeatureCollection[T Featurer](rows []T) FeatureCollection {
+	fc := FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, len(rows))}
+	for _, row := range rows {
+		f := row.Feature()
+		fc.Features = append(fc.Features, f)
+		eachPosition(f.Geometry.Coordinates, fc.extend)
+	}
+	return fc
+}
+
+// eachPosition calls fn for every [lon, lat] position in a coordinates
+// value, whether built in Go ([]float64, [][][]float64) or decoded from JSON
+// ([]interface{}).
+func eachPosition(coords interface{}, fn func(lon, lat float64)) {
+	switch c := coords.(type) {
+	case []float64:
+		if len(c) >= 2 {
+			fn(c[0], c[1])
+		}
+	case [][]float64:
+		for _, part := range c {
+			eachPosition(part, fn)
+		}
+	case [][][]float64:
+		for _, part := range c {
+			eachPosition(part, fn)
+		}
+	case []interface{}:
+		if len(c) >= 2 {
+			lon, okLon := c[0].(float64)
+			lat, okLat := c[1].(float64)
+			if okLon && okLat {
+				fn(lon, lat)
+				return
+			}
+		}
+		for _, part := range c {
+			eachPosition(part, fn)
+		}
+	}
+}
+
+// extend grows the collection bbox to include (lon, lat).
+func (fc *FeatureCollection) extend(lon, lat float64) {
+	if fc.BBox == nil {
+		fc.BBox = []float64{lon, lat, lon, lat}
+		return
+	}
+	fc.BBox[0] = min(fc.BBox[0], lon)
+	fc.BBox[1] = min(fc.BBox[1], lat)
+	fc.BBox[2] = max(fc.BBox[2], lon)
+	fc.BBox[3] = max(fc.BBox[3], lat)
+}
+
+// parseMetadata decodes a JSON metadata column, falling back to the raw
+// string when it is not valid JSON.
+func parseMetadata(raw string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return raw
+	}
+	return v
+}
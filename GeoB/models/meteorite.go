@@ -17,3 +17,20 @@ type Meteorite struct {
 	Nametype string  `db:"nametype" json:"nametype"`
 	Fall     string  `db:"fall" json:"fall"`
 }
+
+// Feature renders the meteorite as a GeoJSON Point feature.
+func (m Meteorite) Feature() Feature {
+	return Feature{
+		Type:     "Feature",
+		ID:       m.ID,
+		Geometry: PointGeometry(m.Lat, m.Lon),
+		Properties: map[string]interface{}{
+			"name":     m.Name,
+			"recclass": m.Recclass,
+			"mass":     m.Mass,
+			"year":     m.Year,
+			"nametype": m.Nametype,
+			"fall":     m.Fall,
+		},
+	}
+}
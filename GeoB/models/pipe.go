@@ -0,0 +1,34 @@
+package models
+
+// Pipe is one drainage pipe: a directed edge from an upstream pit to a
+// downstream pit in the stormwater network.
+// Measurements are nil when the asset register has no value (or records 0,
+// which the register uses for "not surveyed").
+type Pipe struct {
+	ID            int      `db:"id" json:"id"`
+	CompKey       int      `db:"compkey" json:"compkey"`
+	UnitID        string   `db:"pipe_unit_id" json:"pipe_unit_id,omitempty"`
+	FromPit       string   `db:"from_pit" json:"from_pit"`
+	ToPit         string   `db:"to_pit" json:"to_pit"`
+	UnitType      string   `db:"unit_type" json:"unit_type"`
+	Catchment     string   `db:"catchment" json:"catchment,omitempty"`
+	SubCatchment  string   `db:"sub_catchment" json:"sub_catchment,omitempty"`
+	Material      string   `db:"material" json:"material,omitempty"`
+	Shape         string   `db:"shape" json:"shape,omitempty"`
+	ParallelLines int      `db:"parallel_lines" json:"parallel_lines"`
+	DiameterMM    *float64 `db:"diameter_mm" json:"diameter_mm,omitempty"`
+	HeightMM      *float64 `db:"height_mm" json:"height_mm,omitempty"`
+	LengthM       *float64 `db:"length_m" json:"length_m,omitempty"`
+	UpInvert      *float64 `db:"up_invert" json:"up_invert,omitempty"`
+	DownInvert    *float64 `db:"down_invert" json:"down_invert,omitempty"`
+	Grade         *float64 `db:"grade" json:"grade,omitempty"`
+	AverageDepth  *float64 `db:"average_depth" json:"average_depth,omitempty"`
+}
+
+// Length returns the pipe length in metres, or 0 when unknown.
+func (p Pipe) Length() float64 {
+	if p.LengthM == nil {
+		return 0
+	}
+	return *p.LengthM
+}
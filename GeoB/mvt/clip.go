@@ -0,0 +1,130 @@
+// clip.go
+//
+// Clipping of lines and polygon rings to the buffered tile square
+// Compliance Level: Moderate
+//
+// - Lines use Liang-Barsky per segment; a line leaving and re-entering the
+//   square becomes several parts
+// - Rings use Sutherland-Hodgman against each edge of the square; a concave
+//   ring cut into pieces keeps connecting edges along the border, which
+//   renderers fill correctly
+//
+// NOTE: Works on float tile coordinates before quantisation so clipped
+// vertices land on the border exactly
+
+package mvt
+
+// clipLine returns the parts of line inside the square [lo, hi] on both axes.
+func clipLine(line Path, bounds [2]float64) []Path {
+	lo, hi := bounds[0], bounds[1]
+	var parts []Path
+	var current Path
+	for i := 1; i < len(line); i++ {
+		a, b, ok := clipSegment(line[i-1], line[i], lo, hi)
+		if !ok {
+			if len(current) > 0 {
+				parts = append(parts, current)
+				current = nil
+			}
+			continue
+		}
+		if len(current) == 0 || current[len(current)-1] != a {
+			if len(current) > 0 {
+				parts = append(parts, current)
+			}
+			current = Path{a}
+		}
+		current = append(current, b)
+		// The segment left the square: close this part
+		if b != line[i] {
+			parts = append(parts, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// clipSegment clips a-b to the square (Liang-Barsky).
+func clipSegment(a, b [2]float64, lo, hi float64) ([2]float64, [2]float64, bool) {
+	t0, t1 := 0.0, 1.0
+	d := [2]float64{b[0] - a[0], b[1] - a[1]}
+	for axis := 0; axis < 2; axis++ {
+		for _, edge := range [2]struct{ p, q float64 }{
+			{-d[axis], a[axis] - lo},
+			{d[axis], hi - a[axis]},
+		} {
+			if edge.p == 0 {
+				if edge.q < 0 {
+					return a, b, false
+				}
+				continue
+			}
+			t := edge.q / edge.p
+			if edge.p < 0 {
+				t0 = max(t0, t)
+			} else {
+				t1 = min(t1, t)
+			}
+		}
+	}
+	if t0 > t1 {
+		return a, b, false
+	}
+	at := func(t float64) [2]float64 { return [2]float64{a[0] + t*d[0], a[1] + t*d[1]} }
+	ca, cb := a, b
+	if t0 > 0 {
+		ca = at(t0)
+	}
+	if t1 < 1 {
+		cb = at(t1)
+	}
+	return ca, cb, true
+}
+
+// clipRing clips a ring to the square (Sutherland-Hodgman).
+func clipRing(ring Path, bounds [2]float64) Path {
+	lo, hi := bounds[0], bounds[1]
+	out := ring
+	for axis := 0; axis < 2; axis++ {
+		for _, keepAbove := range []bool{true, false} {
+			limit := hi
+			if keepAbove {
+				limit = lo
+			}
+			inside := func(p [2]float64) bool {
+				if keepAbove {
+					return p[axis] >= limit
+				}
+				return p[axis] <= limit
+			}
+			in := out
+			out = nil
+			for i := range in {
+				cur, prev := in[i], in[(i+len(in)-1)%len(in)]
+				if inside(cur) {
+					if !inside(prev) {
+						out = append(out, intersect(prev, cur, axis, limit))
+					}
+					out = append(out, cur)
+				} else if inside(prev) {
+					out = append(out, intersect(prev, cur, axis, limit))
+				}
+			}
+			if len(out) == 0 {
+				return nil
+			}
+		}
+	}
+	return out
+}
+
+// intersect returns the point where a-b crosses the line axis = limit.
+func intersect(a, b [2]float64, axis int, limit float64) [2]float64 {
+	t := (limit - a[axis]) / (b[axis] - a[axis])
+	p := [2]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}
+	p[axis] = limit
+	return p
+}
@@ -0,0 +1,358 @@
+// mvt.go
+//
+// Mapbox Vector Tile encoder for GeoGO
+// Encodes point, line and polygon layers into the MVT 2.1 protobuf format
+// without a protobuf dependency.
+// Compliance Level: High
+//
+// - Implements the subset of vector_tile.proto needed for point, line string
+//   and polygon features
+// - Keys and values are de-duplicated per layer as the spec requires
+// - Coordinates are tile-local integers in [0, extent), plus any buffer
+// - Lines and polygons are simplified to the layer Tolerance (see simplify.go),
+//   clipped to the extent plus Buffer (see clip.go), and polygon rings are
+//   rewound to the spec's winding order
+//
+// Specification: https://github.com/mapbox/vector-tile-spec/tree/master/2.1
+//
+// NOTE: Only used by storage backends without ST_AsMVT; PostGIS encodes tiles itself
+
+package mvt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+)
+
+// DefaultExtent is the tile coordinate range used by most renderers.
+const DefaultExtent = 4096
+
+// Geometry types from vector_tile.proto.
+const (
+	geomPoint      = 1
+	geomLineString = 2
+	geomPolygon    = 3
+)
+
+// Geometry commands.
+const (
+	cmdMoveTo    = 1
+	cmdLineTo    = 2
+	cmdClosePath = 7
+)
+
+// Path is a sequence of tile coordinates. Polygon rings may repeat their
+// first point at the end; it is dropped when encoding.
+type Path [][2]float64
+
+// Layer is one named layer of a vector tile.
+type Layer struct {
+	Name   string
+	Extent uint32
+	// Buffer is how far lines and polygons may extend past the tile edge
+	Buffer uint32
+	// Tolerance is the Douglas-Peucker tolerance of lines and polygons, in
+	// tile units; 0 keeps every vertex
+	Tolerance float64
+
+	features   []feature
+	keys       []string
+	keyIndex   map[string]uint32
+	values     []interface{}
+	valueIndex map[interface{}]uint32
+}
+
+type feature struct {
+	id       uint64
+	geomType uint64
+	tags     []uint32
+	geometry []uint32
+}
+
+// NewLayer returns an empty layer.
+func NewLayer(name string, extent uint32) *Layer {
+	return &Layer{
+		Name:       name,
+		Extent:     extent,
+		keyIndex:   make(map[string]uint32),
+		valueIndex: make(map[interface{}]uint32),
+	}
+}
+
+// Len returns the number of features in the layer.
+func (l *Layer) Len() int {
+	return len(l.features)
+}
+
+// AddPoint appends a point feature at tile coordinates (x, y).
+// Property values may be strings, bools, integers, floats or time.Time;
+// nil values are skipped.
+func (l *Layer) AddPoint(id uint64, x, y int, props map[string]interface{}) error {
+	tags, err := l.tags(props)
+	if err != nil {
+		return err
+	}
+	l.features = append(l.features, feature{
+		id:       id,
+		geomType: geomPoint,
+		tags:     tags,
+		geometry: []uint32{command(cmdMoveTo, 1), zigzag(x), zigzag(y)},
+	})
+	return nil
+}
+
+// AddLineString appends a (multi) line string feature and reports whether
+// any part survived clipping.
+func (l *Layer) AddLineString(id uint64, lines []Path, props map[string]interface{}) (bool, error) {
+	var geometry []uint32
+	var cursor [2]int
+	for _, line := range lines {
+		for _, part := range clipLine(simplify(line, l.Tolerance), l.clipBounds()) {
+			pts := quantize(part)
+			if len(pts) < 2 {
+				continue
+			}
+			geometry = appendPath(geometry, &cursor, pts, false)
+		}
+	}
+	return l.addShape(id, geomLineString, geometry, props)
+}
+
+// AddPolygon appends a (multi) polygon feature and reports whether any ring
+// survived clipping. Each polygon is an exterior ring followed by its holes.
+func (l *Layer) AddPolygon(id uint64, polygons [][]Path, props map[string]interface{}) (bool, error) {
+	var geometry []uint32
+	var cursor [2]int
+	for _, polygon := range polygons {
+		for i, ring := range polygon {
+			pts := quantize(clipRing(simplifyRing(ring, l.Tolerance), l.clipBounds()))
+			if len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+				pts = pts[:len(pts)-1]
+			}
+			if len(pts) < 3 {
+				if i == 0 {
+					break // exterior collapsed: drop the polygon with its holes
+				}
+				continue
+			}
+			// Exterior rings have positive area in tile coordinates, holes negative
+			if area := signedArea(pts); (i == 0) != (area > 0) {
+				slices.Reverse(pts)
+			}
+			geometry = appendPath(geometry, &cursor, pts, true)
+		}
+	}
+	return l.addShape(id, geomPolygon, geometry, props)
+}
+
+func (l *Layer) addShape(id, geomType uint64, geometry []uint32, props map[string]interface{}) (bool, error) {
+	if len(geometry) == 0 {
+		return false, nil
+	}
+	tags, err := l.tags(props)
+	if err != nil {
+		return false, err
+	}
+	l.features = append(l.features, feature{id: id, geomType: geomType, tags: tags, geometry: geometry})
+	return true, nil
+}
+
+// clipBounds is the buffered tile square.
+func (l *Layer) clipBounds() [2]float64 {
+	return [2]float64{-float64(l.Buffer), float64(l.Extent + l.Buffer)}
+}
+
+// quantize rounds a path to integer coordinates, dropping repeated points.
+func quantize(path Path) [][2]int {
+	pts := make([][2]int, 0, len(path))
+	for _, p := range path {
+		q := [2]int{int(math.Round(p[0])), int(math.Round(p[1]))}
+		if len(pts) == 0 || pts[len(pts)-1] != q {
+			pts = append(pts, q)
+		}
+	}
+	return pts
+}
+
+// signedArea is the surveyor's formula in tile coordinates (y down).
+func signedArea(pts [][2]int) int {
+	var a int
+	for i := range pts {
+		j := (i + 1) % len(pts)
+		a += pts[i][0]*pts[j][1] - pts[j][0]*pts[i][1]
+	}
+	return a
+}
+
+// appendPath encodes one line or ring, delta-encoding from the cursor.
+func appendPath(geometry []uint32, cursor *[2]int, pts [][2]int, closed bool) []uint32 {
+	for i, p := range pts {
+		switch i {
+		case 0:
+			geometry = append(geometry, command(cmdMoveTo, 1))
+		case 1:
+			geometry = append(geometry, command(cmdLineTo, len(pts)-1))
+		}
+		geometry = append(geometry, zigzag(p[0]-cursor[0]), zigzag(p[1]-cursor[1]))
+		*cursor = p
+	}
+	if closed {
+		geometry = append(geometry, command(cmdClosePath, 1))
+	}
+	return geometry
+}
+
+// tags interns props into the layer's key/value tables. Keys are visited in
+// sorted order so identical input always encodes identically.
+func (l *Layer) tags(props map[string]interface{}) ([]uint32, error) {
+	names := make([]string, 0, len(props))
+	for k, v := range props {
+		if v != nil {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	tags := make([]uint32, 0, 2*len(names))
+	for _, k := range names {
+		v, err := normalizeValue(props[k])
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", k, err)
+		}
+		ki, ok := l.keyIndex[k]
+		if !ok {
+			ki = uint32(len(l.keys))
+			l.keys = append(l.keys, k)
+			l.keyIndex[k] = ki
+		}
+		vi, ok := l.valueIndex[v]
+		if !ok {
+			vi = uint32(len(l.values))
+			l.values = append(l.values, v)
+			l.valueIndex[v] = vi
+		}
+		tags = append(tags, ki, vi)
+	}
+	return tags, nil
+}
+
+// normalizeValue maps Go values onto the MVT value kinds (string, double, sint, bool).
+func normalizeValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case string, bool, float64, int64:
+		return t, nil
+	case int:
+		return int64(t), nil
+	case int32:
+		return int64(t), nil
+	case float32:
+		return float64(t), nil
+	case time.Time:
+		return t.Format(time.RFC3339), nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// Encode serialises the layers as a vector tile. Empty layers are omitted.
+func Encode(layers ...*Layer) []byte {
+	var tile buffer
+	for _, l := range layers {
+		if len(l.features) > 0 {
+			tile.message(3, l.encode())
+		}
+	}
+	return tile
+}
+
+func (l *Layer) encode() []byte {
+	var b buffer
+	b.uint(15, 2) // version
+	b.message(1, []byte(l.Name))
+	for _, f := range l.features {
+		var fb buffer
+		fb.uint(1, f.id)
+		fb.packed(2, f.tags)
+		fb.uint(3, f.geomType)
+		fb.packed(4, f.geometry)
+		b.message(2, fb)
+	}
+	for _, k := range l.keys {
+		b.message(3, []byte(k))
+	}
+	for _, v := range l.values {
+		b.message(4, encodeValue(v))
+	}
+	b.uint(5, uint64(l.Extent))
+	return b
+}
+
+func encodeValue(v interface{}) []byte {
+	var b buffer
+	switch t := v.(type) {
+	case string:
+		b.message(1, []byte(t))
+	case float64:
+		b.double(3, t)
+	case int64:
+		b.uint(6, uint64((t<<1)^(t>>63)))
+	case bool:
+		n := uint64(0)
+		if t {
+			n = 1
+		}
+		b.uint(7, n)
+	}
+	return b
+}
+
+func command(id, count int) uint32 {
+	return uint32(id&0x7) | uint32(count)<<3
+}
+
+func zigzag(n int) uint32 {
+	return uint32((int32(n) << 1) ^ (int32(n) >> 31))
+}
+
+// buffer is a minimal protobuf writer.
+type buffer []byte
+
+func (b *buffer) varint(v uint64) {
+	*b = binary.AppendUvarint(*b, v)
+}
+
+func (b *buffer) key(field, wire int) {
+	b.varint(uint64(field<<3 | wire))
+}
+
+// uint writes a varint field (wire type 0).
+func (b *buffer) uint(field int, v uint64) {
+	b.key(field, 0)
+	b.varint(v)
+}
+
+// double writes a 64-bit field (wire type 1).
+func (b *buffer) double(field int, v float64) {
+	b.key(field, 1)
+	*b = binary.LittleEndian.AppendUint64(*b, math.Float64bits(v))
+}
+
+// message writes a length-delimited field (wire type 2).
+func (b *buffer) message(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	*b = append(*b, data...)
+}
+
+// packed writes a packed repeated uint32 field.
+func (b *buffer) packed(field int, vs []uint32) {
+	var inner buffer
+	for _, v := range vs {
+		inner.varint(uint64(v))
+	}
+	b.message(field, inner)
+}
@@ -0,0 +1,69 @@
+// simplify.go
+//
+// Vertex thinning of lines and polygon rings before clipping
+// Compliance Level: Moderate
+//
+// - Douglas-Peucker: a vertex is kept when it lies further than the tolerance
+//   from the simplified line; end points are always kept
+// - Rings are simplified as closed paths, so a ring reduced to fewer than
+//   three distinct points is dropped by the encoder
+//
+// NOTE: Matches PostGIS ST_Simplify, which the PostGIS backend applies to the
+// same tolerance in projected metres
+
+package mvt
+
+import "math"
+
+// simplify drops the vertices of path within tolerance tile units of the
+// simplified line.
+func simplify(path Path, tolerance float64) Path {
+	if tolerance <= 0 || len(path) < 3 {
+		return path
+	}
+	keep := make([]bool, len(path))
+	keep[0], keep[len(path)-1] = true, true
+	spans := [][2]int{{0, len(path) - 1}}
+	for len(spans) > 0 {
+		first, last := spans[len(spans)-1][0], spans[len(spans)-1][1]
+		spans = spans[:len(spans)-1]
+		worst, index := 0.0, -1
+		for i := first + 1; i < last; i++ {
+			if d := segmentDistance(path[i], path[first], path[last]); d > worst {
+				worst, index = d, i
+			}
+		}
+		if index >= 0 && worst > tolerance {
+			keep[index] = true
+			spans = append(spans, [2]int{first, index}, [2]int{index, last})
+		}
+	}
+	out := make(Path, 0, len(path))
+	for i, p := range path {
+		if keep[i] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// simplifyRing simplifies a ring, closing it first so the last edge counts.
+func simplifyRing(ring Path, tolerance float64) Path {
+	if tolerance <= 0 || len(ring) == 0 {
+		return ring
+	}
+	if ring[0] != ring[len(ring)-1] {
+		ring = append(ring[:len(ring):len(ring)], ring[0])
+	}
+	return simplify(ring, tolerance)
+}
+
+// segmentDistance is the distance from p to the segment a-b.
+func segmentDistance(p, a, b [2]float64) float64 {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = max(0, min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/l))
+	}
+	return math.Hypot(p[0]-a[0]-t*dx, p[1]-a[1]-t*dy)
+}
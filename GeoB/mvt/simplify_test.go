@@ -0,0 +1,77 @@
+package mvt
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSimplify(t *testing.T) {
+	// An L with a wobble of 0.5 units on each leg
+	l := Path{{0, 0}, {50, 0.5}, {100, 0}, {100, 50}, {99.5, 100}, {100, 200}}
+	tests := []struct {
+		name      string
+		path      Path
+		tolerance float64
+		want      Path
+	}{
+		{"no tolerance", l, 0, l},
+		{"wobble kept", l, 0.1, l},
+		{"wobble dropped", l, 1, Path{{0, 0}, {100, 0}, {100, 200}}},
+		{"corner dropped", l, 100, Path{{0, 0}, {100, 200}}},
+		{"two points", Path{{0, 0}, {1, 1}}, 10, Path{{0, 0}, {1, 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := simplify(tt.path, tt.tolerance); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("simplify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// circle returns a closed ring of n vertices around (c, c).
+func circle(c, r float64, n int) Path {
+	ring := make(Path, 0, n+1)
+	for i := 0; i <= n; i++ {
+		a := 2 * math.Pi * float64(i%n) / float64(n)
+		ring = append(ring, [2]float64{c + r*math.Cos(a), c + r*math.Sin(a)})
+	}
+	return ring
+}
+
+func TestAddPolygonTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		ring      Path
+		tolerance float64
+		added     bool
+		maxPoints int
+	}{
+		{"full detail", circle(2048, 1000, 720), 0, true, 720},
+		{"one pixel", circle(2048, 1000, 720), 16, true, 40},
+		{"smaller than tolerance", circle(2048, 6, 64), 16, false, 0},
+		{"open ring", circle(2048, 1000, 720)[:720], 16, true, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewLayer("test", DefaultExtent)
+			l.Tolerance = tt.tolerance
+			added, err := l.AddPolygon(1, [][]Path{{tt.ring}}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if added != tt.added {
+				t.Fatalf("added = %v, want %v", added, tt.added)
+			}
+			if !added {
+				return
+			}
+			// MoveTo, LineTo(n-1), ClosePath: the LineTo count gives the ring size
+			n := int(l.features[0].geometry[3]>>3) + 1
+			if n < 3 || n > tt.maxPoints {
+				t.Errorf("ring has %d points, want 3-%d", n, tt.maxPoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// network.go
+//
+// Directed drainage network for GeoGO
+// Pits are nodes and pipes are edges from the upstream (From Pit) to the
+// downstream (To Pit) end, as recorded in the asset register.
+// Compliance Level: High
+//
+// - Built in memory from the pipes table; the whole council network is a few
+//   thousand edges
+// - Traces walk breadth-first from a pit and visit each pipe once, so loops in
+//   the register (including pipes from a pit to itself) terminate
+// - Downstream traces end at outfalls: reached pits with no outgoing pipe
+// - Upstream traces end at sources: reached pits with no incoming pipe
+// - A loop the trace cannot leave (e.g. two pits recorded as draining into
+//   each other) is a terminal too; its pits are reported in Loops
+//
+// NOTE: Pit identifiers are matched exactly (e.g. "WcwWint0034MH")
+
+package network
+
+import (
+	"GeoGO/models"
+	"errors"
+	"sort"
+)
+
+// ErrUnknownPit is returned when a pit is not an end of any pipe.
+var ErrUnknownPit = errors.New("unknown pit")
+
+// Direction selects which way a trace follows the pipes.
+type Direction string
+
+const (
+	Downstream Direction = "downstream"
+	Upstream   Direction = "upstream"
+)
+
+// Graph is an immutable pipe network.
+type Graph struct {
+	pipes []models.Pipe
+	byID  map[int]int
+	out   map[string][]int
+	in    map[string][]int
+}
+
+// New builds a graph; pipes without both pit ids are ignored.
+func New(pipes []models.Pipe) *Graph {
+	g := &Graph{byID: make(map[int]int), out: make(map[string][]int), in: make(map[string][]int)}
+	for _, p := range pipes {
+		if p.FromPit == "" || p.ToPit == "" {
+			continue
+		}
+		i := len(g.pipes)
+		g.pipes = append(g.pipes, p)
+		g.byID[p.ID] = i
+		g.out[p.FromPit] = append(g.out[p.FromPit], i)
+		g.in[p.ToPit] = append(g.in[p.ToPit], i)
+	}
+	return g
+}
+
+// Len returns the number of pipes in the graph.
+func (g *Graph) Len() int {
+	return len(g.pipes)
+}
+
+// Pipes returns every pipe in the graph.
+func (g *Graph) Pipes() []models.Pipe {
+	return g.pipes
+}
+
+// Pipe returns the pipe with the given id.
+func (g *Graph) Pipe(id int) (models.Pipe, bool) {
+	i, ok := g.byID[id]
+	if !ok {
+		return models.Pipe{}, false
+	}
+	return g.pipes[i], true
+}
+
+// From returns the pipes leaving a pit (its downstream pipes).
+func (g *Graph) From(pit string) []models.Pipe {
+	return g.collect(g.out[pit])
+}
+
+// Into returns the pipes entering a pit (its upstream pipes).
+func (g *Graph) Into(pit string) []models.Pipe {
+	return g.collect(g.in[pit])
+}
+
+func (g *Graph) collect(indexes []int) []models.Pipe {
+	pipes := make([]models.Pipe, len(indexes))
+	for j, i := range indexes {
+		pipes[j] = g.pipes[i]
+	}
+	return pipes
+}
+
+// HasPit reports whether a pit is the end of at least one pipe.
+func (g *Graph) HasPit(pit string) bool {
+	return len(g.out[pit]) > 0 || len(g.in[pit]) > 0
+}
+
+// Trace is the result of walking the network from one pit.
+type Trace struct {
+	Pit       string
+	Direction Direction
+	// Pipes are in breadth-first order from the start pit
+	Pipes []models.Pipe
+	// Pits is the number of distinct pits reached, including the start
+	Pits int
+	// TotalLength is the summed length of Pipes in metres (unknown lengths count as 0)
+	TotalLength float64
+	// Terminals are the outfalls (downstream) or sources (upstream), sorted
+	Terminals []string
+	// Loops are closed cycles the trace cannot leave; their pits are also Terminals
+	Loops [][]string
+}
+
+// Trace returns every pipe reachable from pit in the given direction.
+func (g *Graph) Trace(pit string, dir Direction) (*Trace, error) {
+	if !g.HasPit(pit) {
+		return nil, ErrUnknownPit
+	}
+	next := g.out
+	if dir == Upstream {
+		next = g.in
+	}
+
+	t := &Trace{Pit: pit, Direction: dir}
+	seenPit := map[string]bool{pit: true}
+	seenPipe := make(map[int]bool)
+	queue := []string{pit}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, i := range next[current] {
+			if seenPipe[i] {
+				continue
+			}
+			seenPipe[i] = true
+			p := g.pipes[i]
+			t.Pipes = append(t.Pipes, p)
+			t.TotalLength += p.Length()
+
+			end := g.end(p, dir)
+			if !seenPit[end] {
+				seenPit[end] = true
+				queue = append(queue, end)
+			}
+		}
+	}
+	t.Pits = len(seenPit)
+
+	for _, component := range g.closedComponents(pit, dir) {
+		t.Terminals = append(t.Terminals, component...)
+		// a closed single pit with pipes only has pipes to itself
+		if len(component) > 1 || len(next[component[0]]) > 0 {
+			t.Loops = append(t.Loops, component)
+		}
+	}
+	sort.Strings(t.Terminals)
+	sort.Slice(t.Loops, func(i, j int) bool { return t.Loops[i][0] < t.Loops[j][0] })
+	return t, nil
+}
+
+// end returns the pit a pipe leads to when walked in dir.
+func (g *Graph) end(p models.Pipe, dir Direction) string {
+	if dir == Upstream {
+		return p.FromPit
+	}
+	return p.ToPit
+}
+
+// closedComponents returns the strongly connected components reachable from
+// pit that have no pipe leaving them (Tarjan's algorithm). A pit with no next
+// pipe is a component of its own. Each component is sorted.
+func (g *Graph) closedComponents(pit string, dir Direction) [][]string {
+	next := g.out
+	if dir == Upstream {
+		next = g.in
+	}
+
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var closed [][]string
+
+	var visit func(v string)
+	visit = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, i := range next[v] {
+			w := g.end(g.pipes[i], dir)
+			if _, done := index[w]; !done {
+				visit(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] != index[v] {
+			return
+		}
+
+		var component []string
+		member := make(map[string]bool)
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component = append(component, w)
+			member[w] = true
+			if w == v {
+				break
+			}
+		}
+		for _, w := range component {
+			for _, i := range next[w] {
+				if !member[g.end(g.pipes[i], dir)] {
+					return
+				}
+			}
+		}
+		sort.Strings(component)
+		closed = append(closed, component)
+	}
+	visit(pit)
+	return closed
+}
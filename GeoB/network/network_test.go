@@ -0,0 +1,173 @@
+package network
+
+import (
+	"GeoGO/models"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// pipe returns a pipe of length 10 m from one pit to another.
+func pipe(id int, from, to string) models.Pipe {
+	length := 10.0
+	return models.Pipe{ID: id, FromPit: from, ToPit: to, LengthM: &length}
+}
+
+func pipeIDs(pipes []models.Pipe) []int {
+	ids := make([]int, len(pipes))
+	for i, p := range pipes {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		name      string
+		pipes     []models.Pipe
+		pit       string
+		dir       Direction
+		ids       []int
+		pits      int
+		terminals []string
+		loops     [][]string
+	}{
+		{
+			name:      "chain downstream",
+			pipes:     []models.Pipe{pipe(1, "A", "B"), pipe(2, "B", "C"), pipe(3, "C", "D")},
+			pit:       "A",
+			dir:       Downstream,
+			ids:       []int{1, 2, 3},
+			pits:      4,
+			terminals: []string{"D"},
+		},
+		{
+			name:      "chain upstream from the middle",
+			pipes:     []models.Pipe{pipe(1, "A", "B"), pipe(2, "B", "C"), pipe(3, "C", "D")},
+			pit:       "C",
+			dir:       Upstream,
+			ids:       []int{2, 1},
+			pits:      3,
+			terminals: []string{"A"},
+		},
+		{
+			name:      "fork to two outfalls",
+			pipes:     []models.Pipe{pipe(1, "A", "B"), pipe(2, "B", "C"), pipe(3, "B", "D"), pipe(4, "D", "E")},
+			pit:       "A",
+			dir:       Downstream,
+			ids:       []int{1, 2, 3, 4},
+			pits:      5,
+			terminals: []string{"C", "E"},
+		},
+		{
+			name:      "fork joins upstream",
+			pipes:     []models.Pipe{pipe(1, "A", "C"), pipe(2, "B", "C"), pipe(3, "C", "D")},
+			pit:       "D",
+			dir:       Upstream,
+			ids:       []int{3, 1, 2},
+			pits:      4,
+			terminals: []string{"A", "B"},
+		},
+		{
+			// A pipe from a pit to itself is walked once and does not trap the trace
+			name:      "self-loop on the way",
+			pipes:     []models.Pipe{pipe(1, "A", "B"), pipe(2, "B", "B"), pipe(3, "B", "C")},
+			pit:       "A",
+			dir:       Downstream,
+			ids:       []int{1, 2, 3},
+			pits:      3,
+			terminals: []string{"C"},
+		},
+		{
+			name:      "self-loop outfall",
+			pipes:     []models.Pipe{pipe(1, "A", "B"), pipe(2, "B", "B")},
+			pit:       "A",
+			dir:       Downstream,
+			ids:       []int{1, 2},
+			pits:      2,
+			terminals: []string{"B"},
+			loops:     [][]string{{"B"}},
+		},
+		{
+			// Two pits recorded as draining into each other (pipes 32-34 of
+			// the drainage pipe register)
+			name: "closed two-pit loop",
+			pipes: []models.Pipe{
+				pipe(32, "WcwWint0004GP", "WcwWint0016MH"),
+				pipe(33, "WcwWint0016MH", "WcwWint0005GP"),
+				pipe(34, "WcwWint0005GP", "WcwWint0016MH"),
+			},
+			pit:       "WcwWint0004GP",
+			dir:       Downstream,
+			ids:       []int{32, 33, 34},
+			pits:      3,
+			terminals: []string{"WcwWint0005GP", "WcwWint0016MH"},
+			loops:     [][]string{{"WcwWint0005GP", "WcwWint0016MH"}},
+		},
+		{
+			// A cycle with an exit is not closed; the trace ends at the exit
+			name:      "cycle with an exit",
+			pipes:     []models.Pipe{pipe(1, "A", "B"), pipe(2, "B", "C"), pipe(3, "C", "A"), pipe(4, "C", "D")},
+			pit:       "A",
+			dir:       Downstream,
+			ids:       []int{1, 2, 3, 4},
+			pits:      4,
+			terminals: []string{"D"},
+		},
+		{
+			name:      "closed cycle and outfall",
+			pipes:     []models.Pipe{pipe(1, "A", "B"), pipe(2, "B", "C"), pipe(3, "C", "D"), pipe(4, "D", "B"), pipe(5, "A", "E")},
+			pit:       "A",
+			dir:       Downstream,
+			ids:       []int{1, 5, 2, 3, 4},
+			pits:      5,
+			terminals: []string{"B", "C", "D", "E"},
+			loops:     [][]string{{"B", "C", "D"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, err := New(tt.pipes).Trace(tt.pit, tt.dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := pipeIDs(tr.Pipes); !reflect.DeepEqual(got, tt.ids) {
+				t.Errorf("pipes = %v, want %v", got, tt.ids)
+			}
+			if tr.Pits != tt.pits {
+				t.Errorf("pits = %d, want %d", tr.Pits, tt.pits)
+			}
+			if want := 10 * float64(len(tt.ids)); tr.TotalLength != want {
+				t.Errorf("total length = %v, want %v", tr.TotalLength, want)
+			}
+			if !reflect.DeepEqual(tr.Terminals, tt.terminals) {
+				t.Errorf("terminals = %v, want %v", tr.Terminals, tt.terminals)
+			}
+			if !reflect.DeepEqual(tr.Loops, tt.loops) {
+				t.Errorf("loops = %v, want %v", tr.Loops, tt.loops)
+			}
+		})
+	}
+}
+
+func TestTraceUnknownPit(t *testing.T) {
+	g := New([]models.Pipe{pipe(1, "A", "B"), {ID: 2, FromPit: "C"}})
+	if g.Len() != 1 {
+		t.Errorf("len = %d, want pipes without both pits ignored", g.Len())
+	}
+	for _, pit := range []string{"X", "C"} {
+		if _, err := g.Trace(pit, Downstream); !errors.Is(err, ErrUnknownPit) {
+			t.Errorf("Trace(%q) error = %v, want ErrUnknownPit", pit, err)
+		}
+	}
+}
+
+func TestTraceStartAtOutfall(t *testing.T) {
+	tr, err := New([]models.Pipe{pipe(1, "A", "B")}).Trace("B", Downstream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.Pipes) != 0 || tr.Pits != 1 || !reflect.DeepEqual(tr.Terminals, []string{"B"}) || tr.Loops != nil {
+		t.Errorf("trace = %+v, want the outfall alone", tr)
+	}
+}
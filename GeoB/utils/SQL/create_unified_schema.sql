@@ -4,6 +4,7 @@
 -- Drop existing tables if they exist
 DROP TABLE IF EXISTS datasets CASCADE;
 DROP TABLE IF EXISTS locations CASCADE;
+DROP TABLE IF EXISTS pipes CASCADE;
 
 -- Create the unified datasets table
 CREATE TABLE datasets (
@@ -24,20 +25,56 @@ CREATE TABLE datasets (
     nametype VARCHAR(50),
     fall VARCHAR(50),
     
-    -- Create spatial index
-    geom GEOMETRY(POINT, 4326)
+    -- Point, LineString, Polygon or a Multi* geometry; lat/lon hold the
+    -- point itself, or a representative point on a line or polygon
+    geom GEOMETRY(GEOMETRY, 4326)
 );
 
 -- Create spatial index for fast geospatial queries
 CREATE INDEX idx_datasets_geom ON datasets USING GIST (geom);
 CREATE INDEX idx_datasets_type ON datasets (dataset_type);
 CREATE INDEX idx_datasets_name ON datasets (name);
+-- Climate projections are looked up by station (/climate/stations/:stn_id/projections)
+CREATE INDEX idx_datasets_station ON datasets ((metadata->>'station_id')) WHERE dataset_type = 'climate';
+
+-- Drainage pipe network (one row per pipe, flowing from_pit -> to_pit)
+-- Loaded by `geogo import` from the INF_DRN_PIPES asset register
+CREATE TABLE pipes (
+    id SERIAL PRIMARY KEY,
+    compkey INTEGER NOT NULL,
+    pipe_unit_id VARCHAR(50),
+    from_pit VARCHAR(50) NOT NULL,
+    to_pit VARCHAR(50) NOT NULL,
+    unit_type VARCHAR(10),
+    catchment VARCHAR(50),
+    sub_catchment VARCHAR(50),
+    material VARCHAR(20),
+    shape VARCHAR(20),
+    parallel_lines INTEGER,
+    diameter_mm DOUBLE PRECISION,
+    height_mm DOUBLE PRECISION,
+    length_m DOUBLE PRECISION,
+    up_invert DOUBLE PRECISION,
+    down_invert DOUBLE PRECISION,
+    grade DOUBLE PRECISION,
+    average_depth DOUBLE PRECISION,
+
+    -- Pipe centreline; NULL until pit coordinates are available
+    geom GEOMETRY(LINESTRING, 4326)
+);
+
+CREATE INDEX idx_pipes_from_pit ON pipes (from_pit);
+CREATE INDEX idx_pipes_to_pit ON pipes (to_pit);
+CREATE INDEX idx_pipes_geom ON pipes USING GIST (geom);
 
 -- Create a function to automatically update the geometry column
+-- Point rows follow lat/lon; lines and polygons keep the geometry they were loaded with
 CREATE OR REPLACE FUNCTION update_dataset_geometry()
 RETURNS TRIGGER AS $$
 BEGIN
-    NEW.geom = ST_SetSRID(ST_MakePoint(NEW.lon, NEW.lat), 4326);
+    IF NEW.geom IS NULL OR GeometryType(NEW.geom) = 'POINT' THEN
+        NEW.geom = ST_SetSRID(ST_MakePoint(NEW.lon, NEW.lat), 4326);
+    END IF;
     RETURN NEW;
 END;
 $$ LANGUAGE plpgsql;
@@ -1,4 +1,5 @@
 import pandas as pd
+import calendar
 import json
 import os
 from datetime import datetime
@@ -131,6 +132,10 @@ class DatasetProcessor:
                     "climate_type": climate_type,
                     "unit": unit
                 }
+                # Keep every season and month so the API can select a period
+                for period in ["NDJFMA", "MJJASO"] + list(calendar.month_name)[1:]:
+                    if period in row and not pd.isna(row[period]):
+                        metadata[period.lower()] = row[period]
                 
                 unified_data.append({
                     "dataset_type": "climate",
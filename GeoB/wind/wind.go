@@ -0,0 +1,155 @@
+// wind.go
+//
+// Wind observation analysis for GeoGO
+// Resamples a station's observations into fixed intervals and bins them into
+// a wind rose.
+// Compliance Level: Moderate
+//
+// - Intervals are aligned to UTC multiples of their length (1h intervals start
+//   on the hour, 1d intervals at midnight UTC); empty intervals are left out
+// - Interval direction is the speed-weighted vector mean, so 350° and 10°
+//   average to 0° rather than 180°; it is undefined when the vectors cancel
+// - Directions are meteorological: the bearing the wind blows from, in degrees
+//
+// NOTE: Observations without a direction still count towards speed and gust,
+// but only take part in a rose when they are calm
+
+package wind
+
+import (
+	"GeoGO/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// cardinals are the 16 compass points, clockwise from north.
+var cardinals = [...]string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+
+// Cardinal returns the 16-point compass name of a direction in degrees.
+func Cardinal(deg float64) string {
+	return cardinals[int(math.Floor(normalize(deg)/22.5+0.5))%len(cardinals)]
+}
+
+// normalize maps deg into [0, 360).
+func normalize(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	// A tiny negative angle rounds up to exactly 360 when shifted
+	if deg >= 360 {
+		deg = 0
+	}
+	return deg
+}
+
+// vectorMean accumulates speed-weighted direction vectors.
+type vectorMean struct{ u, v float64 }
+
+func (m *vectorMean) add(speed, deg float64) {
+	rad := deg * math.Pi / 180
+	m.u += speed * math.Sin(rad)
+	m.v += speed * math.Cos(rad)
+}
+
+// direction returns the mean direction, or nil when the vectors cancel.
+func (m vectorMean) direction() *float64 {
+	if math.Hypot(m.u, m.v) < 1e-9 {
+		return nil
+	}
+	deg := normalize(math.Atan2(m.u, m.v) * 180 / math.Pi)
+	return &deg
+}
+
+// Interval summarises the observations of one resampling interval.
+type Interval struct {
+	Start     time.Time
+	Count     int
+	MeanSpeed float64
+	MaxSpeed  float64
+	MaxGust   *float64 // nil when no observation has a gust
+	Direction *float64 // vector mean; nil without directions or when they cancel
+}
+
+// Resample groups time-ordered observations into intervals of length d.
+func Resample(obs []models.WindData, d time.Duration) []Interval {
+	var out []Interval
+	var mean vectorMean
+	flush := func() {
+		last := &out[len(out)-1]
+		last.MeanSpeed /= float64(last.Count)
+		last.Direction = mean.direction()
+		mean = vectorMean{}
+	}
+	for _, o := range obs {
+		start := o.DateTime.UTC().Truncate(d)
+		if len(out) == 0 || !out[len(out)-1].Start.Equal(start) {
+			if len(out) > 0 {
+				flush()
+			}
+			out = append(out, Interval{Start: start})
+		}
+		in := &out[len(out)-1]
+		in.Count++
+		in.MeanSpeed += o.AverageWindSpeed
+		in.MaxSpeed = max(in.MaxSpeed, o.AverageWindSpeed)
+		if o.GustSpeed != nil && (in.MaxGust == nil || *o.GustSpeed > *in.MaxGust) {
+			g := *o.GustSpeed
+			in.MaxGust = &g
+		}
+		if o.WindDirection != nil {
+			mean.add(o.AverageWindSpeed, *o.WindDirection)
+		}
+	}
+	if len(out) > 0 {
+		flush()
+	}
+	return out
+}
+
+// Sector is one direction slice of a rose.
+type Sector struct {
+	Direction float64 // centre, degrees
+	Count     int
+	Bins      []int // counts per speed bin, as Rose.Edges
+}
+
+// Rose is a direction/speed frequency table.
+type Rose struct {
+	// Edges are the ascending speed bin edges; bin i is [Edges[i], Edges[i+1])
+	// and the last bin is open. Speeds below Edges[0] are calm.
+	Edges   []float64
+	Sectors []Sector
+	Total   int // calm plus binned observations
+	Calm    int
+	Missing int // non-calm observations without a direction
+}
+
+// NewRose bins observations into n direction sectors (centred on north) and
+// the speed bins given by edges.
+func NewRose(obs []models.WindData, n int, edges []float64) Rose {
+	r := Rose{Edges: edges, Sectors: make([]Sector, n)}
+	width := 360 / float64(n)
+	for i := range r.Sectors {
+		r.Sectors[i] = Sector{Direction: float64(i) * width, Bins: make([]int, len(edges))}
+	}
+	for _, o := range obs {
+		if o.AverageWindSpeed < edges[0] {
+			r.Calm++
+			r.Total++
+			continue
+		}
+		if o.WindDirection == nil {
+			r.Missing++
+			continue
+		}
+		s := &r.Sectors[int(normalize(*o.WindDirection+width/2)/width)%n]
+		bin := sort.Search(len(edges), func(i int) bool { return edges[i] > o.AverageWindSpeed }) - 1
+		s.Bins[bin]++
+		s.Count++
+		r.Total++
+	}
+	return r
+}
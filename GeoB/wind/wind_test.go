@@ -0,0 +1,83 @@
+package wind
+
+import (
+	"GeoGO/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func obs(minute int, speed float64, direction *float64) models.WindData {
+	return models.WindData{
+		DateTime:         time.Date(2024, 7, 1, 10, minute, 0, 0, time.UTC),
+		AverageWindSpeed: speed,
+		WindDirection:    direction,
+	}
+}
+
+func deg(v float64) *float64 { return &v }
+
+// angle is the absolute difference between two directions, in degrees.
+func angle(a, b float64) float64 {
+	d := math.Abs(normalize(a - b))
+	return math.Min(d, 360-d)
+}
+
+func TestResampleDirection(t *testing.T) {
+	tests := []struct {
+		name string
+		obs  []models.WindData
+		want *float64 // nil when the direction is undefined
+	}{
+		{"across north", []models.WindData{obs(0, 4, deg(350)), obs(10, 4, deg(10))}, deg(0)},
+		{"speed weighted", []models.WindData{obs(0, 6, deg(350)), obs(10, 2, deg(10))}, deg(354.96)},
+		{"single", []models.WindData{obs(0, 3, deg(90))}, deg(90)},
+		{"opposite cancel", []models.WindData{obs(0, 5, deg(90)), obs(10, 5, deg(270))}, nil},
+		{"calm", []models.WindData{obs(0, 0, deg(180)), obs(10, 0, deg(200))}, nil},
+		{"missing", []models.WindData{obs(0, 3, nil), obs(10, 4, nil)}, nil},
+		// Missing and calm observations do not pull the mean
+		{"mixed", []models.WindData{obs(0, 3, nil), obs(10, 0, deg(180)), obs(20, 2, deg(45))}, deg(45)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := Resample(tt.obs, time.Hour)
+			if len(out) != 1 || out[0].Count != len(tt.obs) {
+				t.Fatalf("intervals = %+v, want one of %d observations", out, len(tt.obs))
+			}
+			got := out[0].Direction
+			switch {
+			case tt.want == nil && got != nil:
+				t.Errorf("direction = %v, want undefined", *got)
+			case tt.want != nil && got == nil:
+				t.Errorf("direction undefined, want %v", *tt.want)
+			case tt.want != nil && (angle(*got, *tt.want) > 0.05 || *got < 0 || *got >= 360):
+				t.Errorf("direction = %v, want %v in [0, 360)", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestResampleIntervals(t *testing.T) {
+	gust := 9.5
+	in := []models.WindData{obs(5, 2, deg(0)), obs(50, 4, deg(0)), obs(70, 3, nil)}
+	in[1].GustSpeed = &gust
+	out := Resample(in, 30*time.Minute)
+	if len(out) != 3 {
+		t.Fatalf("intervals = %+v, want 3", out)
+	}
+	// 10:05 and 10:50 fall in separate half hours; 11:10 starts the next hour
+	for i, want := range []string{"10:00", "10:30", "11:00"} {
+		if got := out[i].Start.Format("15:04"); got != want {
+			t.Errorf("interval %d starts %s, want %s", i, got, want)
+		}
+	}
+	if out[1].MaxGust == nil || *out[1].MaxGust != gust || out[0].MaxGust != nil {
+		t.Errorf("gusts = %v, %v", out[0].MaxGust, out[1].MaxGust)
+	}
+	if out[2].MeanSpeed != 3 || out[2].Direction != nil {
+		t.Errorf("missing direction interval = %+v", out[2])
+	}
+	if Resample(nil, time.Hour) != nil {
+		t.Error("no observations should give no intervals")
+	}
+}